@@ -28,6 +28,51 @@ integration {
     name = "Key Vault Secret"
     slug = "keyvaultsecret"
   }
+  component {
+    type = "data-source"
+    name = "Key Vault Secrets"
+    slug = "keyvaultsecrets"
+  }
+  component {
+    type = "data-source"
+    name = "Key Vault Certificate"
+    slug = "keyvaultcertificate"
+  }
+  component {
+    type = "data-source"
+    name = "Shared Image Version"
+    slug = "sharedimageversion"
+  }
+  component {
+    type = "data-source"
+    name = "Platform Image"
+    slug = "platformimage"
+  }
+  component {
+    type = "data-source"
+    name = "Managed Image"
+    slug = "managedimage"
+  }
+  component {
+    type = "data-source"
+    name = "Virtual Network"
+    slug = "virtualnetwork"
+  }
+  component {
+    type = "data-source"
+    name = "Instance Metadata"
+    slug = "imds"
+  }
+  component {
+    type = "data-source"
+    name = "Storage Blob"
+    slug = "storageblob"
+  }
+  component {
+    type = "data-source"
+    name = "App Configuration"
+    slug = "appconfiguration"
+  }
   component {
     type = "provisioner"
     name = "DTL Artifact"
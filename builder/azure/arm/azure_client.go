@@ -13,6 +13,8 @@ import (
 
 	"net/http"
 
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2021-07-01/skus"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
@@ -20,12 +22,14 @@ import (
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/computerps"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/vaults"
 	networks "github.com/hashicorp/go-azure-sdk/resource-manager/network/2023-09-01"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-09-01/deploymentoperations"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-09-01/deployments"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-09-01/resourcegroups"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-12-01/subscriptions"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
 	"github.com/hashicorp/go-azure-sdk/sdk/client"
 	"github.com/hashicorp/go-azure-sdk/sdk/client/resourcemanager"
@@ -56,6 +60,10 @@ type AzureClient struct {
 	galleryimageversions.GalleryImageVersionsClient
 	galleryimages.GalleryImagesClient
 	virtualmachineimages.VirtualMachineImagesClient
+	permissions.PermissionsClient
+	skus.SkusClient
+	computerps.ComputeRPSClient
+	subscriptions.SubscriptionsClient
 	GiovanniBlobClient giovanniBlobStorageSDK.Client
 	InspectorMaxLength int
 	LastError          azureErrorResponse
@@ -105,6 +113,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	disksClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(disksClient.Client, authOptions.Transport)
 	disksClient.Client.UserAgent = useragent.String(version.AzurePluginVersion.FormattedVersion())
 	disksClient.Client.ResponseMiddlewares = &responseMiddleware
 	disksClient.Client.RequestMiddlewares = &requestMiddleware
@@ -115,6 +124,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	virtualMachinesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(virtualMachinesClient.Client, authOptions.Transport)
 	virtualMachinesClient.Client.ResponseMiddlewares = &responseMiddleware
 	virtualMachinesClient.Client.RequestMiddlewares = &requestMiddleware
 	virtualMachinesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), virtualMachinesClient.Client.UserAgent)
@@ -125,6 +135,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	snapshotsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(snapshotsClient.Client, authOptions.Transport)
 	snapshotsClient.Client.ResponseMiddlewares = &responseMiddleware
 	snapshotsClient.Client.RequestMiddlewares = &requestMiddleware
 	snapshotsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), snapshotsClient.Client.UserAgent)
@@ -135,6 +146,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	vaultsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(vaultsClient.Client, authOptions.Transport)
 	vaultsClient.Client.ResponseMiddlewares = &responseMiddleware
 	vaultsClient.Client.RequestMiddlewares = &requestMiddleware
 	vaultsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), vaultsClient.Client.UserAgent)
@@ -145,6 +157,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	secretsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(secretsClient.Client, authOptions.Transport)
 	secretsClient.Client.ResponseMiddlewares = &responseMiddleware
 	secretsClient.Client.RequestMiddlewares = &requestMiddleware
 	secretsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), vaultsClient.Client.UserAgent)
@@ -157,6 +170,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 	deploymentsClient.Client.ResponseMiddlewares = &responseMiddleware
 	deploymentsClient.Client.RequestMiddlewares = &requestMiddleware
 	deploymentsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(deploymentsClient.Client, authOptions.Transport)
 	deploymentsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), deploymentsClient.Client.UserAgent)
 	azureClient.DeploymentsClient = *deploymentsClient
 
@@ -165,6 +179,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	deploymentOperationsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(deploymentOperationsClient.Client, authOptions.Transport)
 	deploymentOperationsClient.Client.ResponseMiddlewares = &responseMiddleware
 	deploymentOperationsClient.Client.RequestMiddlewares = &requestMiddleware
 	deploymentOperationsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), deploymentOperationsClient.Client.UserAgent)
@@ -175,6 +190,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	resourceGroupsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(resourceGroupsClient.Client, authOptions.Transport)
 	resourceGroupsClient.Client.ResponseMiddlewares = &responseMiddleware
 	resourceGroupsClient.Client.RequestMiddlewares = &requestMiddleware
 	resourceGroupsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), resourceGroupsClient.Client.UserAgent)
@@ -185,6 +201,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	imagesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(imagesClient.Client, authOptions.Transport)
 	imagesClient.Client.ResponseMiddlewares = &responseMiddleware
 	imagesClient.Client.RequestMiddlewares = &requestMiddleware
 	imagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), imagesClient.Client.UserAgent)
@@ -195,6 +212,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	storageAccountsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(storageAccountsClient.Client, authOptions.Transport)
 	storageAccountsClient.Client.ResponseMiddlewares = &responseMiddleware
 	storageAccountsClient.Client.RequestMiddlewares = &requestMiddleware
 	storageAccountsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), storageAccountsClient.Client.UserAgent)
@@ -202,6 +220,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 
 	networkMetaClient, err := networks.NewClientWithBaseURI(cloud.ResourceManager, func(c *resourcemanager.Client) {
 		c.Client.Authorizer = resourceManagerAuthorizer
+		commonclient.ConfigureTransport(c.Client, authOptions.Transport)
 		c.Client.UserAgent = useragent.String(version.AzurePluginVersion.FormattedVersion())
 		c.Client.ResponseMiddlewares = &responseMiddleware
 		c.Client.RequestMiddlewares = &requestMiddleware
@@ -216,6 +235,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	galleryImageVersionsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(galleryImageVersionsClient.Client, authOptions.Transport)
 	galleryImageVersionsClient.Client.ResponseMiddlewares = &responseMiddleware
 	galleryImageVersionsClient.Client.RequestMiddlewares = &requestMiddleware
 	galleryImageVersionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImageVersionsClient.Client.UserAgent)
@@ -226,6 +246,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	galleryImagesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(galleryImagesClient.Client, authOptions.Transport)
 	galleryImagesClient.Client.ResponseMiddlewares = &responseMiddleware
 	galleryImagesClient.Client.RequestMiddlewares = &requestMiddleware
 	galleryImagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImagesClient.Client.UserAgent)
@@ -236,11 +257,56 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 		return nil, err
 	}
 	vmImagesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(vmImagesClient.Client, authOptions.Transport)
 	vmImagesClient.Client.ResponseMiddlewares = &responseMiddleware
 	vmImagesClient.Client.RequestMiddlewares = &requestMiddleware
 	vmImagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), vmImagesClient.Client.UserAgent)
 	azureClient.VirtualMachineImagesClient = *vmImagesClient
 
+	permissionsClient, err := permissions.NewPermissionsClientWithBaseURI(cloud.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	permissionsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(permissionsClient.Client, authOptions.Transport)
+	permissionsClient.Client.ResponseMiddlewares = &responseMiddleware
+	permissionsClient.Client.RequestMiddlewares = &requestMiddleware
+	permissionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), permissionsClient.Client.UserAgent)
+	azureClient.PermissionsClient = *permissionsClient
+
+	skusClient, err := skus.NewSkusClientWithBaseURI(cloud.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	skusClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(skusClient.Client, authOptions.Transport)
+	skusClient.Client.ResponseMiddlewares = &responseMiddleware
+	skusClient.Client.RequestMiddlewares = &requestMiddleware
+	skusClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), skusClient.Client.UserAgent)
+	azureClient.SkusClient = *skusClient
+
+	computeRPSClient, err := computerps.NewComputeRPSClientWithBaseURI(cloud.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	computeRPSClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(computeRPSClient.Client, authOptions.Transport)
+	computeRPSClient.Client.ResponseMiddlewares = &responseMiddleware
+	computeRPSClient.Client.RequestMiddlewares = &requestMiddleware
+	computeRPSClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), computeRPSClient.Client.UserAgent)
+	azureClient.ComputeRPSClient = *computeRPSClient
+
+	subscriptionsClient, err := subscriptions.NewSubscriptionsClientWithBaseURI(cloud.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(subscriptionsClient.Client, authOptions.Transport)
+	subscriptionsClient.Client.ResponseMiddlewares = &responseMiddleware
+	subscriptionsClient.Client.RequestMiddlewares = &requestMiddleware
+	subscriptionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), subscriptionsClient.Client.UserAgent)
+	azureClient.SubscriptionsClient = *subscriptionsClient
+
 	// We only need the Blob Client to delete the OS VHD during VHD builds
 	if storageAccountName != "" {
 		storageAccountAuthorizer, err := commonclient.BuildStorageAuthorizer(ctx, authOptions, *cloud)
@@ -254,6 +320,7 @@ func NewAzureClient(ctx context.Context, storageAccountName string, cloud *envir
 			return nil, err
 		}
 		blobClient.Client.Authorizer = storageAccountAuthorizer
+		commonclient.ConfigureTransport(blobClient.Client, authOptions.Transport)
 		blobClient.Client.RequestMiddlewares = &requestMiddleware
 		blobClient.Client.ResponseMiddlewares = &responseMiddleware
 		azureClient.GiovanniBlobClient = *blobClient
@@ -90,6 +90,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	if err != nil {
 		return nil, err
 	}
+	defer b.config.ClientConfig.Close()
 
 	//When running Packer on an Azure instance using Managed Identity, FillParameters will update SubscriptionID from the instance
 	// so lets make sure to update our state bag with the valid subscriptionID.
@@ -117,6 +118,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		SubscriptionID:     b.config.ClientConfig.SubscriptionID,
 		OidcRequestUrl:     b.config.ClientConfig.OidcRequestURL,
 		OidcRequestToken:   b.config.ClientConfig.OidcRequestToken,
+		Transport:          b.config.ClientConfig.Transport(),
 	}
 
 	ui.Say("Creating Azure Resource Manager (ARM) client ...")
@@ -140,6 +142,14 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	// All requests against go-azure-sdk require a polling duration
 	builderPollingContext, builderCancel := context.WithTimeout(ctx, azureClient.PollingDuration)
 	defer builderCancel()
+
+	if b.config.CheckPermissions {
+		ui.Say("Checking permissions ...")
+		if err := checkPermissions(builderPollingContext, commonclient.NewPermissionsLister(azureClient.PermissionsClient), &b.config); err != nil {
+			return nil, err
+		}
+	}
+
 	objectID := azureClient.ObjectID
 	if b.config.ClientConfig.ObjectID == "" {
 		b.config.ClientConfig.ObjectID = objectID
@@ -232,7 +242,25 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		b.config.Location = group.Model.Location
 	}
 
-	b.config.validateLocationZoneResiliency(ui.Say)
+	capabilities := newCapabilityValidator(azureClient, b.config.ClientConfig.SubscriptionID)
+	if err := reportCapabilityProblems(ui, &b.config, capabilities.validate(builderPollingContext, &b.config, ui.Say)); err != nil {
+		return nil, err
+	}
+
+	if b.config.CheckVMSize != "" {
+		ui.Say(fmt.Sprintf("Checking quota and capabilities of VM size %s ...", b.config.VMSize))
+		requiresGen2, err := sourceRequiresGen2(builderPollingContext, newSourceImageLookup(azureClient), &b.config)
+		if err != nil {
+			return nil, err
+		}
+		problems, err := capabilities.checkVMSize(builderPollingContext, &b.config, requiresGen2)
+		if err != nil {
+			return nil, err
+		}
+		if err := reportVMSizeProblems(ui, &b.config, problems); err != nil {
+			return nil, err
+		}
+	}
 
 	if b.config.StorageAccount != "" {
 		account, err := b.getBlobAccount(builderPollingContext, azureClient, b.config.ClientConfig.SubscriptionID, b.config.ResourceGroupName, b.config.StorageAccount)
@@ -0,0 +1,202 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2021-07-01/skus"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/computerps"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-12-01/subscriptions"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// capabilityClient is the subset of AzureClient used by capabilityValidator.
+type capabilityClient interface {
+	ResourceSkusListComplete(ctx context.Context, id commonids.SubscriptionId, options skus.ResourceSkusListOperationOptions) (skus.ResourceSkusListCompleteResult, error)
+	UsageListComplete(ctx context.Context, id computerps.LocationId) (computerps.UsageListCompleteResult, error)
+	ListLocationsComplete(ctx context.Context, id commonids.SubscriptionId, options subscriptions.ListLocationsOperationOptions) (subscriptions.ListLocationsCompleteResult, error)
+}
+
+// capabilityValidator checks the build configuration against the resource
+// SKUs and locations that are available to the subscription. Every lookup is
+// made at most once per build.
+type capabilityValidator struct {
+	client         capabilityClient
+	subscriptionID string
+
+	skus      map[string][]skus.ResourceSku
+	locations map[string]struct{}
+}
+
+func newCapabilityValidator(client capabilityClient, subscriptionID string) *capabilityValidator {
+	return &capabilityValidator{
+		client:         client,
+		subscriptionID: subscriptionID,
+		skus:           make(map[string][]skus.ResourceSku),
+	}
+}
+
+// resourceSKUs returns the compute resource SKUs offered in location.
+func (v *capabilityValidator) resourceSKUs(ctx context.Context, location string) ([]skus.ResourceSku, error) {
+	location = normalizeAzureRegion(location)
+	if cached, ok := v.skus[location]; ok {
+		return cached, nil
+	}
+
+	filter := fmt.Sprintf("location eq '%s'", location)
+	result, err := v.client.ResourceSkusListComplete(ctx, commonids.NewSubscriptionID(v.subscriptionID), skus.ResourceSkusListOperationOptions{Filter: &filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the resource SKUs of %s: %v", location, err)
+	}
+	v.skus[location] = result.Items
+	return result.Items, nil
+}
+
+// isLocation reports whether name is the name or display name of a region of
+// the current cloud.
+func (v *capabilityValidator) isLocation(ctx context.Context, name string) (bool, error) {
+	if v.locations == nil {
+		result, err := v.client.ListLocationsComplete(ctx, commonids.NewSubscriptionID(v.subscriptionID), subscriptions.DefaultListLocationsOperationOptions())
+		if err != nil {
+			return false, fmt.Errorf("failed to list the locations of the subscription: %v", err)
+		}
+		v.locations = make(map[string]struct{})
+		for _, l := range result.Items {
+			if l.Name != nil {
+				v.locations[normalizeAzureRegion(*l.Name)] = struct{}{}
+			}
+			if l.DisplayName != nil {
+				v.locations[normalizeAzureRegion(*l.DisplayName)] = struct{}{}
+			}
+		}
+	}
+	_, ok := v.locations[normalizeAzureRegion(name)]
+	return ok, nil
+}
+
+// validate checks that vm_size can be deployed in the build location, that
+// the location supports the requested image storage and zone resiliency, and
+// that the Shared Image Gallery replication regions exist. It returns the
+// problems found, which fail the build only when check_vm_size is error as
+// the SKU data is not always accurate; see reportCapabilityProblems. Problems
+// that do not necessarily prevent the build, like failing lookups, are passed
+// to say as warnings.
+//
+// Ultra disks are not checked: UltraSSD_LRS is not a valid
+// managed_image_storage_account_type, as managed images cannot be stored on
+// them.
+func (v *capabilityValidator) validate(ctx context.Context, c *Config, say func(string)) (problems []string) {
+	location := normalizeAzureRegion(c.Location)
+	skuList, err := v.resourceSKUs(ctx, location)
+	if err != nil {
+		say(fmt.Sprintf("WARNING: Unable to validate the capabilities of %s: %v", location, err))
+		return nil
+	}
+
+	sku := findVirtualMachineSKU(skuList, c.VMSize)
+	if sku == nil {
+		problems = append(problems, fmt.Sprintf("The vm_size %q is not offered in %s", c.VMSize, location))
+	} else if isRestrictedIn(sku, location) {
+		problems = append(problems, fmt.Sprintf("The vm_size %q is not available to this subscription in %s", c.VMSize, location))
+	}
+
+	if c.managedImageStorageAccountType == virtualmachines.StorageAccountTypesPremiumLRS {
+		if findSKU(skuList, "disks", string(c.managedImageStorageAccountType)) == nil {
+			problems = append(problems, fmt.Sprintf("Premium storage is not offered in %s", location))
+		} else if sku != nil && !strings.EqualFold(skuCapability(sku, "PremiumIO"), "True") {
+			problems = append(problems, fmt.Sprintf("The vm_size %q does not support the managed_image_storage_account_type %s", c.VMSize, c.managedImageStorageAccountType))
+		}
+	}
+
+	if c.isPublishToSIG() {
+		regions := append([]string{}, c.SharedGalleryDestination.SigDestinationReplicationRegions...)
+		for _, r := range c.SharedGalleryDestination.SigDestinationTargetRegions {
+			regions = append(regions, r.Name)
+		}
+		for _, r := range regions {
+			ok, err := v.isLocation(ctx, r)
+			if err != nil {
+				say(fmt.Sprintf("WARNING: Unable to validate the Shared Image Gallery replication regions: %v", err))
+				break
+			}
+			if !ok {
+				problems = append(problems, fmt.Sprintf("The Shared Image Gallery replication region %q is not a region of the current cloud", r))
+			}
+		}
+	}
+
+	if c.ManagedImageZoneResilient && (sku == nil || !hasAvailabilityZones(sku, location)) {
+		say(fmt.Sprintf("WARNING: Zone resiliency may not be supported for %s in %s, checkout the docs at https://docs.microsoft.com/en-us/azure/availability-zones/", c.VMSize, c.Location))
+	}
+
+	return problems
+}
+
+// reportCapabilityProblems shows the problems found by validate, and returns
+// an error if the build should not continue.
+func reportCapabilityProblems(ui packersdk.Ui, c *Config, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	if c.CheckVMSize != vmSizeCheckError {
+		for _, p := range problems {
+			ui.Say(fmt.Sprintf("Warning: %s", p))
+		}
+		return nil
+	}
+	return fmt.Errorf("the build cannot run in %s:\n  %s", normalizeAzureRegion(c.Location), strings.Join(problems, "\n  "))
+}
+
+func findVirtualMachineSKU(skuList []skus.ResourceSku, vmSize string) *skus.ResourceSku {
+	return findSKU(skuList, "virtualMachines", vmSize)
+}
+
+func findSKU(skuList []skus.ResourceSku, resourceType, name string) *skus.ResourceSku {
+	for i, sku := range skuList {
+		if sku.ResourceType != nil && strings.EqualFold(*sku.ResourceType, resourceType) &&
+			sku.Name != nil && strings.EqualFold(*sku.Name, name) {
+			return &skuList[i]
+		}
+	}
+	return nil
+}
+
+// isRestrictedIn reports whether sku cannot be deployed in location by the
+// current subscription.
+func isRestrictedIn(sku *skus.ResourceSku, location string) bool {
+	if sku.Restrictions == nil {
+		return false
+	}
+	for _, r := range *sku.Restrictions {
+		if r.Type == nil || *r.Type != skus.ResourceSkuRestrictionsTypeLocation || r.Values == nil {
+			continue
+		}
+		for _, value := range *r.Values {
+			if normalizeAzureRegion(value) == location {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasAvailabilityZones reports whether sku can be deployed to availability
+// zones in location.
+func hasAvailabilityZones(sku *skus.ResourceSku, location string) bool {
+	if sku.LocationInfo == nil {
+		return false
+	}
+	for _, info := range *sku.LocationInfo {
+		if info.Location != nil && normalizeAzureRegion(*info.Location) == location &&
+			info.Zones != nil && len(*info.Zones) > 0 {
+			return true
+		}
+	}
+	return false
+}
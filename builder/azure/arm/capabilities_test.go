@@ -0,0 +1,208 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2021-07-01/skus"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/computerps"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-12-01/subscriptions"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+type fakeCapabilityClient struct {
+	skus      []skus.ResourceSku
+	skusError error
+	usages    []computerps.Usage
+	locations []string
+
+	filter        string
+	skuCalls      int
+	locationCalls int
+}
+
+func (f *fakeCapabilityClient) ResourceSkusListComplete(_ context.Context, _ commonids.SubscriptionId, options skus.ResourceSkusListOperationOptions) (skus.ResourceSkusListCompleteResult, error) {
+	f.skuCalls++
+	if options.Filter != nil {
+		f.filter = *options.Filter
+	}
+	return skus.ResourceSkusListCompleteResult{Items: f.skus}, f.skusError
+}
+
+func (f *fakeCapabilityClient) UsageListComplete(context.Context, computerps.LocationId) (computerps.UsageListCompleteResult, error) {
+	return computerps.UsageListCompleteResult{Items: f.usages}, nil
+}
+
+func (f *fakeCapabilityClient) ListLocationsComplete(context.Context, commonids.SubscriptionId, subscriptions.ListLocationsOperationOptions) (subscriptions.ListLocationsCompleteResult, error) {
+	f.locationCalls++
+	var items []subscriptions.Location
+	for i := range f.locations {
+		items = append(items, subscriptions.Location{Name: &f.locations[i]})
+	}
+	return subscriptions.ListLocationsCompleteResult{Items: items}, nil
+}
+
+func TestCapabilityValidator(t *testing.T) {
+	d2 := testVMSizeSKU("Standard_D2s_v3", "standardDSv3Family", map[string]string{"PremiumIO": "True"})
+	zones := []string{"1", "2", "3"}
+	location := "westeurope"
+	d2.LocationInfo = &[]skus.ResourceSkuLocationInfo{{Location: &location, Zones: &zones}}
+	a1 := testVMSizeSKU("Standard_A1", "standardA0_A7Family", map[string]string{"PremiumIO": "False"})
+	a1.LocationInfo = &[]skus.ResourceSkuLocationInfo{{Location: &location}}
+	restricted := testVMSizeSKU("Standard_M416ms_v2", "standardMSv2Family", nil)
+	restrictionType := skus.ResourceSkuRestrictionsTypeLocation
+	restricted.Restrictions = &[]skus.ResourceSkuRestrictions{{Type: &restrictionType, Values: &[]string{"westeurope"}}}
+	diskType, premium := "disks", "Premium_LRS"
+	premiumDisks := skus.ResourceSku{ResourceType: &diskType, Name: &premium}
+
+	tests := []struct {
+		name    string
+		config  Config
+		skus    []skus.ResourceSku
+		warning string
+		err     string
+	}{
+		{
+			name:   "valid",
+			config: Config{VMSize: "Standard_D2s_v3", Location: "West Europe", ManagedImageZoneResilient: true, managedImageStorageAccountType: virtualmachines.StorageAccountTypesPremiumLRS},
+			skus:   []skus.ResourceSku{d2, premiumDisks},
+		},
+		{
+			name:   "unknown vm_size",
+			config: Config{VMSize: "Standard_Nope", Location: "westeurope"},
+			skus:   []skus.ResourceSku{d2},
+			err:    `The vm_size "Standard_Nope" is not offered in westeurope`,
+		},
+		{
+			name:   "restricted vm_size",
+			config: Config{VMSize: "Standard_M416ms_v2", Location: "westeurope"},
+			skus:   []skus.ResourceSku{restricted},
+			err:    "not available to this subscription",
+		},
+		{
+			name:   "premium storage not supported by vm_size",
+			config: Config{VMSize: "Standard_A1", Location: "westeurope", managedImageStorageAccountType: virtualmachines.StorageAccountTypesPremiumLRS},
+			skus:   []skus.ResourceSku{a1, premiumDisks},
+			err:    "does not support the managed_image_storage_account_type Premium_LRS",
+		},
+		{
+			name:   "premium storage not offered in location",
+			config: Config{VMSize: "Standard_D2s_v3", Location: "westeurope", managedImageStorageAccountType: virtualmachines.StorageAccountTypesPremiumLRS},
+			skus:   []skus.ResourceSku{d2},
+			err:    "Premium storage is not offered in westeurope",
+		},
+		{
+			name:    "no availability zones",
+			config:  Config{VMSize: "Standard_A1", Location: "ukwest", ManagedImageZoneResilient: true},
+			skus:    []skus.ResourceSku{a1},
+			warning: "WARNING: Zone resiliency may not be supported for Standard_A1 in ukwest",
+		},
+		{
+			name:    "no availability zones for vm_size",
+			config:  Config{VMSize: "Standard_A1", Location: "westeurope", ManagedImageZoneResilient: true},
+			skus:    []skus.ResourceSku{a1, d2},
+			warning: "WARNING: Zone resiliency may not be supported for Standard_A1 in westeurope",
+		},
+		{
+			name:    "availability zones in another location",
+			config:  Config{VMSize: "Standard_D2s_v3", Location: "northeurope", ManagedImageZoneResilient: true},
+			skus:    []skus.ResourceSku{d2},
+			warning: "WARNING: Zone resiliency may not be supported for Standard_D2s_v3 in northeurope",
+		},
+		{
+			name: "unknown replication region",
+			config: Config{
+				VMSize:   "Standard_D2s_v3",
+				Location: "westeurope",
+				SharedGalleryDestination: SharedImageGalleryDestination{
+					SigDestinationGalleryName:        "gallery",
+					SigDestinationReplicationRegions: []string{"West Europe"},
+					SigDestinationTargetRegions:      []TargetRegion{{Name: "eastus"}, {Name: "moon-1"}},
+				},
+			},
+			skus: []skus.ResourceSku{d2},
+			err:  `replication region "moon-1" is not a region of the current cloud`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCapabilityClient{skus: tt.skus, locations: []string{"westeurope", "eastus"}}
+			var warning string
+			problems := newCapabilityValidator(client, "sub").validate(context.TODO(), &tt.config, func(s string) { warning = s })
+			if tt.err == "" && len(problems) > 0 {
+				t.Fatalf("Unexpected problems: %q", problems)
+			}
+			if tt.err != "" && (len(problems) != 1 || !strings.Contains(problems[0], tt.err)) {
+				t.Fatalf("Expected a problem containing %q, got %q", tt.err, problems)
+			}
+			if !strings.HasPrefix(warning, tt.warning) || (tt.warning == "" && warning != "") {
+				t.Errorf("Expected warning %q, got %q", tt.warning, warning)
+			}
+		})
+	}
+}
+
+func TestCapabilityValidatorCachesLookups(t *testing.T) {
+	client := &fakeCapabilityClient{
+		skus:      []skus.ResourceSku{testVMSizeSKU("Standard_A1", "standardA0_A7Family", nil)},
+		locations: []string{"westeurope", "eastus"},
+	}
+	v := newCapabilityValidator(client, "sub")
+	c := &Config{
+		VMSize:   "Standard_A1",
+		Location: "westeurope",
+		SharedGalleryDestination: SharedImageGalleryDestination{
+			SigDestinationGalleryName:        "gallery",
+			SigDestinationReplicationRegions: []string{"westeurope", "eastus"},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if problems := v.validate(context.TODO(), c, func(string) {}); len(problems) > 0 {
+			t.Fatalf("Unexpected problems: %q", problems)
+		}
+	}
+	if _, err := v.checkVMSize(context.TODO(), c, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.skuCalls != 1 || client.locationCalls != 1 {
+		t.Errorf("Expected one SKU and one location lookup, got %d and %d", client.skuCalls, client.locationCalls)
+	}
+}
+
+func TestCapabilityValidatorWarnsWhenLookupFails(t *testing.T) {
+	client := &fakeCapabilityClient{skusError: fmt.Errorf("AuthorizationFailed")}
+	var warning string
+	problems := newCapabilityValidator(client, "sub").validate(context.TODO(), &Config{VMSize: "Standard_A1", Location: "westeurope"}, func(s string) { warning = s })
+	if len(problems) > 0 {
+		t.Fatalf("Expected a failed lookup not to fail the build, got %q", problems)
+	}
+	if !strings.Contains(warning, "AuthorizationFailed") {
+		t.Errorf("Expected a warning about the failed lookup, got %q", warning)
+	}
+}
+
+func TestReportCapabilityProblems(t *testing.T) {
+	ui := packersdk.TestUi(t)
+	problems := []string{`The vm_size "Standard_Nope" is not offered in westeurope`}
+	for _, checkVMSize := range []string{"", vmSizeCheckWarn} {
+		c := &Config{Location: "westeurope", CheckVMSize: checkVMSize}
+		if err := reportCapabilityProblems(ui, c, problems); err != nil {
+			t.Fatalf("Expected only a warning with check_vm_size %q, got %v", checkVMSize, err)
+		}
+	}
+
+	c := &Config{Location: "westeurope", CheckVMSize: vmSizeCheckError}
+	err := reportCapabilityProblems(ui, c, problems)
+	if err == nil || !strings.Contains(err.Error(), "Standard_Nope") {
+		t.Fatalf("Expected the problem to fail the build, got %v", err)
+	}
+}
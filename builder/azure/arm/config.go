@@ -679,6 +679,30 @@ type Config struct {
 	// Specifies the encryption type to use for the Confidential VM. "DiskWithVMGuestState" or "VMGuestStateOnly"
 	SecurityEncryptionType string `mapstructure:"security_encryption_type" required:"false"`
 
+	// If set to `true`, Packer checks the effective Azure RBAC permissions of the
+	// identity it authenticates with before creating any resources, and fails
+	// with a list of the missing actions and scopes instead of failing late in
+	// the build. The check covers resource group creation, the build key vault,
+	// the managed image and snapshots, the shared image gallery version and the
+	// VHD storage account. Defaults to `false`.
+	CheckPermissions bool `mapstructure:"check_permissions" required:"false"`
+
+	// Set to `warn` or `error` to look up `vm_size` in the resource SKUs of the
+	// build location before creating any resources. Packer then checks that the
+	// regional and VM family vCPU quotas of the subscription can fit the build
+	// VM, printing the current usage and limit, and that the size supports the
+	// configured `security_type`, `encryption_at_host`, `accelerated_networking`,
+	// `disk_controller_type`, `spot` settings and Generation 2 platform, managed
+	// or Shared Image Gallery source images.
+	// With `warn` the problems found are printed and the build continues, with
+	// `error` the build fails. Defaults to unset, which skips the check.
+	// Whatever the setting, Packer also checks that `vm_size` is offered in the
+	// build location, that the location and size support Premium
+	// `managed_image_storage_account_type` and that the Shared Image Gallery
+	// replication regions exist; these problems fail the build with `error`
+	// and are printed as warnings otherwise.
+	CheckVMSize string `mapstructure:"check_vm_size" required:"false"`
+
 	// Runtime Values
 	UserName               string `mapstructure-to-hcl2:",skip"`
 	Password               string `mapstructure-to-hcl2:",skip"`
@@ -1638,6 +1662,12 @@ func assertRequiredParametersSet(c *Config, errs *packersdk.MultiError) {
 		}
 	}
 
+	switch c.CheckVMSize {
+	case "", vmSizeCheckWarn, vmSizeCheckError:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("The check_vm_size %q is invalid, the valid values are %s and %s", c.CheckVMSize, vmSizeCheckWarn, vmSizeCheckError))
+	}
+
 	/////////////////////////////////////////////
 	// License Type (Azure Hybrid Benefit)
 	if c.LicenseType != "" {
@@ -1812,41 +1842,3 @@ func isValidPassword(password string) bool {
 
 	return requirements >= 3
 }
-
-func (c *Config) validateLocationZoneResiliency(say func(s string)) {
-	// Docs on regions that support Availibility Zones:
-	//   https://docs.microsoft.com/en-us/azure/availability-zones/az-overview#regions-that-support-availability-zones
-	// Query technical names for locations:
-	//   az account list-locations --query '[].name' -o tsv
-
-	var zones = make(map[string]struct{})
-	zones["westeurope"] = struct{}{}
-	zones["australiaeast"] = struct{}{}
-	zones["brazilsouth"] = struct{}{}
-	zones["canadacentral"] = struct{}{}
-	zones["centralindia"] = struct{}{}
-	zones["centralus"] = struct{}{}
-	zones["chinanorth3"] = struct{}{}
-	zones["eastasia"] = struct{}{}
-	zones["eastus"] = struct{}{}
-	zones["eastus2"] = struct{}{}
-	zones["francecentral"] = struct{}{}
-	zones["germanywestcentral"] = struct{}{}
-	zones["japaneast"] = struct{}{}
-	zones["koreacentral"] = struct{}{}
-	zones["northeurope"] = struct{}{}
-	zones["norwayeast"] = struct{}{}
-	zones["southafricanorth"] = struct{}{}
-	zones["southcentralus"] = struct{}{}
-	zones["southeastasia"] = struct{}{}
-	zones["swedencentral"] = struct{}{}
-	zones["switzerlandnorth"] = struct{}{}
-	zones["uksouth"] = struct{}{}
-	zones["usgovvirginia"] = struct{}{}
-	zones["westus2"] = struct{}{}
-	zones["westus3"] = struct{}{}
-
-	if _, ok := zones[normalizeAzureRegion(c.Location)]; !ok {
-		say(fmt.Sprintf("WARNING: Zone resiliency may not be supported in %s, checkout the docs at https://docs.microsoft.com/en-us/azure/availability-zones/", c.Location))
-	}
-}
@@ -34,6 +34,10 @@ type FlatConfig struct {
 	OidcRequestToken                           *string                            `mapstructure:"oidc_request_token" cty:"oidc_request_token" hcl:"oidc_request_token"`
 	OidcRequestURL                             *string                            `mapstructure:"oidc_request_url" cty:"oidc_request_url" hcl:"oidc_request_url"`
 	UseAzureCLIAuth                            *bool                              `mapstructure:"use_azure_cli_auth" required:"false" cty:"use_azure_cli_auth" hcl:"use_azure_cli_auth"`
+	HTTPProxy                                  *string                            `mapstructure:"http_proxy" required:"false" cty:"http_proxy" hcl:"http_proxy"`
+	NoProxy                                    *string                            `mapstructure:"no_proxy" required:"false" cty:"no_proxy" hcl:"no_proxy"`
+	CABundleFile                               *string                            `mapstructure:"ca_bundle_file" required:"false" cty:"ca_bundle_file" hcl:"ca_bundle_file"`
+	TraceFile                                  *string                            `mapstructure:"azure_trace_file" required:"false" cty:"azure_trace_file" hcl:"azure_trace_file"`
 	UserAssignedManagedIdentities              []string                           `mapstructure:"user_assigned_managed_identities" required:"false" cty:"user_assigned_managed_identities" hcl:"user_assigned_managed_identities"`
 	CaptureNamePrefix                          *string                            `mapstructure:"capture_name_prefix" cty:"capture_name_prefix" hcl:"capture_name_prefix"`
 	CaptureContainerName                       *string                            `mapstructure:"capture_container_name" cty:"capture_container_name" hcl:"capture_container_name"`
@@ -104,6 +108,8 @@ type FlatConfig struct {
 	VTpmEnabled                                *bool                              `mapstructure:"vtpm_enabled" required:"false" cty:"vtpm_enabled" hcl:"vtpm_enabled"`
 	SecurityType                               *string                            `mapstructure:"security_type" required:"false" cty:"security_type" hcl:"security_type"`
 	SecurityEncryptionType                     *string                            `mapstructure:"security_encryption_type" required:"false" cty:"security_encryption_type" hcl:"security_encryption_type"`
+	CheckPermissions                           *bool                              `mapstructure:"check_permissions" required:"false" cty:"check_permissions" hcl:"check_permissions"`
+	CheckVMSize                                *string                            `mapstructure:"check_vm_size" required:"false" cty:"check_vm_size" hcl:"check_vm_size"`
 	Type                                       *string                            `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
 	PauseBeforeConnect                         *string                            `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
 	SSHHost                                    *string                            `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
@@ -168,36 +174,40 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":                             &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":                           &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":                           &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":                                  &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":                                  &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":                               &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":                         &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables":                    &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"skip_create_image":                             &hcldec.AttrSpec{Name: "skip_create_image", Type: cty.Bool, Required: false},
-		"cloud_environment_name":                        &hcldec.AttrSpec{Name: "cloud_environment_name", Type: cty.String, Required: false},
-		"metadata_host":                                 &hcldec.AttrSpec{Name: "metadata_host", Type: cty.String, Required: false},
-		"client_id":                                     &hcldec.AttrSpec{Name: "client_id", Type: cty.String, Required: false},
-		"client_secret":                                 &hcldec.AttrSpec{Name: "client_secret", Type: cty.String, Required: false},
-		"client_cert_path":                              &hcldec.AttrSpec{Name: "client_cert_path", Type: cty.String, Required: false},
-		"client_cert_password":                          &hcldec.AttrSpec{Name: "client_cert_password", Type: cty.String, Required: false},
-		"client_jwt":                                    &hcldec.AttrSpec{Name: "client_jwt", Type: cty.String, Required: false},
-		"object_id":                                     &hcldec.AttrSpec{Name: "object_id", Type: cty.String, Required: false},
-		"tenant_id":                                     &hcldec.AttrSpec{Name: "tenant_id", Type: cty.String, Required: false},
-		"subscription_id":                               &hcldec.AttrSpec{Name: "subscription_id", Type: cty.String, Required: false},
-		"oidc_request_token":                            &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
-		"oidc_request_url":                              &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
-		"use_azure_cli_auth":                            &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
-		"user_assigned_managed_identities":              &hcldec.AttrSpec{Name: "user_assigned_managed_identities", Type: cty.List(cty.String), Required: false},
-		"capture_name_prefix":                           &hcldec.AttrSpec{Name: "capture_name_prefix", Type: cty.String, Required: false},
-		"capture_container_name":                        &hcldec.AttrSpec{Name: "capture_container_name", Type: cty.String, Required: false},
-		"shared_image_gallery":                          &hcldec.BlockSpec{TypeName: "shared_image_gallery", Nested: hcldec.ObjectSpec((*FlatSharedImageGallery)(nil).HCL2Spec())},
-		"shared_image_gallery_destination":              &hcldec.BlockSpec{TypeName: "shared_image_gallery_destination", Nested: hcldec.ObjectSpec((*FlatSharedImageGalleryDestination)(nil).HCL2Spec())},
-		"shared_image_gallery_timeout":                  &hcldec.AttrSpec{Name: "shared_image_gallery_timeout", Type: cty.String, Required: false},
-		"shared_gallery_image_version_end_of_life_date": &hcldec.AttrSpec{Name: "shared_gallery_image_version_end_of_life_date", Type: cty.String, Required: false},
-		"shared_image_gallery_replica_count":            &hcldec.AttrSpec{Name: "shared_image_gallery_replica_count", Type: cty.Number, Required: false},
+		"packer_build_name":                &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":              &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":              &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                     &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                     &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                  &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":            &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":       &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"skip_create_image":                &hcldec.AttrSpec{Name: "skip_create_image", Type: cty.Bool, Required: false},
+		"cloud_environment_name":           &hcldec.AttrSpec{Name: "cloud_environment_name", Type: cty.String, Required: false},
+		"metadata_host":                    &hcldec.AttrSpec{Name: "metadata_host", Type: cty.String, Required: false},
+		"client_id":                        &hcldec.AttrSpec{Name: "client_id", Type: cty.String, Required: false},
+		"client_secret":                    &hcldec.AttrSpec{Name: "client_secret", Type: cty.String, Required: false},
+		"client_cert_path":                 &hcldec.AttrSpec{Name: "client_cert_path", Type: cty.String, Required: false},
+		"client_cert_password":             &hcldec.AttrSpec{Name: "client_cert_password", Type: cty.String, Required: false},
+		"client_jwt":                       &hcldec.AttrSpec{Name: "client_jwt", Type: cty.String, Required: false},
+		"object_id":                        &hcldec.AttrSpec{Name: "object_id", Type: cty.String, Required: false},
+		"tenant_id":                        &hcldec.AttrSpec{Name: "tenant_id", Type: cty.String, Required: false},
+		"subscription_id":                  &hcldec.AttrSpec{Name: "subscription_id", Type: cty.String, Required: false},
+		"oidc_request_token":               &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
+		"oidc_request_url":                 &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
+		"use_azure_cli_auth":               &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
+		"http_proxy":                       &hcldec.AttrSpec{Name: "http_proxy", Type: cty.String, Required: false},
+		"no_proxy":                         &hcldec.AttrSpec{Name: "no_proxy", Type: cty.String, Required: false},
+		"ca_bundle_file":                   &hcldec.AttrSpec{Name: "ca_bundle_file", Type: cty.String, Required: false},
+		"azure_trace_file":                 &hcldec.AttrSpec{Name: "azure_trace_file", Type: cty.String, Required: false},
+		"user_assigned_managed_identities": &hcldec.AttrSpec{Name: "user_assigned_managed_identities", Type: cty.List(cty.String), Required: false},
+		"capture_name_prefix":              &hcldec.AttrSpec{Name: "capture_name_prefix", Type: cty.String, Required: false},
+		"capture_container_name":           &hcldec.AttrSpec{Name: "capture_container_name", Type: cty.String, Required: false},
+		"shared_image_gallery":             &hcldec.BlockSpec{TypeName: "shared_image_gallery", Nested: hcldec.ObjectSpec((*FlatSharedImageGallery)(nil).HCL2Spec())},
+		"shared_image_gallery_destination": &hcldec.BlockSpec{TypeName: "shared_image_gallery_destination", Nested: hcldec.ObjectSpec((*FlatSharedImageGalleryDestination)(nil).HCL2Spec())},
+		"shared_image_gallery_timeout":     &hcldec.AttrSpec{Name: "shared_image_gallery_timeout", Type: cty.String, Required: false},
+		"shared_gallery_image_version_end_of_life_date":    &hcldec.AttrSpec{Name: "shared_gallery_image_version_end_of_life_date", Type: cty.String, Required: false},
+		"shared_image_gallery_replica_count":               &hcldec.AttrSpec{Name: "shared_image_gallery_replica_count", Type: cty.Number, Required: false},
 		"shared_gallery_image_version_exclude_from_latest": &hcldec.AttrSpec{Name: "shared_gallery_image_version_exclude_from_latest", Type: cty.Bool, Required: false},
 		"image_publisher":           &hcldec.AttrSpec{Name: "image_publisher", Type: cty.String, Required: false},
 		"image_offer":               &hcldec.AttrSpec{Name: "image_offer", Type: cty.String, Required: false},
@@ -260,6 +270,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"vtpm_enabled":                             &hcldec.AttrSpec{Name: "vtpm_enabled", Type: cty.Bool, Required: false},
 		"security_type":                            &hcldec.AttrSpec{Name: "security_type", Type: cty.String, Required: false},
 		"security_encryption_type":                 &hcldec.AttrSpec{Name: "security_encryption_type", Type: cty.String, Required: false},
+		"check_permissions":                        &hcldec.AttrSpec{Name: "check_permissions", Type: cty.Bool, Required: false},
+		"check_vm_size":                            &hcldec.AttrSpec{Name: "check_vm_size", Type: cty.String, Required: false},
 		"communicator":                             &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
 		"pause_before_connecting":                  &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
 		"ssh_host":                                 &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
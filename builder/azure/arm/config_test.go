@@ -2528,44 +2528,6 @@ func TestConfigShouldRejectSharedImageGalleryWithVhdTarget(t *testing.T) {
 	}
 }
 
-func Test_GivenZoneNotSupportingResiliency_ConfigValidate_ShouldWarn(t *testing.T) {
-	builderValues := getArmBuilderConfiguration()
-	builderValues["managed_image_zone_resilient"] = "true"
-	builderValues["location"] = "ukwest"
-
-	var c Config
-	_, err := c.Prepare(builderValues, getPackerConfiguration())
-	if err != nil {
-		t.Errorf("newConfig failed with %q", err)
-	}
-
-	var m = ""
-	c.validateLocationZoneResiliency(func(s string) { m = s })
-
-	if m != "WARNING: Zone resiliency may not be supported in ukwest, checkout the docs at https://docs.microsoft.com/en-us/azure/availability-zones/" {
-		t.Errorf("warning message not as expected: %s", m)
-	}
-}
-
-func Test_GivenZoneSupportingResiliency_ConfigValidate_ShouldNotWarn(t *testing.T) {
-	builderValues := getArmBuilderConfiguration()
-	builderValues["managed_image_zone_resilient"] = "true"
-	builderValues["location"] = "westeurope"
-
-	var c Config
-	_, err := c.Prepare(builderValues, getPackerConfiguration())
-	if err != nil {
-		t.Errorf("newConfig failed with %q", err)
-	}
-
-	var m = ""
-	c.validateLocationZoneResiliency(func(s string) { m = s })
-
-	if m != "" {
-		t.Errorf("warning message not as expected: %s", m)
-	}
-}
-
 func TestConfig_PrepareProvidedWinRMPassword(t *testing.T) {
 	config := getArmBuilderConfiguration()
 	config["communicator"] = "winrm"
@@ -3855,3 +3817,22 @@ func TestConfigAdditionalDiskLunsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigShouldRejectInvalidCheckVMSize(t *testing.T) {
+	config := getArmBuilderConfiguration()
+	config["check_vm_size"] = "fail"
+
+	var c Config
+	_, err := c.Prepare(config, getPackerConfiguration())
+	if err == nil || !strings.Contains(err.Error(), `The check_vm_size "fail" is invalid`) {
+		t.Fatalf("expected config to reject check_vm_size, got %v", err)
+	}
+
+	for _, value := range []string{"warn", "error"} {
+		config["check_vm_size"] = value
+		var c Config
+		if _, err := c.Prepare(config, getPackerConfiguration()); err != nil {
+			t.Errorf("expected check_vm_size %q to be accepted, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	commonclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/constants"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+)
+
+// requiredPermissions returns the RBAC actions that a build with this
+// configuration performs, together with the scope each is performed on.
+// Resources that are created during the build, like the temporary resource
+// group, are checked on the subscription since they do not exist yet.
+func (c *Config) requiredPermissions() []commonclient.RequiredPermission {
+	subscriptionID := c.ClientConfig.SubscriptionID
+	subscriptionScope := commonids.NewSubscriptionID(subscriptionID).ID()
+	resourceGroupScope := func(name string) string {
+		if strings.EqualFold(name, c.tmpResourceGroupName) {
+			return subscriptionScope
+		}
+		return commonids.NewResourceGroupID(subscriptionID, name).ID()
+	}
+
+	var required []commonclient.RequiredPermission
+	add := func(scope, action, reason string) {
+		required = append(required, commonclient.RequiredPermission{Scope: scope, Action: action, Reason: reason})
+	}
+
+	buildScope := subscriptionScope
+	if c.BuildResourceGroupName != "" {
+		buildScope = resourceGroupScope(c.BuildResourceGroupName)
+	} else {
+		add(subscriptionScope, "Microsoft.Resources/subscriptions/resourceGroups/write", "creating the temporary resource group")
+		add(subscriptionScope, "Microsoft.Resources/subscriptions/resourceGroups/delete", "deleting the temporary resource group")
+	}
+	add(buildScope, "Microsoft.Resources/deployments/write", "deploying the build virtual machine")
+	add(buildScope, "Microsoft.Compute/virtualMachines/write", "creating the build virtual machine")
+
+	if c.OSType == constants.Target_Windows && !c.SkipCreateBuildKeyVault {
+		if c.BuildKeyVaultName == "" {
+			add(buildScope, "Microsoft.KeyVault/vaults/write", "deploying the temporary key vault")
+		} else {
+			// The certificate is stored in the vault of that name in the
+			// resource group of the build, see StepCertificateInKeyVault.
+			vaultGroup := c.BuildResourceGroupName
+			if c.tmpResourceGroupName != "" {
+				vaultGroup = c.tmpResourceGroupName
+			}
+			switch {
+			case vaultGroup == "":
+				log.Printf("Not checking the permissions on build_key_vault_name %q, its resource group is unknown", c.BuildKeyVaultName)
+			case strings.EqualFold(vaultGroup, c.tmpResourceGroupName):
+				add(subscriptionScope, "Microsoft.KeyVault/vaults/secrets/write", "storing the certificate in build_key_vault_name")
+			default:
+				vaultID := fmt.Sprintf("%s/providers/Microsoft.KeyVault/vaults/%s", resourceGroupScope(vaultGroup), c.BuildKeyVaultName)
+				add(vaultID, "Microsoft.KeyVault/vaults/secrets/write", "storing the certificate in build_key_vault_name")
+			}
+		}
+	}
+
+	if c.SkipCreateImage {
+		return required
+	}
+
+	if c.isManagedImage() {
+		imageScope := resourceGroupScope(c.ManagedImageResourceGroupName)
+		add(imageScope, "Microsoft.Compute/images/write", "creating the managed image")
+		if c.ManagedImageOSDiskSnapshotName != "" || c.ManagedImageDataDiskSnapshotPrefix != "" {
+			add(imageScope, "Microsoft.Compute/snapshots/write", "creating the managed image disk snapshots")
+		}
+	}
+
+	if c.isPublishToSIG() {
+		sigSubscriptionID := c.SharedGalleryDestination.SigDestinationSubscription
+		if sigSubscriptionID == "" {
+			sigSubscriptionID = subscriptionID
+		}
+		galleryImageID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/galleries/%s/images/%s",
+			sigSubscriptionID,
+			c.SharedGalleryDestination.SigDestinationResourceGroup,
+			c.SharedGalleryDestination.SigDestinationGalleryName,
+			c.SharedGalleryDestination.SigDestinationImageName)
+		add(galleryImageID, "Microsoft.Compute/galleries/images/versions/write", "publishing to the shared image gallery")
+	}
+
+	if c.isVHDSaveToStorage() {
+		accountID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s",
+			subscriptionID, c.ResourceGroupName, c.StorageAccount)
+		required = append(required, commonclient.RequiredPermission{
+			Scope:      accountID,
+			Action:     "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+			DataAction: true,
+			Reason:     "capturing the VHD to storage_account",
+		})
+	}
+
+	return required
+}
+
+// checkPermissions fails when the caller is missing any of the permissions
+// returned by requiredPermissions.
+func checkPermissions(ctx context.Context, list commonclient.PermissionsLister, c *Config) error {
+	missing, err := commonclient.FindMissingPermissions(ctx, list, c.requiredPermissions())
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %v", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the identity used by Packer is missing permissions required for this build:\n%s",
+			commonclient.FormatMissingPermissions(missing))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/constants"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	config := map[string]interface{}{
+		"image_offer":                       "ignore",
+		"image_publisher":                   "ignore",
+		"image_sku":                         "ignore",
+		"location":                          "ignore",
+		"subscription_id":                   "sub",
+		"communicator":                      "none",
+		"os_type":                           "linux",
+		"managed_image_name":                "image",
+		"managed_image_resource_group_name": "imagerg",
+		"check_permissions":                 true,
+	}
+
+	var c Config
+	if _, err := c.Prepare(config, getPackerConfiguration()); err != nil {
+		t.Fatalf("newConfig failed with %q", err)
+	}
+
+	want := map[string]string{
+		"Microsoft.Resources/subscriptions/resourceGroups/write":  "/subscriptions/sub",
+		"Microsoft.Resources/subscriptions/resourceGroups/delete": "/subscriptions/sub",
+		"Microsoft.Resources/deployments/write":                   "/subscriptions/sub",
+		"Microsoft.Compute/virtualMachines/write":                 "/subscriptions/sub",
+		"Microsoft.Compute/images/write":                          "/subscriptions/sub/resourceGroups/imagerg",
+	}
+	got := c.requiredPermissions()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d permissions, got %d: %+v", len(want), len(got), got)
+	}
+	for _, p := range got {
+		if want[p.Action] != p.Scope {
+			t.Errorf("Expected %s on %q, got %q", p.Action, want[p.Action], p.Scope)
+		}
+	}
+
+	c.BuildResourceGroupName = "buildrg"
+	c.SkipCreateImage = true
+	for _, p := range c.requiredPermissions() {
+		if strings.HasPrefix(p.Action, "Microsoft.Resources/subscriptions/resourceGroups/") {
+			t.Errorf("Expected %s not to be required with build_resource_group_name", p.Action)
+		}
+		if p.Action == "Microsoft.Compute/images/write" {
+			t.Errorf("Expected %s not to be required with skip_create_image", p.Action)
+		}
+		if p.Scope != "/subscriptions/sub/resourceGroups/buildrg" {
+			t.Errorf("Expected %s to be checked on the build resource group, got %q", p.Action, p.Scope)
+		}
+	}
+
+	list := func(_ context.Context, scope string) ([]permissions.Permission, error) {
+		return []permissions.Permission{{Actions: &[]string{"Microsoft.Compute/*"}}}, nil
+	}
+	err := checkPermissions(context.TODO(), list, &c)
+	if err == nil || !strings.Contains(err.Error(), "Microsoft.Resources/deployments/write") {
+		t.Fatalf("Expected the missing deployment permission to be reported, got %v", err)
+	}
+}
+
+func TestRequiredPermissionsBuildKeyVault(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name:   "build resource group",
+			config: Config{BuildResourceGroupName: "buildrg"},
+			want:   "/subscriptions/sub/resourceGroups/buildrg/providers/Microsoft.KeyVault/vaults/vault",
+		},
+		{
+			// the temporary resource group does not exist yet
+			name:   "temporary resource group",
+			config: Config{tmpResourceGroupName: "packer-Resource-Group-abc"},
+			want:   "/subscriptions/sub",
+		},
+		{
+			name: "unknown resource group",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.config
+			c.ClientConfig.SubscriptionID = "sub"
+			c.OSType = constants.Target_Windows
+			c.BuildKeyVaultName = "vault"
+			c.SkipCreateImage = true
+
+			var got string
+			for _, p := range c.requiredPermissions() {
+				if p.Action == "Microsoft.KeyVault/vaults/secrets/write" {
+					got = p.Scope
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Expected the build_key_vault_name permission to be checked on %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2021-07-01/skus"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/computerps"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/constants"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const (
+	vmSizeCheckWarn  = "warn"
+	vmSizeCheckError = "error"
+)
+
+// checkVMSize looks up the build VM size in the resource SKUs of the build
+// location, and verifies that it supports the features requested in the
+// configuration and that the vCPU quota of the subscription can fit it.
+// The returned problems are meant to be shown to the user; err is only set
+// when the lookups themselves failed.
+func (v *capabilityValidator) checkVMSize(ctx context.Context, c *Config, requiresGen2 bool) (problems []string, err error) {
+	location := normalizeAzureRegion(c.Location)
+	skuList, err := v.resourceSKUs(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	sku := findVirtualMachineSKU(skuList, c.VMSize)
+	if sku == nil {
+		return []string{fmt.Sprintf("it is not offered in %s", location)}, nil
+	}
+
+	problems = vmSizeCapabilityProblems(c, sku, requiresGen2)
+
+	usages, err := v.client.UsageListComplete(ctx, computerps.NewLocationID(v.subscriptionID, location))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the compute usages of %s: %v", location, err)
+	}
+	problems = append(problems, vmSizeQuotaProblems(c, sku, usages.Items)...)
+
+	return problems, nil
+}
+
+// reportVMSizeProblems shows the problems found by checkVMSize, and returns
+// an error if the build should not continue.
+func reportVMSizeProblems(ui packersdk.Ui, c *Config, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	if c.CheckVMSize == vmSizeCheckWarn {
+		for _, p := range problems {
+			ui.Say(fmt.Sprintf("Warning: the vm_size %q may not be usable for this build, %s", c.VMSize, p))
+		}
+		return nil
+	}
+	return fmt.Errorf("the vm_size %q cannot be used for this build:\n  %s", c.VMSize, strings.Join(problems, "\n  "))
+}
+
+// skuCapability returns the value of the named capability of sku, or an
+// empty string if the SKU does not report it.
+func skuCapability(sku *skus.ResourceSku, name string) string {
+	if sku.Capabilities == nil {
+		return ""
+	}
+	for _, capability := range *sku.Capabilities {
+		if capability.Name != nil && strings.EqualFold(*capability.Name, name) && capability.Value != nil {
+			return *capability.Value
+		}
+	}
+	return ""
+}
+
+func vmSizeCapabilityProblems(c *Config, sku *skus.ResourceSku, requiresGen2 bool) []string {
+	var problems []string
+	capable := func(name string) bool { return strings.EqualFold(skuCapability(sku, name), "True") }
+
+	if requiresGen2 && !strings.Contains(strings.ToUpper(skuCapability(sku, "HyperVGenerations")), "V2") {
+		problems = append(problems, "it does not support Generation 2 images")
+	}
+	switch c.SecurityType {
+	case constants.TrustedLaunch:
+		if capable("TrustedLaunchDisabled") {
+			problems = append(problems, "it does not support security_type TrustedLaunch")
+		}
+	case constants.ConfidentialVM:
+		if skuCapability(sku, "ConfidentialComputingType") == "" {
+			problems = append(problems, "it does not support security_type ConfidentialVM")
+		}
+	}
+	if c.EncryptionAtHost != nil && *c.EncryptionAtHost && !capable("EncryptionAtHostSupported") {
+		problems = append(problems, "it does not support encryption_at_host")
+	}
+	if c.AcceleratedNetworking != nil && *c.AcceleratedNetworking && !capable("AcceleratedNetworkingEnabled") {
+		problems = append(problems, "it does not support accelerated_networking")
+	}
+	if strings.EqualFold(c.DiskControllerType, "NVMe") && !strings.Contains(strings.ToUpper(skuCapability(sku, "DiskControllerTypes")), "NVME") {
+		problems = append(problems, "it does not support disk_controller_type NVMe")
+	}
+	if c.Spot.EvictionPolicy != "" && !capable("LowPriorityCapable") {
+		problems = append(problems, "it cannot be used for spot instances")
+	}
+
+	return problems
+}
+
+func vmSizeQuotaProblems(c *Config, sku *skus.ResourceSku, usages []computerps.Usage) []string {
+	vCPUs, err := strconv.ParseInt(skuCapability(sku, "vCPUs"), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	// Spot VMs only count against the regional low priority quota.
+	quotas := []string{"cores"}
+	if sku.Family != nil {
+		quotas = append(quotas, *sku.Family)
+	}
+	if c.Spot.EvictionPolicy != "" {
+		quotas = []string{"lowPriorityCores"}
+	}
+
+	var problems []string
+	for _, quota := range quotas {
+		for _, usage := range usages {
+			if usage.Name.Value == nil || !strings.EqualFold(*usage.Name.Value, quota) {
+				continue
+			}
+			if usage.CurrentValue+vCPUs > usage.Limit {
+				name := quota
+				if usage.Name.LocalizedValue != nil {
+					name = *usage.Name.LocalizedValue
+				}
+				problems = append(problems, fmt.Sprintf("the %d vCPUs it needs exceed the %q quota (%d of %d vCPUs in use)",
+					vCPUs, name, usage.CurrentValue, usage.Limit))
+			}
+		}
+	}
+	return problems
+}
+
+// sourceImageLookup gets the source images whose Hyper-V generation
+// decides whether the build VM has to support Generation 2 images.
+type sourceImageLookup struct {
+	getGalleryImage    func(context.Context, galleryimages.GalleryImageId) (*galleryimages.GalleryImage, error)
+	getManagedImage    func(context.Context, images.ImageId) (*images.Image, error)
+	getPlatformImage   func(context.Context, virtualmachineimages.SkuVersionId) (*virtualmachineimages.VirtualMachineImage, error)
+	listPlatformImages func(context.Context, virtualmachineimages.SkuId) ([]virtualmachineimages.VirtualMachineImageResource, error)
+}
+
+func newSourceImageLookup(azureClient *AzureClient) sourceImageLookup {
+	return sourceImageLookup{
+		getGalleryImage: func(ctx context.Context, id galleryimages.GalleryImageId) (*galleryimages.GalleryImage, error) {
+			result, err := azureClient.GalleryImagesClient.Get(ctx, id)
+			return result.Model, err
+		},
+		getManagedImage: func(ctx context.Context, id images.ImageId) (*images.Image, error) {
+			result, err := azureClient.ImagesClient.Get(ctx, id, images.DefaultGetOperationOptions())
+			return result.Model, err
+		},
+		getPlatformImage: func(ctx context.Context, id virtualmachineimages.SkuVersionId) (*virtualmachineimages.VirtualMachineImage, error) {
+			result, err := azureClient.VirtualMachineImagesClient.Get(ctx, id)
+			return result.Model, err
+		},
+		listPlatformImages: func(ctx context.Context, id virtualmachineimages.SkuId) ([]virtualmachineimages.VirtualMachineImageResource, error) {
+			result, err := azureClient.VirtualMachineImagesClient.List(ctx, id, virtualmachineimages.DefaultListOperationOptions())
+			if err != nil || result.Model == nil {
+				return nil, err
+			}
+			return *result.Model, nil
+		},
+	}
+}
+
+// sourceRequiresGen2 reports whether the build VM has to support Generation 2
+// images. This is the case for Trusted Launch and Confidential VMs, and for
+// Shared Image Gallery, platform and managed image sources with a V2 image.
+func sourceRequiresGen2(ctx context.Context, lookup sourceImageLookup, c *Config) (bool, error) {
+	if c.SecurityType != "" {
+		return true, nil
+	}
+
+	switch {
+	case c.SharedGallery.GalleryName != "" || c.SharedGallery.ID != "":
+		return galleryImageRequiresGen2(ctx, lookup, c)
+	case c.ImagePublisher != "":
+		return platformImageRequiresGen2(ctx, lookup, c)
+	case c.CustomManagedImageName != "":
+		id := images.NewImageID(c.ClientConfig.SubscriptionID, c.CustomManagedImageResourceGroupName, c.CustomManagedImageName)
+		image, err := lookup.getManagedImage(ctx, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the source managed image %s: %v", c.CustomManagedImageName, err)
+		}
+		if image == nil || image.Properties == nil || image.Properties.HyperVGeneration == nil {
+			return false, nil
+		}
+		return *image.Properties.HyperVGeneration == images.HyperVGenerationTypesVTwo, nil
+	}
+	return false, nil
+}
+
+func galleryImageRequiresGen2(ctx context.Context, lookup sourceImageLookup, c *Config) (bool, error) {
+	source := &c.SharedGallery
+	if source.GalleryName == "" {
+		source = c.getSharedImageGalleryObjectFromId()
+	}
+	if source == nil || source.Subscription == "" {
+		return false, nil
+	}
+
+	image, err := lookup.getGalleryImage(ctx, galleryimages.NewGalleryImageID(source.Subscription, source.ResourceGroup, source.GalleryName, source.ImageName))
+	if err != nil {
+		return false, fmt.Errorf("failed to get the source Shared Gallery Image %s: %v", source.ImageName, err)
+	}
+	if image == nil || image.Properties == nil || image.Properties.HyperVGeneration == nil {
+		return false, nil
+	}
+	return *image.Properties.HyperVGeneration == galleryimages.HyperVGenerationVTwo, nil
+}
+
+// platformImageRequiresGen2 looks up the generation of the platform image
+// version, resolving `latest` to the most recent version first.
+func platformImageRequiresGen2(ctx context.Context, lookup sourceImageLookup, c *Config) (bool, error) {
+	location := normalizeAzureRegion(c.Location)
+	version := c.ImageVersion
+	if version == "" || strings.EqualFold(version, DefaultImageVersion) {
+		versions, err := lookup.listPlatformImages(ctx, virtualmachineimages.NewSkuID(c.ClientConfig.SubscriptionID, location, c.ImagePublisher, c.ImageOffer, c.ImageSku))
+		if err != nil {
+			return false, fmt.Errorf("failed to list the versions of the source image %s:%s:%s: %v", c.ImagePublisher, c.ImageOffer, c.ImageSku, err)
+		}
+		version = latestPlatformImageVersion(versions)
+		if version == "" {
+			return false, fmt.Errorf("the source image %s:%s:%s has no versions in %s", c.ImagePublisher, c.ImageOffer, c.ImageSku, location)
+		}
+	}
+
+	id := virtualmachineimages.NewSkuVersionID(c.ClientConfig.SubscriptionID, location, c.ImagePublisher, c.ImageOffer, c.ImageSku, version)
+	image, err := lookup.getPlatformImage(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the source image %s:%s:%s:%s: %v", c.ImagePublisher, c.ImageOffer, c.ImageSku, version, err)
+	}
+	if image == nil || image.Properties == nil || image.Properties.HyperVGeneration == nil {
+		return false, nil
+	}
+	return *image.Properties.HyperVGeneration == virtualmachineimages.HyperVGenerationTypesVTwo, nil
+}
+
+// latestPlatformImageVersion returns the highest of the platform image
+// versions, compared as versions rather than strings so that 1.10.0 is more
+// recent than 1.9.0, or an empty string if none of them is a version.
+func latestPlatformImageVersion(versions []virtualmachineimages.VirtualMachineImageResource) string {
+	var latest *goversion.Version
+	for _, v := range versions {
+		parsed, err := goversion.NewVersion(v.Name)
+		if err != nil {
+			log.Printf("[DEBUG] Skipping platform image version %q: %v", v.Name, err)
+			continue
+		}
+		if latest == nil || parsed.GreaterThan(latest) {
+			latest = parsed
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Original()
+}
@@ -0,0 +1,195 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package arm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2021-07-01/skus"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/computerps"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/constants"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testVMSizeSKU(name, family string, capabilities map[string]string) skus.ResourceSku {
+	var caps []skus.ResourceSkuCapabilities
+	for k, v := range capabilities {
+		caps = append(caps, skus.ResourceSkuCapabilities{Name: &k, Value: &v})
+	}
+	resourceType := "virtualMachines"
+	return skus.ResourceSku{Name: &name, Family: &family, ResourceType: &resourceType, Capabilities: &caps}
+}
+
+func testUsage(name string, current, limit int64) computerps.Usage {
+	return computerps.Usage{Name: computerps.UsageName{Value: &name}, CurrentValue: current, Limit: limit}
+}
+
+func TestCheckVMSize(t *testing.T) {
+	trueValue := true
+	d4 := testVMSizeSKU("Standard_D4s_v3", "standardDSv3Family", map[string]string{
+		"vCPUs":                        "4",
+		"HyperVGenerations":            "V1,V2",
+		"AcceleratedNetworkingEnabled": "True",
+		"EncryptionAtHostSupported":    "True",
+		"LowPriorityCapable":           "True",
+	})
+	a1 := testVMSizeSKU("Standard_A1", "standardA0_A7Family", map[string]string{
+		"vCPUs":                        "1",
+		"HyperVGenerations":            "V1",
+		"TrustedLaunchDisabled":        "True",
+		"DiskControllerTypes":          "SCSI",
+		"AcceleratedNetworkingEnabled": "False",
+	})
+
+	tests := []struct {
+		name         string
+		config       Config
+		requiresGen2 bool
+		usages       []computerps.Usage
+		want         []string
+	}{
+		{
+			name:   "fits",
+			config: Config{VMSize: "standard_d4s_v3", Location: "West US 2"},
+			usages: []computerps.Usage{testUsage("cores", 10, 20), testUsage("standardDSv3Family", 16, 20)},
+		},
+		{
+			name:   "family quota exceeded",
+			config: Config{VMSize: "Standard_D4s_v3", Location: "westus2"},
+			usages: []computerps.Usage{testUsage("cores", 10, 20), testUsage("standardDSv3Family", 17, 20)},
+			want:   []string{`"standardDSv3Family" quota (17 of 20 vCPUs in use)`},
+		},
+		{
+			name:   "spot only counts low priority quota",
+			config: Config{VMSize: "Standard_D4s_v3", Location: "westus2", Spot: Spot{EvictionPolicy: "Delete"}},
+			usages: []computerps.Usage{testUsage("cores", 20, 20), testUsage("lowPriorityCores", 100, 100)},
+			want:   []string{`"lowPriorityCores" quota (100 of 100 vCPUs in use)`},
+		},
+		{
+			name:   "unknown size",
+			config: Config{VMSize: "Standard_Nope", Location: "westus2"},
+			want:   []string{"not offered in westus2"},
+		},
+		{
+			name: "unsupported capabilities",
+			config: Config{
+				VMSize:                "Standard_A1",
+				Location:              "westus2",
+				SecurityType:          constants.TrustedLaunch,
+				AcceleratedNetworking: &trueValue,
+				EncryptionAtHost:      &trueValue,
+				DiskControllerType:    "NVMe",
+				Spot:                  Spot{EvictionPolicy: "Deallocate"},
+			},
+			requiresGen2: true,
+			want: []string{
+				"Generation 2",
+				"security_type TrustedLaunch",
+				"encryption_at_host",
+				"accelerated_networking",
+				"disk_controller_type NVMe",
+				"spot instances",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCapabilityClient{skus: []skus.ResourceSku{a1, d4}, usages: tt.usages}
+			problems, err := newCapabilityValidator(client, "sub").checkVMSize(context.TODO(), &tt.config, tt.requiresGen2)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if client.filter != "location eq 'westus2'" {
+				t.Errorf("Expected SKUs to be filtered on the normalized location, got %q", client.filter)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("Expected %d problems, got %d: %q", len(tt.want), len(problems), problems)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("Expected problem %q to contain %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestReportVMSizeProblems(t *testing.T) {
+	ui := packersdk.TestUi(t)
+	c := &Config{VMSize: "Standard_A1", CheckVMSize: vmSizeCheckWarn}
+	if err := reportVMSizeProblems(ui, c, []string{"it does not support encryption_at_host"}); err != nil {
+		t.Fatalf("Expected only a warning, got %v", err)
+	}
+
+	c.CheckVMSize = vmSizeCheckError
+	err := reportVMSizeProblems(ui, c, []string{"it does not support encryption_at_host"})
+	if err == nil || !strings.Contains(err.Error(), "encryption_at_host") {
+		t.Fatalf("Expected the problem to fail the build, got %v", err)
+	}
+}
+
+func TestSourceRequiresGen2_PlatformImage(t *testing.T) {
+	gen := func(g virtualmachineimages.HyperVGenerationTypes) *virtualmachineimages.VirtualMachineImage {
+		return &virtualmachineimages.VirtualMachineImage{Properties: &virtualmachineimages.VirtualMachineImageProperties{HyperVGeneration: &g}}
+	}
+	var gotVersion string
+	lookup := sourceImageLookup{
+		listPlatformImages: func(_ context.Context, id virtualmachineimages.SkuId) ([]virtualmachineimages.VirtualMachineImageResource, error) {
+			// as ordered by name desc, a string sort
+			return []virtualmachineimages.VirtualMachineImageResource{{Name: "9.0.20250101"}, {Name: "22.04.202601010"}, {Name: "22.04.202512010"}, {Name: "22.04.20251201"}}, nil
+		},
+		getPlatformImage: func(_ context.Context, id virtualmachineimages.SkuVersionId) (*virtualmachineimages.VirtualMachineImage, error) {
+			gotVersion = id.VersionName
+			if strings.HasSuffix(id.SkuName, "-gen2") {
+				return gen(virtualmachineimages.HyperVGenerationTypesVTwo), nil
+			}
+			return gen(virtualmachineimages.HyperVGenerationTypesVOne), nil
+		},
+	}
+
+	tests := []struct {
+		sku, version string
+		want         bool
+		wantVersion  string
+	}{
+		{sku: "22_04-lts-gen2", version: "latest", want: true, wantVersion: "22.04.202601010"},
+		{sku: "22_04-lts", version: "22.04.202512010", want: false, wantVersion: "22.04.202512010"},
+	}
+	for _, tt := range tests {
+		c := &Config{Location: "westus2", ImagePublisher: "Canonical", ImageOffer: "0001-com-ubuntu-server-jammy", ImageSku: tt.sku, ImageVersion: tt.version}
+		got, err := sourceRequiresGen2(context.TODO(), lookup, c)
+		if err != nil {
+			t.Fatalf("sourceRequiresGen2() failed: %v", err)
+		}
+		if got != tt.want || gotVersion != tt.wantVersion {
+			t.Errorf("sourceRequiresGen2(%s:%s) = %v for version %q, want %v for %q", tt.sku, tt.version, got, gotVersion, tt.want, tt.wantVersion)
+		}
+	}
+}
+
+func TestSourceRequiresGen2_ManagedImage(t *testing.T) {
+	for _, generation := range []images.HyperVGenerationTypes{images.HyperVGenerationTypesVOne, images.HyperVGenerationTypesVTwo} {
+		lookup := sourceImageLookup{
+			getManagedImage: func(_ context.Context, id images.ImageId) (*images.Image, error) {
+				if id.ResourceGroupName != "imagerg" || id.ImageName != "myimage" {
+					t.Errorf("Unexpected image %s", id)
+				}
+				return &images.Image{Properties: &images.ImageProperties{HyperVGeneration: &generation}}, nil
+			},
+		}
+		c := &Config{CustomManagedImageName: "myimage", CustomManagedImageResourceGroupName: "imagerg"}
+		got, err := sourceRequiresGen2(context.TODO(), lookup, c)
+		if err != nil {
+			t.Fatalf("sourceRequiresGen2() failed: %v", err)
+		}
+		if want := generation == images.HyperVGenerationTypesVTwo; got != want {
+			t.Errorf("sourceRequiresGen2() of a %s image = %v, want %v", generation, got, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bufio"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	posixpath "path"
+	"path/filepath"
+	"strings"
+)
+
+// imageArchitecture is a CPU architecture that images can be built for.
+type imageArchitecture struct {
+	// Name is the name of the architecture in the Azure APIs.
+	Name    string
+	GOARCH  string
+	Machine elf.Machine
+	// Qemu is the name of the architecture in qemu, as in qemu-aarch64-static.
+	Qemu string
+	// Magic and Mask match the ELF header of the executables of the
+	// architecture in binfmt_misc, as in qemu-binfmt-conf.sh.
+	Magic []byte
+	Mask  []byte
+}
+
+var imageArchitectures = []imageArchitecture{
+	{
+		Name:    "x64",
+		GOARCH:  "amd64",
+		Machine: elf.EM_X86_64,
+		Qemu:    "x86_64",
+		Magic:   []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3e, 0x00},
+		Mask:    []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xfe, 0xfe, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe, 0xff, 0xff, 0xff},
+	},
+	{
+		Name:    "Arm64",
+		GOARCH:  "arm64",
+		Machine: elf.EM_AARCH64,
+		Qemu:    "aarch64",
+		Magic:   []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb7, 0x00},
+		Mask:    []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe, 0xff, 0xff, 0xff},
+	},
+}
+
+func architectureByMachine(machine elf.Machine) (imageArchitecture, bool) {
+	for _, a := range imageArchitectures {
+		if a.Machine == machine {
+			return a, true
+		}
+	}
+	return imageArchitecture{}, false
+}
+
+func architectureByGOARCH(goarch string) (imageArchitecture, bool) {
+	for _, a := range imageArchitectures {
+		if a.GOARCH == goarch {
+			return a, true
+		}
+	}
+	return imageArchitecture{}, false
+}
+
+// binfmtHandler returns the line registering a binfmt_misc handler named
+// name, running the executables of the architecture with the qemu user mode
+// emulator at interpreter.
+func (a imageArchitecture) binfmtHandler(name, interpreter string) string {
+	escape := func(b []byte) string {
+		var sb strings.Builder
+		for _, c := range b {
+			fmt.Fprintf(&sb, `\x%02x`, c)
+		}
+		return sb.String()
+	}
+	return fmt.Sprintf(":%s:M::%s:%s:%s:", name, escape(a.Magic), escape(a.Mask), interpreter)
+}
+
+// architectureProbes are the executables whose ELF header tells the
+// architecture of an image.
+var architectureProbes = []string{"/bin/sh", "/usr/bin/sh", "/bin/bash", "/usr/bin/env", "/sbin/init"}
+
+// detectImageMachine returns the ELF machine of the executables of the root
+// filesystem mounted at root, and the executable it was read from.
+func detectImageMachine(root string) (elf.Machine, string, error) {
+	for _, probe := range architectureProbes {
+		path, err := resolveInRoot(root, probe)
+		if err != nil {
+			continue
+		}
+		machine, err := readELFMachine(path)
+		if err != nil {
+			continue
+		}
+		return machine, probe, nil
+	}
+	return elf.EM_NONE, "", fmt.Errorf("none of %s is an ELF executable", strings.Join(architectureProbes, ", "))
+}
+
+// readELFMachine returns the machine of the ELF header of the file at path.
+func readELFMachine(path string) (elf.Machine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return elf.EM_NONE, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return elf.EM_NONE, err
+	}
+	if string(header[:4]) != elf.ELFMAG {
+		return elf.EM_NONE, fmt.Errorf("%s is not an ELF file", path)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if elf.Data(header[elf.EI_DATA]) == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+	return elf.Machine(order.Uint16(header[18:])), nil
+}
+
+// resolveInRoot returns the path on the host of name in the filesystem
+// mounted at root, following symbolic links as if root was "/": absolute
+// links in the image must not point to the files of the host.
+func resolveInRoot(root, name string) (string, error) {
+	return resolvePathInRoot(root, name, false)
+}
+
+// resolveMountTarget is resolveInRoot for a directory that is created if it
+// does not exist: the components that do not exist yet are kept as they are.
+func resolveMountTarget(root, name string) (string, error) {
+	return resolvePathInRoot(root, name, true)
+}
+
+func resolvePathInRoot(root, name string, allowMissing bool) (string, error) {
+	resolved := "/"
+	rest := strings.Split(name, "/")
+	for links := 0; len(rest) > 0; {
+		component := rest[0]
+		rest = rest[1:]
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = posixpath.Dir(resolved)
+			continue
+		}
+
+		next := posixpath.Join(resolved, component)
+		fi, err := os.Lstat(filepath.Join(root, next))
+		if err != nil && !(allowMissing && os.IsNotExist(err)) {
+			return "", err
+		}
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if links++; links > 40 {
+			return "", fmt.Errorf("%s: too many levels of symbolic links", name)
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if posixpath.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return filepath.Join(root, resolved), nil
+}
+
+// binfmtStatus is the status of a binfmt_misc handler, as read from
+// /proc/sys/fs/binfmt_misc/<name>.
+type binfmtStatus struct {
+	Enabled     bool
+	Interpreter string
+	Flags       string
+}
+
+func parseBinfmtStatus(r io.Reader) binfmtStatus {
+	var status binfmtStatus
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		switch key {
+		case "enabled":
+			status.Enabled = true
+		case "interpreter":
+			status.Interpreter = value
+		case "flags:":
+			status.Flags = value
+		}
+	}
+	return status
+}
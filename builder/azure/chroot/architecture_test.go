@@ -0,0 +1,157 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestELF writes the beginning of a 64-bit little endian ELF executable
+// for machine at path.
+func writeTestELF(t *testing.T, path string, machine elf.Machine) {
+	t.Helper()
+	header := make([]byte, 64)
+	copy(header, elf.ELFMAG)
+	header[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	binary.LittleEndian.PutUint16(header[16:], uint16(elf.ET_DYN))
+	binary.LittleEndian.PutUint16(header[18:], uint16(machine))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, header, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testUsrMergedRoot creates a root filesystem where /bin is a link to usr/bin
+// and /usr/bin/sh an absolute link to /usr/bin/dash, an aarch64 executable.
+func testUsrMergedRoot(t *testing.T) string {
+	root := t.TempDir()
+	writeTestELF(t, filepath.Join(root, "usr", "bin", "dash"), elf.EM_AARCH64)
+	if err := os.Symlink("usr/bin", filepath.Join(root, "bin")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/usr/bin/dash", filepath.Join(root, "usr", "bin", "sh")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func Test_resolveInRoot(t *testing.T) {
+	root := testUsrMergedRoot(t)
+	if err := os.Symlink("../../..", filepath.Join(root, "usr", "bin", "up")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("loop", filepath.Join(root, "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "/bin/sh", want: "/usr/bin/dash"},
+		{name: "/usr/bin/../bin/./sh", want: "/usr/bin/dash"},
+		// ".." does not go above the root
+		{name: "/bin/up/usr/bin/dash", want: "/usr/bin/dash"},
+		{name: "/loop", wantErr: true},
+		{name: "/bin/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveInRoot(root, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveInRoot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != filepath.Join(root, tt.want) {
+				t.Errorf("resolveInRoot() = %q, want %q", got, filepath.Join(root, tt.want))
+			}
+		})
+	}
+}
+
+func Test_detectImageMachine(t *testing.T) {
+	machine, probe, err := detectImageMachine(testUsrMergedRoot(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if machine != elf.EM_AARCH64 || probe != "/bin/sh" {
+		t.Errorf("detectImageMachine() = %v, %q, want EM_AARCH64, /bin/sh", machine, probe)
+	}
+
+	// /bin/sh is a script, /usr/bin/env is an executable
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "bin", "sh"), []byte("#!/bin/busybox\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestELF(t, filepath.Join(root, "usr", "bin", "env"), elf.EM_X86_64)
+	if machine, probe, err := detectImageMachine(root); err != nil || machine != elf.EM_X86_64 || probe != "/usr/bin/env" {
+		t.Errorf("detectImageMachine() = %v, %q, %v, want EM_X86_64, /usr/bin/env", machine, probe, err)
+	}
+
+	if _, _, err := detectImageMachine(t.TempDir()); err == nil {
+		t.Error("detectImageMachine() of an empty filesystem did not fail")
+	}
+}
+
+func Test_imageArchitecture_binfmtHandler(t *testing.T) {
+	arch, _ := architectureByMachine(elf.EM_AARCH64)
+	registration := arch.binfmtHandler("packer-qemu-aarch64", "/usr/bin/qemu-aarch64-static")
+	// as registered by qemu-binfmt-conf.sh
+	want := `:packer-qemu-aarch64:M::` +
+		`\x7f\x45\x4c\x46\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xb7\x00:` +
+		`\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff:` +
+		`/usr/bin/qemu-aarch64-static:`
+	if registration != want {
+		t.Errorf("registration = %q, want %q", registration, want)
+	}
+}
+
+func Test_parseBinfmtStatus(t *testing.T) {
+	got := parseBinfmtStatus(strings.NewReader(`enabled
+interpreter /usr/libexec/qemu-binfmt/aarch64-binfmt-P
+flags: POCF
+offset 0
+magic 7f454c460201010000000000000000000200b700
+mask ffffffffffffff00fffffffffffffffffeffffff
+`))
+	want := binfmtStatus{Enabled: true, Interpreter: "/usr/libexec/qemu-binfmt/aarch64-binfmt-P", Flags: "POCF"}
+	if got != want {
+		t.Errorf("parseBinfmtStatus() = %+v, want %+v", got, want)
+	}
+	if got := parseBinfmtStatus(strings.NewReader("disabled\ninterpreter /usr/bin/qemu-aarch64-static\n")); got.Enabled {
+		t.Errorf("parseBinfmtStatus() of a disabled handler = %+v", got)
+	}
+}
+
+func Test_resolveMountTarget(t *testing.T) {
+	root := testUsrMergedRoot(t)
+	if err := os.Symlink("/usr/bin", filepath.Join(root, "boot")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"/boot/efi":        "/usr/bin/efi",
+		"/srv/data":        "/srv/data",
+		"/srv/../boot/efi": "/usr/bin/efi",
+	} {
+		got, err := resolveMountTarget(root, name)
+		if err != nil {
+			t.Fatalf("resolveMountTarget(%q) error = %v", name, err)
+		}
+		if got != filepath.Join(root, want) {
+			t.Errorf("resolveMountTarget(%q) = %q, want %q", name, got, filepath.Join(root, want))
+		}
+	}
+}
@@ -53,9 +53,23 @@ type Config struct {
 
 	// When set to `true`, starts with an empty, unpartitioned disk. Defaults to `false`.
 	FromScratch bool `mapstructure:"from_scratch"`
+	// The partitions, LVM volumes and filesystems to create on the empty disk of a
+	// `from_scratch` build, instead of creating them with `pre_mount_commands`. The
+	// filesystems are mounted at their mount points under `mount_path`, and the
+	// UUID of each filesystem and the PARTUUID of each partition are available to
+	// provisioners as `UUID_<name>` and `PARTUUID_<name>` in the
+	// [build shared information variables](#build-shared-information-variables).
+	DiskLayout DiskLayout `mapstructure:"disk_layout" required:"false"`
 	// One of the following can be used as a source for an image:
 	// - a shared image version resource ID
 	// - a managed disk resource ID
+	// - a managed image resource ID, the snapshot or VHD the image was
+	//   created from must still exist; images captured from a managed disk
+	//   are published to a temporary gallery (see `temporary_gallery_id`)
+	//   to create the OS disk from
+	// - a snapshot resource ID
+	// - the URL of a VHD blob, in a storage account of the subscription and
+	//   location of the VM
 	// - a publisher:offer:sku:version specifier for platform image sources.
 	Source     string `mapstructure:"source" required:"true"`
 	sourceType sourceType
@@ -69,8 +83,8 @@ type Config struct {
 	// mount path are provided by `{{.Device}}` and `{{.MountPath}}`.
 	ManualMountCommand string `mapstructure:"manual_mount_command" required:"false"`
 	// A series of commands to execute after attaching the root volume and before mounting the chroot.
-	// This is not required unless using `from_scratch`. If so, this should include any partitioning
-	// and filesystem creation commands. The path to the device is provided by `{{.Device}}`.
+	// This is not required unless using `from_scratch` without `disk_layout`. If so, this should include
+	// any partitioning and filesystem creation commands. The path to the device is provided by `{{.Device}}`.
 	PreMountCommands []string `mapstructure:"pre_mount_commands"`
 	// Options to supply the `mount` command when mounting devices. Each option will be prefixed with
 	// `-o` and supplied to the `mount` command ran by Packer. Because this command is ran in a shell,
@@ -82,6 +96,20 @@ type Config struct {
 	// to `/mnt/packer-amazon-chroot-volumes/{{.Device}}`. This is a configuration template where the `.Device`
 	// variable is replaced with the name of the device where the volume is attached.
 	MountPath string `mapstructure:"mount_path"`
+	// When set to `true`, the filesystems listed in the `/etc/fstab` of the root filesystem that are
+	// on the attached disk, like `/boot`, `/boot/efi`, `/var` or btrfs subvolumes, are mounted under
+	// `mount_path` after the root device, with their fstab options. Entries referring to the disk by
+	// `UUID=`, `LABEL=`, `PARTUUID=`, `PARTLABEL=`, `/dev/disk/by-*` or LVM device paths are resolved
+	// to the partitions and logical volumes of the attached disk, other entries are skipped, as are
+	// `noauto` entries and entries with an unsupported filesystem type or mount options.
+	// Defaults to `false`.
+	AutoMountFstab bool `mapstructure:"auto_mount_fstab" required:"false"`
+	// When set to `true` and the root filesystem is on the last partition of the disk, that
+	// partition is grown to the end of the disk after mounting, for example when `os_disk_size_gb`
+	// is larger than the source, moving the backup GPT to the new end of the disk. The LUKS volume,
+	// LVM physical volume and root logical volume on the partition, if any, and the ext4 or xfs root
+	// filesystem are grown with it. Only GPT partitioned disks are supported. Defaults to `false`.
+	GrowRootPartition bool `mapstructure:"grow_root_partition" required:"false"`
 	// As `pre_mount_commands`, but the commands are executed after mounting the root device and before the
 	// extra mount and copy steps. The device and mount path are provided by `{{.Device}}` and `{{.MountPath}}`.
 	PostMountCommands []string `mapstructure:"post_mount_commands"`
@@ -89,6 +117,13 @@ type Config struct {
 	// some additional documentation which is in the "Chroot Mounts" section below. Please read that section
 	// for more information on how to use this.
 	ChrootMounts [][]string `mapstructure:"chroot_mounts"`
+	// The statically linked qemu user mode emulator on the host that runs the executables of the
+	// image when it is not for the architecture of the host, for example to build Arm64 images on an
+	// x64 VM. The architecture of the image is detected from the executables of the mounted root
+	// filesystem. Defaults to `/usr/bin/qemu-<arch>-static`, as installed by the `qemu-user-static`
+	// packages. Not needed when the host has a binfmt_misc handler for the architecture registered
+	// with the `F` flag.
+	QemuBinary string `mapstructure:"qemu_binary" required:"false"`
 	// Paths to files on the running Azure instance that will be copied into the chroot environment prior to
 	// provisioning. Defaults to `/etc/resolv.conf` so that DNS lookups work. Pass an empty list to skip copying
 	// `/etc/resolv.conf`. You may need to do this if you're building an image that uses systemd.
@@ -127,12 +162,26 @@ type Config struct {
 	// The prefix for the resource ids of the temporary data disk snapshots that will be created. The snapshots will be suffixed with a number. Will be generated if not set.
 	TemporaryDataDiskSnapshotIDPrefix string `mapstructure:"temporary_data_disk_snapshot_id"`
 
+	// The id of the temporary gallery that a source managed image captured from a managed disk is published to,
+	// as Azure cannot create a disk from such an image directly. Will be generated if not set.
+	TemporaryGalleryID string `mapstructure:"temporary_gallery_id"`
+
 	// Explicitly specify the LVM root device path to mount (e.g., `/dev/mapper/rhel-root`).
 	// When set, LVM volume groups are activated and this device is used as the mount target
 	// instead of a partition on the raw disk. Normally, LVM is auto-detected and does not
 	// require any configuration. Use this only when auto-detection picks the wrong logical volume.
 	LVMRootDevice string `mapstructure:"lvm_root_device"`
 
+	// The passphrase of the LUKS volume holding the root filesystem. When set, a LUKS header is
+	// looked for on the attached disk and its partitions and the volume is opened with
+	// `cryptsetup` before LVM detection and mounting, and closed before the disk is detached.
+	// Conflicts with `luks_key_vault_secret_id`.
+	LUKSPassphrase string `mapstructure:"luks_passphrase" required:"false"`
+	// As `luks_passphrase`, but the passphrase is read from the Key Vault secret with this ID,
+	// for example `https://myvault.vault.azure.net/secrets/luks-key`, using the credentials of
+	// the build. The latest version of the secret is used when the ID has no version.
+	LUKSKeyVaultSecretID string `mapstructure:"luks_key_vault_secret_id" required:"false"`
+
 	// A series of commands to execute on the **host** after provisioning but before unmounting
 	// the chroot and deactivating LVM. Useful for host-side operations on the still-mounted
 	// filesystem such as `fstrim` or `sync`. These commands do **not** run inside the chroot;
@@ -141,6 +190,13 @@ type Config struct {
 	// `{{.MountPath}}`.
 	PreUnmountCommands []string `mapstructure:"pre_unmount_commands"`
 
+	// When set to `true`, the free space of the filesystems of the attached disk that are mounted
+	// under `mount_path` is discarded with `fstrim` after `pre_unmount_commands`, so that the
+	// blocks of the files deleted during provisioning are not captured in the image. Filesystems
+	// that cannot be trimmed, or all of them when the disk does not support discard, have their
+	// free space filled with zeros instead. Defaults to `false`.
+	TrimFilesystems bool `mapstructure:"trim_filesystems" required:"false"`
+
 	// If set to `true`, leaves the temporary disks and snapshots behind in the Packer VM resource group. Defaults to `false`
 	SkipCleanup bool `mapstructure:"skip_cleanup"`
 
@@ -150,6 +206,19 @@ type Config struct {
 	// The shared image to create using this build.
 	SharedImageGalleryDestination SharedImageGalleryDestination `mapstructure:"shared_image_destination"`
 
+	// The page blob to copy the OS disk to as a fixed VHD, for example to
+	// submit it to a marketplace. The OS disk is snapshotted after the chroot
+	// is torn down and the snapshot is copied into the blob.
+	VHDDestination VHDDestination `mapstructure:"vhd_destination"`
+
+	// If set to `true`, Packer checks the effective Azure RBAC permissions of the
+	// identity it authenticates with on the Packer VM, the temporary disk and
+	// snapshot resource groups and the image destinations before creating any
+	// resources, and fails with a list of the missing actions. The storage
+	// account of `vhd_destination` and the Key Vault of `luks_key_vault_secret_id`
+	// are looked up by name in the subscription, and must be in it. Defaults to `false`.
+	CheckPermissions bool `mapstructure:"check_permissions" required:"false"`
+
 	ctx interpolate.Context
 }
 
@@ -159,6 +228,9 @@ const (
 	sourcePlatformImage sourceType = "PlatformImage"
 	sourceDisk          sourceType = "Disk"
 	sourceSharedImage   sourceType = "SharedImage"
+	sourceManagedImage  sourceType = "ManagedImage"
+	sourceSnapshot      sourceType = "Snapshot"
+	sourceVHD           sourceType = "VHD"
 )
 
 // GetContext implements ContextProvider to allow steps to use the config context
@@ -285,6 +357,16 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		}
 	}
 
+	if b.config.TemporaryGalleryID == "" {
+		if def, err := interpolate.Render(
+			"/subscriptions/{{ vm `subscription_id` }}/resourceGroups/{{ vm `resource_group` }}/providers/Microsoft.Compute/galleries/PackerTemp_gallery_{{timestamp}}",
+			&b.config.ctx); err == nil {
+			b.config.TemporaryGalleryID = def
+		} else {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unable to render temporary gallery id: %s", err))
+		}
+	}
+
 	if b.config.OSDiskStorageAccountType == "" {
 		b.config.OSDiskStorageAccountType = string(virtualmachines.StorageAccountTypesPremiumLRS)
 	}
@@ -316,13 +398,25 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			errs = packersdk.MultiErrorAppend(
 				errs, errors.New("source cannot be specified when building from_scratch"))
 		}
+		if b.config.AutoMountFstab {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("auto_mount_fstab cannot be used when building from_scratch"))
+		}
+		if b.config.GrowRootPartition {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("grow_root_partition cannot be used when building from_scratch"))
+		}
+		if b.config.LUKSPassphrase != "" || b.config.LUKSKeyVaultSecretID != "" {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("luks_passphrase and luks_key_vault_secret_id cannot be used when building from_scratch"))
+		}
 		if b.config.OSDiskSizeGB == 0 {
 			errs = packersdk.MultiErrorAppend(
 				errs, errors.New("os_disk_size_gb is required with from_scratch"))
 		}
-		if len(b.config.PreMountCommands) == 0 {
+		if len(b.config.PreMountCommands) == 0 && !azcommon.StringsContains(md.Keys, "disk_layout") {
 			errs = packersdk.MultiErrorAppend(
-				errs, errors.New("pre_mount_commands is required with from_scratch"))
+				errs, errors.New("pre_mount_commands or disk_layout is required with from_scratch"))
 		}
 	} else {
 		if _, err := client.ParsePlatformImageURN(b.config.Source); err == nil {
@@ -338,9 +432,22 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			strings.EqualFold(id.ResourceType.String(), "galleries/images/versions") {
 			log.Println("Source is a shared image ID:", b.config.Source)
 			b.config.sourceType = sourceSharedImage
+		} else if id, err := client.ParseResourceID(b.config.Source); err == nil &&
+			strings.EqualFold(id.Provider, "Microsoft.Compute") &&
+			strings.EqualFold(id.ResourceType.String(), "images") {
+			log.Println("Source is a managed image ID:", b.config.Source)
+			b.config.sourceType = sourceManagedImage
+		} else if id, err := client.ParseResourceID(b.config.Source); err == nil &&
+			strings.EqualFold(id.Provider, "Microsoft.Compute") &&
+			strings.EqualFold(id.ResourceType.String(), "snapshots") {
+			log.Println("Source is a snapshot ID:", b.config.Source)
+			b.config.sourceType = sourceSnapshot
+		} else if _, err := parseVHDURI(b.config.Source); err == nil {
+			log.Println("Source is a VHD blob:", b.config.Source)
+			b.config.sourceType = sourceVHD
 		} else {
 			errs = packersdk.MultiErrorAppend(
-				errs, fmt.Errorf("source: %q is not a valid platform image specifier, nor is it a disk resource ID", b.config.Source))
+				errs, fmt.Errorf("source: %q is not a valid platform image specifier, nor is it a disk, managed image, snapshot, shared image version resource ID or VHD blob URL", b.config.Source))
 		}
 	}
 
@@ -380,27 +487,64 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		}
 	}
 
-	if !b.config.SkipCreateImage && !azcommon.StringsContains(md.Keys, "shared_image_destination") && b.config.ImageResourceID == "" {
-		errs = packersdk.MultiErrorAppend(errs, errors.New("image_resource_id or shared_image_destination is required"))
+	if azcommon.StringsContains(md.Keys, "vhd_destination") {
+		if e := b.config.VHDDestination.Validate("vhd_destination"); len(e) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, e...)
+		}
+	}
+
+	if !b.config.SkipCreateImage &&
+		!azcommon.StringsContains(md.Keys, "shared_image_destination") &&
+		!azcommon.StringsContains(md.Keys, "vhd_destination") &&
+		b.config.ImageResourceID == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("image_resource_id, shared_image_destination or vhd_destination is required"))
 	}
 
 	if err := checkHyperVGeneration(b.config.ImageHyperVGeneration); err != nil {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("image_hyperv_generation: %v", err))
 	}
 
+	if azcommon.StringsContains(md.Keys, "disk_layout") {
+		if !b.config.FromScratch {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("disk_layout can only be used with from_scratch"))
+		}
+		if b.config.ManualMountCommand != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("disk_layout cannot be used with manual_mount_command"))
+		}
+		if e := b.config.DiskLayout.Validate("disk_layout", b.config.OSDiskSizeGB); len(e) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, e...)
+		}
+	}
+
 	if b.config.LVMRootDevice != "" {
 		if err := validateLVMRootDevice(b.config.LVMRootDevice); err != nil {
 			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("lvm_root_device: %v", err))
 		}
 	}
 
+	if b.config.LUKSPassphrase != "" && b.config.LUKSKeyVaultSecretID != "" {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("only one of luks_passphrase and luks_key_vault_secret_id can be specified"))
+	}
+	if b.config.LUKSKeyVaultSecretID != "" {
+		if _, _, _, err := client.ParseSecretURI(b.config.LUKSKeyVaultSecretID); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("luks_key_vault_secret_id: %v", err))
+		}
+	}
+
 	if errs != nil {
 		return nil, warns, errs
 	}
 
 	packersdk.LogSecretFilter.Set(b.config.ClientConfig.ClientSecret, b.config.ClientConfig.ClientJWT)
+	if b.config.LUKSPassphrase != "" {
+		packersdk.LogSecretFilter.Set(b.config.LUKSPassphrase)
+	}
 
 	generatedDataKeys := []string{"SourceImageName"}
+	if b.config.FromScratch {
+		generatedDataKeys = append(generatedDataKeys, b.config.DiskLayout.generatedDataKeys()...)
+	}
 	return generatedDataKeys, warns, nil
 }
 
@@ -466,6 +610,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	if err != nil {
 		return nil, fmt.Errorf("error setting Azure client defaults: %v", err)
 	}
+	defer b.config.ClientConfig.Close()
 	azcli, err := client.New(b.config.ClientConfig, ui.Say)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Azure client: %v", err)
@@ -523,6 +668,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	if e, _ := b.config.SharedImageGalleryDestination.Validate(""); len(e) == 0 {
 		artifact.Resources = append(artifact.Resources, b.config.SharedImageGalleryDestination.ResourceID(info.SubscriptionID))
 	}
+	if vhd, ok := state.GetOk(stateBagKey_VHD); ok {
+		artifact.Resources = append(artifact.Resources, vhd.(string))
+	}
 	if b.config.SkipCleanup {
 		if d, ok := state.GetOk(stateBagKey_Diskset); ok {
 			for _, disk := range d.(Diskset) {
@@ -551,8 +699,20 @@ func buildsteps(
 		steps = append(steps, s...)
 	}
 
+	if config.CheckPermissions {
+		addSteps(
+			NewStepVerifyPermissions(
+				&StepVerifyPermissions{
+					Required:          requiredPermissions(config, info),
+					VHDStorageAccount: vhdStorageAccount(config),
+					LUKSKeyVault:      luksKeyVault(config),
+				}),
+		)
+	}
+
 	e, _ := config.SharedImageGalleryDestination.Validate("")
 	hasValidSharedImage := len(e) == 0
+	hasValidVHD := len(config.VHDDestination.Validate("")) == 0
 
 	if hasValidSharedImage {
 		// validate destination early
@@ -657,20 +817,136 @@ func buildsteps(
 				}),
 			)
 
+		case sourceSnapshot:
+			addSteps(
+				NewStepVerifySourceSnapshot(&StepVerifySourceSnapshot{
+					SourceSnapshotResourceID: config.Source,
+					Location:                 info.Location,
+					Zone:                     info.Zone,
+				}),
+				NewStepGetSourceImageName(&StepGetSourceImageName{
+					GeneratedData:            generatedData,
+					SourceSnapshotResourceID: config.Source,
+					Location:                 info.Location,
+				}),
+				NewStepCreateNewDiskset(&StepCreateNewDiskset{
+					OSDiskID:                 config.TemporaryOSDiskID,
+					OSDiskSizeGB:             config.OSDiskSizeGB,
+					OSDiskStorageAccountType: config.OSDiskStorageAccountType,
+					HyperVGeneration:         config.ImageHyperVGeneration,
+					SourceOSDiskResourceID:   config.Source,
+					Location:                 info.Location,
+					Zone:                     info.Zone,
+
+					SkipCleanup: config.SkipCleanup,
+				}),
+			)
+
+		case sourceManagedImage:
+			osDisk := &osDiskSource{}
+			addSteps(
+				NewStepVerifySourceImage(&StepVerifySourceImage{
+					SourceImageResourceID: config.Source,
+					Location:              info.Location,
+					Zone:                  info.Zone,
+					OSDisk:                osDisk,
+				}),
+				NewStepGetSourceImageName(&StepGetSourceImageName{
+					GeneratedData:                generatedData,
+					SourceManagedImageResourceID: config.Source,
+					Location:                     info.Location,
+				}),
+				NewStepCreateTemporaryGalleryImage(&StepCreateTemporaryGalleryImage{
+					GalleryID:    config.TemporaryGalleryID,
+					Location:     info.Location,
+					SourceOSDisk: osDisk,
+					SkipCleanup:  config.SkipCleanup,
+				}),
+				NewStepCreateNewDiskset(&StepCreateNewDiskset{
+					OSDiskID:                 config.TemporaryOSDiskID,
+					OSDiskSizeGB:             config.OSDiskSizeGB,
+					OSDiskStorageAccountType: config.OSDiskStorageAccountType,
+					HyperVGeneration:         config.ImageHyperVGeneration,
+					SourceOSDisk:             osDisk,
+					Location:                 info.Location,
+					Zone:                     info.Zone,
+
+					SkipCleanup: config.SkipCleanup,
+				}),
+			)
+
+		case sourceVHD:
+			osDisk := &osDiskSource{}
+			addSteps(
+				NewStepVerifySourceVHD(&StepVerifySourceVHD{
+					SourceVHDURI: config.Source,
+					Location:     info.Location,
+					Zone:         info.Zone,
+					OSDisk:       osDisk,
+				}),
+				NewStepGetSourceImageName(&StepGetSourceImageName{
+					GeneratedData: generatedData,
+					SourceVHDURI:  config.Source,
+					Location:      info.Location,
+				}),
+				NewStepCreateNewDiskset(&StepCreateNewDiskset{
+					OSDiskID:                 config.TemporaryOSDiskID,
+					OSDiskSizeGB:             config.OSDiskSizeGB,
+					OSDiskStorageAccountType: config.OSDiskStorageAccountType,
+					HyperVGeneration:         config.ImageHyperVGeneration,
+					SourceOSDisk:             osDisk,
+					Location:                 info.Location,
+					Zone:                     info.Zone,
+
+					SkipCleanup: config.SkipCleanup,
+				}),
+			)
+
 		default:
 			panic(fmt.Errorf("Unknown source type: %+q", config.sourceType))
 		}
 	}
 
+	hasDiskLayout := config.FromScratch && len(config.DiskLayout.Partitions) > 0
+	mountPartition := config.MountPartition
+
 	addSteps(
 		&StepAttachDisk{}, // uses os_disk_resource_id and sets 'device' in stateBag
-		// StepSetupLVM always runs: it auto-detects LVM on the attached disk.
-		// If LVM is found, it activates volume groups and replaces 'device' in
-		// the state bag with the root LV path. If not, it's a no-op.
-		&StepSetupLVM{
-			LVMRootDevice: config.LVMRootDevice,
-		},
 	)
+	if hasDiskLayout {
+		// StepApplyDiskLayout sets 'device' to the root LV itself when the
+		// root filesystem is on LVM, the partition is ignored then.
+		if root, ok := rootFileSystem(config.DiskLayout.fileSystems("")); ok {
+			mountPartition = root.Partition
+		}
+		addSteps(
+			NewStepApplyDiskLayout(&StepApplyDiskLayout{
+				Layout:        config.DiskLayout,
+				GeneratedData: generatedData,
+			}),
+		)
+	} else {
+		if config.LUKSPassphrase != "" || config.LUKSKeyVaultSecretID != "" {
+			// StepUnlockLUKS replaces 'device' with the opened LUKS volume,
+			// in which StepSetupLVM then looks for volume groups.
+			addSteps(
+				NewStepUnlockLUKS(&StepUnlockLUKS{
+					Passphrase:       config.LUKSPassphrase,
+					KeyVaultSecretID: config.LUKSKeyVaultSecretID,
+					ClientConfig:     &config.ClientConfig,
+					MountPartition:   config.MountPartition,
+				}),
+			)
+		}
+		addSteps(
+			// StepSetupLVM always runs: it auto-detects LVM on the attached disk.
+			// If LVM is found, it activates volume groups and replaces 'device' in
+			// the state bag with the root LV path. If not, it's a no-op.
+			&StepSetupLVM{
+				LVMRootDevice: config.LVMRootDevice,
+			},
+		)
+	}
 
 	addSteps(
 		&chroot.StepPreMountCommands{
@@ -679,9 +955,26 @@ func buildsteps(
 		&StepMountDevice{
 			MountOptions:   config.MountOptions,
 			Command:        config.ManualMountCommand,
-			MountPartition: config.MountPartition,
+			MountPartition: mountPartition,
 			MountPath:      config.MountPath,
 		},
+	)
+	if hasDiskLayout {
+		addSteps(NewStepMountDiskLayout(&StepMountDiskLayout{}))
+	}
+	if config.AutoMountFstab {
+		addSteps(NewStepMountFstab(&StepMountFstab{}))
+	}
+	if config.GrowRootPartition {
+		addSteps(NewStepGrowRootPartition(&StepGrowRootPartition{}))
+	}
+	addSteps(
+		NewStepSetupEmulation(&StepSetupEmulation{
+			QemuBinary: config.QemuBinary,
+		}),
+	)
+
+	addSteps(
 		&chroot.StepPostMountCommands{
 			Commands: config.PostMountCommands,
 		},
@@ -695,8 +988,14 @@ func buildsteps(
 		&StepPreUnmountCommands{
 			Commands: config.PreUnmountCommands,
 		},
-		// Custom StepEarlyCleanup that includes LVM deactivation between
-		// unmount and disk detach (the SDK's version lacks "lvm_cleanup").
+	)
+	if config.TrimFilesystems {
+		addSteps(NewStepTrimFilesystems(&StepTrimFilesystems{}))
+	}
+	addSteps(
+		// Custom StepEarlyCleanup that includes LVM deactivation and LUKS
+		// closing between unmount and disk detach (the SDK's version lacks
+		// "lvm_cleanup" and "luks_cleanup").
 		&StepEarlyCleanup{},
 	)
 
@@ -714,7 +1013,7 @@ func buildsteps(
 			}),
 		)
 	}
-	if hasValidSharedImage {
+	if hasValidSharedImage || hasValidVHD {
 		captureSteps = append(
 			captureSteps,
 			NewStepCreateSnapshotset(&StepCreateSnapshotset{
@@ -724,6 +1023,8 @@ func buildsteps(
 				SkipCleanup:              config.SkipCleanup,
 			}),
 		)
+	}
+	if hasValidSharedImage {
 		captureSteps = append(
 			captureSteps,
 			NewStepCreateSharedImageVersion(&StepCreateSharedImageVersion{
@@ -734,6 +1035,15 @@ func buildsteps(
 		)
 	}
 
+	if hasValidVHD {
+		captureSteps = append(
+			captureSteps,
+			NewStepCopyVHD(&StepCopyVHD{
+				Destination: config.VHDDestination,
+			}),
+		)
+	}
+
 	addSteps(config.CaptureSteps(say, captureSteps...)...)
 
 	return steps
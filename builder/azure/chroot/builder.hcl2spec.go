@@ -32,7 +32,12 @@ type FlatConfig struct {
 	OidcRequestToken                  *string                            `mapstructure:"oidc_request_token" cty:"oidc_request_token" hcl:"oidc_request_token"`
 	OidcRequestURL                    *string                            `mapstructure:"oidc_request_url" cty:"oidc_request_url" hcl:"oidc_request_url"`
 	UseAzureCLIAuth                   *bool                              `mapstructure:"use_azure_cli_auth" required:"false" cty:"use_azure_cli_auth" hcl:"use_azure_cli_auth"`
+	HTTPProxy                         *string                            `mapstructure:"http_proxy" required:"false" cty:"http_proxy" hcl:"http_proxy"`
+	NoProxy                           *string                            `mapstructure:"no_proxy" required:"false" cty:"no_proxy" hcl:"no_proxy"`
+	CABundleFile                      *string                            `mapstructure:"ca_bundle_file" required:"false" cty:"ca_bundle_file" hcl:"ca_bundle_file"`
+	TraceFile                         *string                            `mapstructure:"azure_trace_file" required:"false" cty:"azure_trace_file" hcl:"azure_trace_file"`
 	FromScratch                       *bool                              `mapstructure:"from_scratch" cty:"from_scratch" hcl:"from_scratch"`
+	DiskLayout                        *FlatDiskLayout                    `mapstructure:"disk_layout" required:"false" cty:"disk_layout" hcl:"disk_layout"`
 	Source                            *string                            `mapstructure:"source" required:"true" cty:"source" hcl:"source"`
 	CommandWrapper                    *string                            `mapstructure:"command_wrapper" cty:"command_wrapper" hcl:"command_wrapper"`
 	ManualMountCommand                *string                            `mapstructure:"manual_mount_command" required:"false" cty:"manual_mount_command" hcl:"manual_mount_command"`
@@ -40,8 +45,11 @@ type FlatConfig struct {
 	MountOptions                      []string                           `mapstructure:"mount_options" cty:"mount_options" hcl:"mount_options"`
 	MountPartition                    *string                            `mapstructure:"mount_partition" cty:"mount_partition" hcl:"mount_partition"`
 	MountPath                         *string                            `mapstructure:"mount_path" cty:"mount_path" hcl:"mount_path"`
+	AutoMountFstab                    *bool                              `mapstructure:"auto_mount_fstab" required:"false" cty:"auto_mount_fstab" hcl:"auto_mount_fstab"`
+	GrowRootPartition                 *bool                              `mapstructure:"grow_root_partition" required:"false" cty:"grow_root_partition" hcl:"grow_root_partition"`
 	PostMountCommands                 []string                           `mapstructure:"post_mount_commands" cty:"post_mount_commands" hcl:"post_mount_commands"`
 	ChrootMounts                      [][]string                         `mapstructure:"chroot_mounts" cty:"chroot_mounts" hcl:"chroot_mounts"`
+	QemuBinary                        *string                            `mapstructure:"qemu_binary" required:"false" cty:"qemu_binary" hcl:"qemu_binary"`
 	CopyFiles                         []string                           `mapstructure:"copy_files" cty:"copy_files" hcl:"copy_files"`
 	OSDiskSizeGB                      *int64                             `mapstructure:"os_disk_size_gb" cty:"os_disk_size_gb" hcl:"os_disk_size_gb"`
 	OSDiskStorageAccountType          *string                            `mapstructure:"os_disk_storage_account_type" cty:"os_disk_storage_account_type" hcl:"os_disk_storage_account_type"`
@@ -53,11 +61,17 @@ type FlatConfig struct {
 	TemporaryOSDiskSnapshotID         *string                            `mapstructure:"temporary_os_disk_snapshot_id" cty:"temporary_os_disk_snapshot_id" hcl:"temporary_os_disk_snapshot_id"`
 	TemporaryDataDiskIDPrefix         *string                            `mapstructure:"temporary_data_disk_id_prefix" cty:"temporary_data_disk_id_prefix" hcl:"temporary_data_disk_id_prefix"`
 	TemporaryDataDiskSnapshotIDPrefix *string                            `mapstructure:"temporary_data_disk_snapshot_id" cty:"temporary_data_disk_snapshot_id" hcl:"temporary_data_disk_snapshot_id"`
+	TemporaryGalleryID                *string                            `mapstructure:"temporary_gallery_id" cty:"temporary_gallery_id" hcl:"temporary_gallery_id"`
 	LVMRootDevice                     *string                            `mapstructure:"lvm_root_device" cty:"lvm_root_device" hcl:"lvm_root_device"`
+	LUKSPassphrase                    *string                            `mapstructure:"luks_passphrase" required:"false" cty:"luks_passphrase" hcl:"luks_passphrase"`
+	LUKSKeyVaultSecretID              *string                            `mapstructure:"luks_key_vault_secret_id" required:"false" cty:"luks_key_vault_secret_id" hcl:"luks_key_vault_secret_id"`
 	PreUnmountCommands                []string                           `mapstructure:"pre_unmount_commands" cty:"pre_unmount_commands" hcl:"pre_unmount_commands"`
+	TrimFilesystems                   *bool                              `mapstructure:"trim_filesystems" required:"false" cty:"trim_filesystems" hcl:"trim_filesystems"`
 	SkipCleanup                       *bool                              `mapstructure:"skip_cleanup" cty:"skip_cleanup" hcl:"skip_cleanup"`
 	ImageResourceID                   *string                            `mapstructure:"image_resource_id" cty:"image_resource_id" hcl:"image_resource_id"`
 	SharedImageGalleryDestination     *FlatSharedImageGalleryDestination `mapstructure:"shared_image_destination" cty:"shared_image_destination" hcl:"shared_image_destination"`
+	VHDDestination                    *FlatVHDDestination                `mapstructure:"vhd_destination" cty:"vhd_destination" hcl:"vhd_destination"`
+	CheckPermissions                  *bool                              `mapstructure:"check_permissions" required:"false" cty:"check_permissions" hcl:"check_permissions"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -94,7 +108,12 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"oidc_request_token":              &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
 		"oidc_request_url":                &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
 		"use_azure_cli_auth":              &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
+		"http_proxy":                      &hcldec.AttrSpec{Name: "http_proxy", Type: cty.String, Required: false},
+		"no_proxy":                        &hcldec.AttrSpec{Name: "no_proxy", Type: cty.String, Required: false},
+		"ca_bundle_file":                  &hcldec.AttrSpec{Name: "ca_bundle_file", Type: cty.String, Required: false},
+		"azure_trace_file":                &hcldec.AttrSpec{Name: "azure_trace_file", Type: cty.String, Required: false},
 		"from_scratch":                    &hcldec.AttrSpec{Name: "from_scratch", Type: cty.Bool, Required: false},
+		"disk_layout":                     &hcldec.BlockSpec{TypeName: "disk_layout", Nested: hcldec.ObjectSpec((*FlatDiskLayout)(nil).HCL2Spec())},
 		"source":                          &hcldec.AttrSpec{Name: "source", Type: cty.String, Required: false},
 		"command_wrapper":                 &hcldec.AttrSpec{Name: "command_wrapper", Type: cty.String, Required: false},
 		"manual_mount_command":            &hcldec.AttrSpec{Name: "manual_mount_command", Type: cty.String, Required: false},
@@ -102,8 +121,11 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"mount_options":                   &hcldec.AttrSpec{Name: "mount_options", Type: cty.List(cty.String), Required: false},
 		"mount_partition":                 &hcldec.AttrSpec{Name: "mount_partition", Type: cty.String, Required: false},
 		"mount_path":                      &hcldec.AttrSpec{Name: "mount_path", Type: cty.String, Required: false},
+		"auto_mount_fstab":                &hcldec.AttrSpec{Name: "auto_mount_fstab", Type: cty.Bool, Required: false},
+		"grow_root_partition":             &hcldec.AttrSpec{Name: "grow_root_partition", Type: cty.Bool, Required: false},
 		"post_mount_commands":             &hcldec.AttrSpec{Name: "post_mount_commands", Type: cty.List(cty.String), Required: false},
 		"chroot_mounts":                   &hcldec.AttrSpec{Name: "chroot_mounts", Type: cty.List(cty.List(cty.String)), Required: false},
+		"qemu_binary":                     &hcldec.AttrSpec{Name: "qemu_binary", Type: cty.String, Required: false},
 		"copy_files":                      &hcldec.AttrSpec{Name: "copy_files", Type: cty.List(cty.String), Required: false},
 		"os_disk_size_gb":                 &hcldec.AttrSpec{Name: "os_disk_size_gb", Type: cty.Number, Required: false},
 		"os_disk_storage_account_type":    &hcldec.AttrSpec{Name: "os_disk_storage_account_type", Type: cty.String, Required: false},
@@ -115,11 +137,17 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"temporary_os_disk_snapshot_id":   &hcldec.AttrSpec{Name: "temporary_os_disk_snapshot_id", Type: cty.String, Required: false},
 		"temporary_data_disk_id_prefix":   &hcldec.AttrSpec{Name: "temporary_data_disk_id_prefix", Type: cty.String, Required: false},
 		"temporary_data_disk_snapshot_id": &hcldec.AttrSpec{Name: "temporary_data_disk_snapshot_id", Type: cty.String, Required: false},
+		"temporary_gallery_id":            &hcldec.AttrSpec{Name: "temporary_gallery_id", Type: cty.String, Required: false},
 		"lvm_root_device":                 &hcldec.AttrSpec{Name: "lvm_root_device", Type: cty.String, Required: false},
+		"luks_passphrase":                 &hcldec.AttrSpec{Name: "luks_passphrase", Type: cty.String, Required: false},
+		"luks_key_vault_secret_id":        &hcldec.AttrSpec{Name: "luks_key_vault_secret_id", Type: cty.String, Required: false},
 		"pre_unmount_commands":            &hcldec.AttrSpec{Name: "pre_unmount_commands", Type: cty.List(cty.String), Required: false},
+		"trim_filesystems":                &hcldec.AttrSpec{Name: "trim_filesystems", Type: cty.Bool, Required: false},
 		"skip_cleanup":                    &hcldec.AttrSpec{Name: "skip_cleanup", Type: cty.Bool, Required: false},
 		"image_resource_id":               &hcldec.AttrSpec{Name: "image_resource_id", Type: cty.String, Required: false},
 		"shared_image_destination":        &hcldec.BlockSpec{TypeName: "shared_image_destination", Nested: hcldec.ObjectSpec((*FlatSharedImageGalleryDestination)(nil).HCL2Spec())},
+		"vhd_destination":                 &hcldec.BlockSpec{TypeName: "vhd_destination", Nested: hcldec.ObjectSpec((*FlatVHDDestination)(nil).HCL2Spec())},
+		"check_permissions":               &hcldec.AttrSpec{Name: "check_permissions", Type: cty.Bool, Required: false},
 	}
 	return s
 }
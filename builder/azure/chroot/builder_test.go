@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
 
 	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/chroot"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
 )
@@ -99,6 +100,19 @@ func TestBuilder_Prepare(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "managed image to managed image, validate temp gallery id expansion",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/images/sourceimage",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+			},
+			validate: func(c Config) {
+				prefix := "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/galleries/PackerTemp_gallery_"
+				if !strings.HasPrefix(c.TemporaryGalleryID, prefix) {
+					t.Errorf("Expected TemporaryGalleryID to start with %q, but got %q", prefix, c.TemporaryGalleryID)
+				}
+			},
+		},
 		{
 			name: "disk to both managed image and shared image",
 			config: config{
@@ -125,6 +139,29 @@ func TestBuilder_Prepare(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "disk to VHD",
+			config: config{
+				"source": "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"vhd_destination": config{
+					"storage_account": "mystorageaccount",
+					"container_name":  "vhds",
+					"blob_name":       "image.vhd",
+				},
+			},
+		},
+		{
+			name: "disk to VHD with invalid storage account",
+			config: config{
+				"source": "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"vhd_destination": config{
+					"storage_account": "My_Storage_Account",
+					"container_name":  "vhds",
+					"blob_name":       "image.vhd",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "from shared image",
 			config: config{
@@ -204,6 +241,139 @@ func TestBuilder_Prepare(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "luks_passphrase accepted",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"luks_passphrase":   "s3cr3t",
+			},
+		},
+		{
+			name: "luks_key_vault_secret_id accepted",
+			config: config{
+				"source":                   "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"image_resource_id":        "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"luks_key_vault_secret_id": "https://myvault.vault.azure.net/secrets/luks-key/0123456789abcdef",
+			},
+		},
+		{
+			name: "invalid luks_key_vault_secret_id rejected",
+			config: config{
+				"source":                   "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"image_resource_id":        "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"luks_key_vault_secret_id": "https://myvault.vault.azure.net/keys/luks-key",
+			},
+			wantErr: true,
+		},
+		{
+			name: "luks_passphrase with luks_key_vault_secret_id rejected",
+			config: config{
+				"source":                   "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"image_resource_id":        "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"luks_passphrase":          "s3cr3t",
+				"luks_key_vault_secret_id": "https://myvault.vault.azure.net/secrets/luks-key",
+			},
+			wantErr: true,
+		},
+		{
+			name: "from_scratch with grow_root_partition rejected",
+			config: config{
+				"from_scratch":        true,
+				"os_disk_size_gb":     30,
+				"pre_mount_commands":  []string{"sgdisk ..."},
+				"image_resource_id":   "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"grow_root_partition": true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "from_scratch with luks_passphrase rejected",
+			config: config{
+				"from_scratch":       true,
+				"os_disk_size_gb":    30,
+				"pre_mount_commands": []string{"sgdisk ..."},
+				"image_resource_id":  "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"luks_passphrase":    "s3cr3t",
+			},
+			wantErr: true,
+		},
+		{
+			name: "from_scratch with disk_layout",
+			config: config{
+				"from_scratch":      true,
+				"os_disk_size_gb":   30,
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"disk_layout": config{
+					"partitions": []config{
+						{"name": "esp", "type": "efi", "size_mb": 512, "mount_point": "/boot/efi"},
+						{"name": "root", "filesystem": "ext4", "mount_point": "/"},
+					},
+				},
+			},
+			validate: func(c Config) {
+				if len(c.DiskLayout.Partitions) != 2 {
+					t.Errorf("Expected 2 partitions, got %+v", c.DiskLayout)
+				}
+			},
+		},
+		{
+			name: "disk_layout without from_scratch rejected",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/disks/diskname",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+				"disk_layout": config{
+					"partitions": []config{
+						{"name": "root", "filesystem": "ext4", "mount_point": "/"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "from managed image",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/images/sourceimage",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+			},
+			validate: func(c Config) {
+				if c.sourceType != sourceManagedImage {
+					t.Errorf("Expected source type %q, got %q", sourceManagedImage, c.sourceType)
+				}
+			},
+		},
+		{
+			name: "from snapshot",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Compute/snapshots/sourcesnapshot",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+			},
+			validate: func(c Config) {
+				if c.sourceType != sourceSnapshot {
+					t.Errorf("Expected source type %q, got %q", sourceSnapshot, c.sourceType)
+				}
+			},
+		},
+		{
+			name: "from VHD",
+			config: config{
+				"source":            "https://account.blob.core.windows.net/vhds/source.vhd",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+			},
+			validate: func(c Config) {
+				if c.sourceType != sourceVHD {
+					t.Errorf("Expected source type %q, got %q", sourceVHD, c.sourceType)
+				}
+			},
+		},
+		{
+			name: "err: unsupported source resource",
+			config: config{
+				"source":            "/subscriptions/789/resourceGroups/testrg/providers/Microsoft.Storage/storageAccounts/account",
+				"image_resource_id": "/subscriptions/789/resourceGroups/otherrgname/providers/Microsoft.Compute/images/MyDebianOSImage-{{timestamp}}",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -314,6 +484,206 @@ func Test_buildsteps(t *testing.T) {
 				}
 				t.Error("did not find a StepVerifySourceDisk")
 			}},
+		{
+			name:   "Source snapshot adds StepVerifySourceSnapshot and copies the snapshot",
+			config: Config{Source: "snapshotresourceid", sourceType: sourceSnapshot},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var verified bool
+				for _, s := range steps {
+					if s, ok := s.(*StepVerifySourceSnapshot); ok && s.SourceSnapshotResourceID == "snapshotresourceid" {
+						verified = true
+					}
+					if s, ok := s.(*StepCreateNewDiskset); ok {
+						if verified && s.SourceOSDiskResourceID == "snapshotresourceid" {
+							return
+						}
+						t.Errorf("found misconfigured StepCreateNewDisk: %+v", s)
+					}
+				}
+				t.Error("did not find a StepVerifySourceSnapshot before StepCreateNewDisk")
+			}},
+		{
+			name:   "Source managed image shares the OS disk source with StepCreateNewDiskset",
+			config: Config{Source: "imageresourceid", sourceType: sourceManagedImage},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var osDisk *osDiskSource
+				for _, s := range steps {
+					if s, ok := s.(*StepVerifySourceImage); ok && s.SourceImageResourceID == "imageresourceid" {
+						osDisk = s.OSDisk
+					}
+					if s, ok := s.(*StepCreateNewDiskset); ok {
+						if osDisk != nil && s.SourceOSDisk == osDisk {
+							return
+						}
+						t.Errorf("found misconfigured StepCreateNewDisk: %+v", s)
+					}
+				}
+				t.Error("did not find a StepVerifySourceImage before StepCreateNewDisk")
+			}},
+		{
+			name:   "Source VHD shares the OS disk source with StepCreateNewDiskset",
+			config: Config{Source: "https://account.blob.core.windows.net/vhds/source.vhd", sourceType: sourceVHD},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var osDisk *osDiskSource
+				for _, s := range steps {
+					if s, ok := s.(*StepVerifySourceVHD); ok && s.Location == info.Location {
+						osDisk = s.OSDisk
+					}
+					if s, ok := s.(*StepCreateNewDiskset); ok {
+						if osDisk != nil && s.SourceOSDisk == osDisk {
+							return
+						}
+						t.Errorf("found misconfigured StepCreateNewDisk: %+v", s)
+					}
+				}
+				t.Error("did not find a StepVerifySourceVHD before StepCreateNewDisk")
+			}},
+		{
+			name: "VHD destination snapshots the OS disk before StepCopyVHD",
+			config: Config{
+				Source:     "diskresourceid",
+				sourceType: sourceDisk,
+				VHDDestination: VHDDestination{
+					StorageAccount: "mystorageaccount",
+					ContainerName:  "vhds",
+					BlobName:       "image.vhd",
+				},
+			},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var snapshotted bool
+				for _, s := range steps {
+					if _, ok := s.(*StepCreateSnapshotset); ok {
+						snapshotted = true
+					}
+					if s, ok := s.(*StepCopyVHD); ok {
+						if snapshotted && s.Destination.BlobName == "image.vhd" {
+							return
+						}
+						t.Errorf("found misconfigured StepCopyVHD: %+v", s)
+					}
+				}
+				t.Error("did not find a StepCreateSnapshotset before StepCopyVHD")
+			}},
+		{
+			name: "Disk layout replaces StepSetupLVM and mounts the root partition",
+			config: Config{FromScratch: true, DiskLayout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "esp", Type: "efi", SizeMB: 512, MountPoint: "/boot/efi"},
+				{Name: "root", FileSystem: "ext4", MountPoint: "/"},
+			}}},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var applied, mounted bool
+				for _, s := range steps {
+					switch s := s.(type) {
+					case *StepSetupLVM:
+						t.Error("found a StepSetupLVM")
+					case *StepApplyDiskLayout:
+						applied = true
+					case *StepMountDevice:
+						if s.MountPartition != "2" {
+							t.Errorf("found misconfigured StepMountDevice: %+v", s)
+						}
+					case *StepMountDiskLayout:
+						mounted = applied
+					}
+				}
+				if !mounted {
+					t.Error("did not find a StepApplyDiskLayout and a StepMountDiskLayout")
+				}
+			}},
+		{
+			name:   "auto_mount_fstab adds StepMountFstab after StepMountDevice",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk, AutoMountFstab: true},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var mounted bool
+				for _, s := range steps {
+					if _, ok := s.(*StepMountDevice); ok {
+						mounted = true
+					}
+					if _, ok := s.(*StepMountFstab); ok && mounted {
+						return
+					}
+				}
+				t.Error("did not find a StepMountFstab after StepMountDevice")
+			}},
+		{
+			name:   "luks_passphrase adds StepUnlockLUKS before StepSetupLVM",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk, LUKSPassphrase: "s3cr3t"},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var unlocked bool
+				for _, s := range steps {
+					if _, ok := s.(*StepUnlockLUKS); ok {
+						unlocked = true
+					}
+					if _, ok := s.(*StepSetupLVM); ok && unlocked {
+						return
+					}
+				}
+				t.Error("did not find a StepUnlockLUKS before StepSetupLVM")
+			}},
+		{
+			name:   "trim_filesystems adds StepTrimFilesystems before StepEarlyCleanup",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk, TrimFilesystems: true},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var trimmed bool
+				for _, s := range steps {
+					if _, ok := s.(*StepPreUnmountCommands); ok && trimmed {
+						t.Error("StepTrimFilesystems runs before StepPreUnmountCommands")
+					}
+					if _, ok := s.(*StepTrimFilesystems); ok {
+						trimmed = true
+					}
+					if _, ok := s.(*StepEarlyCleanup); ok && trimmed {
+						return
+					}
+				}
+				t.Error("did not find a StepTrimFilesystems before StepEarlyCleanup")
+			}},
+		{
+			name:   "grow_root_partition adds StepGrowRootPartition after StepMountDevice",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk, GrowRootPartition: true},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var mounted bool
+				for _, s := range steps {
+					if _, ok := s.(*StepMountDevice); ok {
+						mounted = true
+					}
+					if _, ok := s.(*StepGrowRootPartition); ok && mounted {
+						return
+					}
+				}
+				t.Error("did not find a StepGrowRootPartition after StepMountDevice")
+			}},
+		{
+			name:   "StepSetupEmulation runs between StepMountDevice and StepChrootProvision",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk, QemuBinary: "/opt/qemu/qemu-aarch64"},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				var mounted, emulated bool
+				for _, s := range steps {
+					if _, ok := s.(*StepMountDevice); ok {
+						mounted = true
+					}
+					if e, ok := s.(*StepSetupEmulation); ok && mounted {
+						if e.QemuBinary != "/opt/qemu/qemu-aarch64" {
+							t.Errorf("QemuBinary = %q", e.QemuBinary)
+						}
+						emulated = true
+					}
+					if _, ok := s.(*chroot.StepChrootProvision); ok && emulated {
+						return
+					}
+				}
+				t.Error("did not find a StepSetupEmulation between StepMountDevice and StepChrootProvision")
+			}},
+		{
+			name:   "no LUKS key, no StepUnlockLUKS",
+			config: Config{Source: "diskresourceid", sourceType: sourceDisk},
+			verify: func(steps []multistep.Step, _ *testing.T) {
+				for _, s := range steps {
+					if _, ok := s.(*StepUnlockLUKS); ok {
+						t.Error("found a StepUnlockLUKS")
+					}
+				}
+			}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
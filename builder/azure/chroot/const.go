@@ -4,6 +4,16 @@
 package chroot
 
 const (
-	stateBagKey_Diskset     = "diskset"
-	stateBagKey_Snapshotset = "snapshotset"
+	stateBagKey_Diskset          = "diskset"
+	stateBagKey_Snapshotset      = "snapshotset"
+	stateBagKey_VHD              = "vhd"
+	stateBagKey_DiskLayoutMounts = "disk_layout_mounts"
+	// stateBagKey_DiskDevice is the device of the attached disk, "device" is
+	// replaced with the root logical volume when the disk uses LVM.
+	stateBagKey_DiskDevice = "disk_device"
+	// stateBagKey_ImageArchitecture is the architecture of the image, x64 or
+	// Arm64, stateBagKey_SharedImageArchitecture the one of the destination
+	// gallery image definition.
+	stateBagKey_ImageArchitecture       = "image_architecture"
+	stateBagKey_SharedImageArchitecture = "shared_image_architecture"
 )
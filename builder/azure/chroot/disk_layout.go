@@ -0,0 +1,336 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DiskLayout,DiskPartition,DiskLogicalVolume
+
+package chroot
+
+import (
+	"fmt"
+	posixpath "path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DiskLayout describes the GPT partition table, the LVM volumes and the
+// filesystems that are created on the empty OS disk of a `from_scratch` build.
+type DiskLayout struct {
+	// The partitions, in the order they are created on the disk. Exactly one
+	// partition or logical volume must be mounted at `/`.
+	Partitions []DiskPartition `mapstructure:"partitions" required:"true"`
+}
+
+// DiskPartition is a partition of a DiskLayout.
+type DiskPartition struct {
+	// The name of the partition, which is also its GPT partition name. Only
+	// letters, digits and underscores are allowed.
+	Name string `mapstructure:"name" required:"true"`
+	// The type of the partition, one of `efi` (EFI system partition), `bios_boot`
+	// (BIOS boot partition for GRUB on Generation 1 VMs), `linux`, `swap` or `lvm`
+	// (LVM physical volume). Defaults to `linux`.
+	Type string `mapstructure:"type" required:"false"`
+	// The size of the partition in MiB. Zero, the default, uses the rest of the
+	// disk and is only allowed for the last partition.
+	SizeMB int64 `mapstructure:"size_mb" required:"false"`
+	// The filesystem to create, one of `ext4`, `xfs`, `btrfs`, `vfat` or `swap`.
+	// Required for `linux` partitions, defaults to `vfat` for `efi` partitions
+	// and `swap` for `swap` partitions. Not allowed for `bios_boot` and `lvm`
+	// partitions.
+	FileSystem string `mapstructure:"filesystem" required:"false"`
+	// The label of the filesystem.
+	Label string `mapstructure:"label" required:"false"`
+	// The path in the chroot to mount the filesystem at, for example `/boot/efi`.
+	MountPoint string `mapstructure:"mount_point" required:"false"`
+	// The name of the volume group to create on an `lvm` partition.
+	VolumeGroup string `mapstructure:"volume_group" required:"false"`
+	// The logical volumes to create in the volume group of an `lvm` partition.
+	LogicalVolumes []DiskLogicalVolume `mapstructure:"logical_volumes" required:"false"`
+}
+
+// DiskLogicalVolume is a logical volume in the volume group of an `lvm`
+// DiskPartition.
+type DiskLogicalVolume struct {
+	// The name of the logical volume. Only letters, digits and underscores are
+	// allowed.
+	Name string `mapstructure:"name" required:"true"`
+	// The size of the logical volume in MiB. Zero, the default, uses the free
+	// space of the volume group and is only allowed for the last logical volume.
+	SizeMB int64 `mapstructure:"size_mb" required:"false"`
+	// The filesystem to create, one of `ext4`, `xfs`, `btrfs` or `swap`.
+	FileSystem string `mapstructure:"filesystem" required:"true"`
+	// The label of the filesystem.
+	Label string `mapstructure:"label" required:"false"`
+	// The path in the chroot to mount the filesystem at, for example `/`.
+	MountPoint string `mapstructure:"mount_point" required:"false"`
+}
+
+const (
+	partitionTypeEFI      = "efi"
+	partitionTypeBIOSBoot = "bios_boot"
+	partitionTypeLinux    = "linux"
+	partitionTypeSwap     = "swap"
+	partitionTypeLVM      = "lvm"
+)
+
+// gptTypeCodes are the sgdisk type codes of the partition types.
+var gptTypeCodes = map[string]string{
+	partitionTypeEFI:      "EF00",
+	partitionTypeBIOSBoot: "EF02",
+	partitionTypeLinux:    "8300",
+	partitionTypeSwap:     "8200",
+	partitionTypeLVM:      "8E00",
+}
+
+var (
+	diskLayoutNameRegex  = regexp.MustCompile(`^[A-Za-z0-9_]{1,36}$`)
+	diskLayoutLabelRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,16}$`)
+)
+
+// gptOverheadMB is the space taken by the GPT headers and the alignment of the
+// first partition.
+const gptOverheadMB = 2
+
+// fileSystemType returns the filesystem of the partition, taking the defaults
+// of the partition type into account.
+func (p DiskPartition) fileSystemType() string {
+	if p.FileSystem != "" {
+		return p.FileSystem
+	}
+	switch p.partitionType() {
+	case partitionTypeEFI:
+		return "vfat"
+	case partitionTypeSwap:
+		return "swap"
+	}
+	return ""
+}
+
+func (p DiskPartition) partitionType() string {
+	if p.Type == "" {
+		return partitionTypeLinux
+	}
+	return p.Type
+}
+
+// Validate validates the layout against the size of the disk (without
+// checking anything on the disk).
+func (dl DiskLayout) Validate(prefix string, diskSizeGB int64) (errs []error) {
+	if len(dl.Partitions) == 0 {
+		return []error{fmt.Errorf("%s.partitions: at least one partition is required", prefix)}
+	}
+
+	names := map[string]bool{}
+	mountPoints := map[string]bool{}
+	checkName := func(p, name string) {
+		if !diskLayoutNameRegex.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%s.name: %q is not a valid name, use up to 36 letters, digits and underscores", p, name))
+		} else if names[name] {
+			errs = append(errs, fmt.Errorf("%s.name: %q is used more than once", p, name))
+		}
+		names[name] = true
+	}
+	checkFileSystem := func(p, fs, label, mountPoint string, allowed ...string) {
+		if !slices.Contains(allowed, fs) {
+			errs = append(errs, fmt.Errorf("%s.filesystem: %q is not one of %s", p, fs, strings.Join(allowed, ", ")))
+		}
+		if label != "" && (!diskLayoutLabelRegex.MatchString(label) || (fs == "vfat" && len(label) > 11)) {
+			errs = append(errs, fmt.Errorf("%s.label: %q is not a valid %s label", p, label, fs))
+		}
+		if mountPoint == "" {
+			return
+		}
+		if fs == "swap" {
+			errs = append(errs, fmt.Errorf("%s.mount_point: swap cannot be mounted", p))
+		} else if !posixpath.IsAbs(mountPoint) || posixpath.Clean(mountPoint) != mountPoint {
+			errs = append(errs, fmt.Errorf("%s.mount_point: %q is not a clean absolute path", p, mountPoint))
+		} else if mountPoints[mountPoint] {
+			errs = append(errs, fmt.Errorf("%s.mount_point: %q is used more than once", p, mountPoint))
+		}
+		mountPoints[mountPoint] = true
+	}
+
+	var totalMB int64
+	for i, part := range dl.Partitions {
+		p := fmt.Sprintf("%s.partitions[%d]", prefix, i)
+		checkName(p, part.Name)
+		if part.SizeMB < 0 {
+			errs = append(errs, fmt.Errorf("%s.size_mb: cannot be negative", p))
+		} else if part.SizeMB == 0 && i != len(dl.Partitions)-1 {
+			errs = append(errs, fmt.Errorf("%s.size_mb: only the last partition can use the rest of the disk", p))
+		}
+		totalMB += part.SizeMB
+
+		if part.partitionType() != partitionTypeLVM && (part.VolumeGroup != "" || len(part.LogicalVolumes) > 0) {
+			errs = append(errs, fmt.Errorf("%s: volume_group and logical_volumes are only allowed for lvm partitions", p))
+		}
+
+		switch part.partitionType() {
+		case partitionTypeEFI:
+			checkFileSystem(p, part.fileSystemType(), part.Label, part.MountPoint, "vfat")
+		case partitionTypeLinux:
+			checkFileSystem(p, part.fileSystemType(), part.Label, part.MountPoint, "ext4", "xfs", "btrfs", "vfat")
+		case partitionTypeSwap:
+			checkFileSystem(p, part.fileSystemType(), part.Label, part.MountPoint, "swap")
+		case partitionTypeBIOSBoot, partitionTypeLVM:
+			if part.FileSystem != "" || part.Label != "" || part.MountPoint != "" {
+				errs = append(errs, fmt.Errorf("%s: filesystem, label and mount_point are not allowed for %s partitions", p, part.partitionType()))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s.type: %q is not one of efi, bios_boot, linux, swap, lvm", p, part.Type))
+		}
+
+		if part.partitionType() != partitionTypeLVM {
+			continue
+		}
+		if !diskLayoutNameRegex.MatchString(part.VolumeGroup) {
+			errs = append(errs, fmt.Errorf("%s.volume_group: %q is not a valid volume group name", p, part.VolumeGroup))
+		}
+		if len(part.LogicalVolumes) == 0 {
+			errs = append(errs, fmt.Errorf("%s.logical_volumes: at least one logical volume is required", p))
+		}
+		var lvTotalMB int64
+		for j, lv := range part.LogicalVolumes {
+			lp := fmt.Sprintf("%s.logical_volumes[%d]", p, j)
+			checkName(lp, lv.Name)
+			if lv.SizeMB < 0 {
+				errs = append(errs, fmt.Errorf("%s.size_mb: cannot be negative", lp))
+			} else if lv.SizeMB == 0 && j != len(part.LogicalVolumes)-1 {
+				errs = append(errs, fmt.Errorf("%s.size_mb: only the last logical volume can use the rest of the volume group", lp))
+			}
+			lvTotalMB += lv.SizeMB
+			checkFileSystem(lp, lv.FileSystem, lv.Label, lv.MountPoint, "ext4", "xfs", "btrfs", "swap")
+		}
+		if part.SizeMB > 0 && lvTotalMB > part.SizeMB {
+			errs = append(errs, fmt.Errorf("%s.logical_volumes: the logical volumes (%d MiB) do not fit in the partition (%d MiB)", p, lvTotalMB, part.SizeMB))
+		}
+	}
+
+	if !mountPoints["/"] {
+		errs = append(errs, fmt.Errorf("%s: one partition or logical volume must be mounted at /", prefix))
+	}
+	if diskSizeGB > 0 && totalMB+gptOverheadMB > diskSizeGB*1024 {
+		errs = append(errs, fmt.Errorf("%s.partitions: the partitions (%d MiB) do not fit on the disk (%d GiB)", prefix, totalMB, diskSizeGB))
+	}
+	return
+}
+
+// generatedDataKeys returns the names of the generated data the layout
+// records: UUID_<name> for every filesystem and PARTUUID_<name> for every
+// partition.
+func (dl DiskLayout) generatedDataKeys() []string {
+	var keys []string
+	for _, part := range dl.Partitions {
+		keys = append(keys, "PARTUUID_"+part.Name)
+		if part.fileSystemType() != "" {
+			keys = append(keys, "UUID_"+part.Name)
+		}
+		for _, lv := range part.LogicalVolumes {
+			keys = append(keys, "UUID_"+lv.Name)
+		}
+	}
+	return keys
+}
+
+// sgdiskArgs returns the arguments of the sgdisk command creating the
+// partitions of the layout on device.
+func (dl DiskLayout) sgdiskArgs(device string) []string {
+	args := []string{"--zap-all", "--clear"}
+	for i, part := range dl.Partitions {
+		n := i + 1
+		end := "0"
+		if part.SizeMB > 0 {
+			end = fmt.Sprintf("+%dM", part.SizeMB)
+		}
+		args = append(args,
+			fmt.Sprintf("--new=%d:0:%s", n, end),
+			fmt.Sprintf("--typecode=%d:%s", n, gptTypeCodes[part.partitionType()]),
+			fmt.Sprintf("--change-name=%d:%s", n, part.Name),
+		)
+	}
+	return append(args, device)
+}
+
+// fileSystems returns the filesystems of the layout, with the devices they
+// are created on for a disk attached at device.
+func (dl DiskLayout) fileSystems(device string) []diskLayoutFileSystem {
+	var fss []diskLayoutFileSystem
+	for i, part := range dl.Partitions {
+		if fs := part.fileSystemType(); fs != "" {
+			fss = append(fss, diskLayoutFileSystem{
+				Name:       part.Name,
+				Device:     partitionDevice(device, fmt.Sprint(i+1)),
+				FileSystem: fs,
+				Label:      part.Label,
+				MountPoint: part.MountPoint,
+				Partition:  fmt.Sprint(i + 1),
+			})
+		}
+		for _, lv := range part.LogicalVolumes {
+			fss = append(fss, diskLayoutFileSystem{
+				Name:       lv.Name,
+				Device:     logicalVolumeDevice(part.VolumeGroup, lv.Name),
+				FileSystem: lv.FileSystem,
+				Label:      lv.Label,
+				MountPoint: lv.MountPoint,
+			})
+		}
+	}
+	return fss
+}
+
+// rootFileSystem returns the filesystem mounted at /.
+func rootFileSystem(fss []diskLayoutFileSystem) (diskLayoutFileSystem, bool) {
+	for _, fs := range fss {
+		if fs.MountPoint == "/" {
+			return fs, true
+		}
+	}
+	return diskLayoutFileSystem{}, false
+}
+
+// mountOrder returns the filesystems with a mount point other than /, parents
+// before their children, so that /boot is mounted before /boot/efi.
+func mountOrder(fss []diskLayoutFileSystem) []diskLayoutFileSystem {
+	var mounts []diskLayoutFileSystem
+	for _, fs := range fss {
+		if fs.MountPoint != "" && fs.MountPoint != "/" {
+			mounts = append(mounts, fs)
+		}
+	}
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return strings.Count(mounts[i].MountPoint, "/") < strings.Count(mounts[j].MountPoint, "/")
+	})
+	return mounts
+}
+
+// mkfsCommand returns the command creating the filesystem fs.
+func mkfsCommand(fs diskLayoutFileSystem) string {
+	var cmd string
+	labelFlag := "-L"
+	switch fs.FileSystem {
+	case "ext4":
+		cmd = "mkfs.ext4 -F"
+	case "xfs":
+		cmd = "mkfs.xfs -f"
+	case "btrfs":
+		cmd = "mkfs.btrfs -f"
+	case "vfat":
+		cmd = "mkfs.vfat -F 32"
+		labelFlag = "-n"
+	case "swap":
+		cmd = "mkswap"
+	}
+	if fs.Label != "" {
+		cmd += fmt.Sprintf(" %s %s", labelFlag, fs.Label)
+	}
+	return fmt.Sprintf("%s %s", cmd, fs.Device)
+}
+
+// logicalVolumeDevice returns the device of the logical volume lv in the
+// volume group vg.
+func logicalVolumeDevice(vg, lv string) string {
+	return fmt.Sprintf("/dev/%s/%s", vg, lv)
+}
@@ -0,0 +1,99 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package chroot
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatDiskLayout is an auto-generated flat version of DiskLayout.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDiskLayout struct {
+	Partitions []FlatDiskPartition `mapstructure:"partitions" required:"true" cty:"partitions" hcl:"partitions"`
+}
+
+// FlatMapstructure returns a new FlatDiskLayout.
+// FlatDiskLayout is an auto-generated flat version of DiskLayout.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DiskLayout) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDiskLayout)
+}
+
+// HCL2Spec returns the hcl spec of a DiskLayout.
+// This spec is used by HCL to read the fields of DiskLayout.
+// The decoded values from this spec will then be applied to a FlatDiskLayout.
+func (*FlatDiskLayout) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"partitions": &hcldec.BlockListSpec{TypeName: "partitions", Nested: hcldec.ObjectSpec((*FlatDiskPartition)(nil).HCL2Spec())},
+	}
+	return s
+}
+
+// FlatDiskLogicalVolume is an auto-generated flat version of DiskLogicalVolume.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDiskLogicalVolume struct {
+	Name       *string `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	SizeMB     *int64  `mapstructure:"size_mb" required:"false" cty:"size_mb" hcl:"size_mb"`
+	FileSystem *string `mapstructure:"filesystem" required:"true" cty:"filesystem" hcl:"filesystem"`
+	Label      *string `mapstructure:"label" required:"false" cty:"label" hcl:"label"`
+	MountPoint *string `mapstructure:"mount_point" required:"false" cty:"mount_point" hcl:"mount_point"`
+}
+
+// FlatMapstructure returns a new FlatDiskLogicalVolume.
+// FlatDiskLogicalVolume is an auto-generated flat version of DiskLogicalVolume.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DiskLogicalVolume) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDiskLogicalVolume)
+}
+
+// HCL2Spec returns the hcl spec of a DiskLogicalVolume.
+// This spec is used by HCL to read the fields of DiskLogicalVolume.
+// The decoded values from this spec will then be applied to a FlatDiskLogicalVolume.
+func (*FlatDiskLogicalVolume) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":        &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"size_mb":     &hcldec.AttrSpec{Name: "size_mb", Type: cty.Number, Required: false},
+		"filesystem":  &hcldec.AttrSpec{Name: "filesystem", Type: cty.String, Required: false},
+		"label":       &hcldec.AttrSpec{Name: "label", Type: cty.String, Required: false},
+		"mount_point": &hcldec.AttrSpec{Name: "mount_point", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatDiskPartition is an auto-generated flat version of DiskPartition.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDiskPartition struct {
+	Name           *string                 `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	Type           *string                 `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	SizeMB         *int64                  `mapstructure:"size_mb" required:"false" cty:"size_mb" hcl:"size_mb"`
+	FileSystem     *string                 `mapstructure:"filesystem" required:"false" cty:"filesystem" hcl:"filesystem"`
+	Label          *string                 `mapstructure:"label" required:"false" cty:"label" hcl:"label"`
+	MountPoint     *string                 `mapstructure:"mount_point" required:"false" cty:"mount_point" hcl:"mount_point"`
+	VolumeGroup    *string                 `mapstructure:"volume_group" required:"false" cty:"volume_group" hcl:"volume_group"`
+	LogicalVolumes []FlatDiskLogicalVolume `mapstructure:"logical_volumes" required:"false" cty:"logical_volumes" hcl:"logical_volumes"`
+}
+
+// FlatMapstructure returns a new FlatDiskPartition.
+// FlatDiskPartition is an auto-generated flat version of DiskPartition.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DiskPartition) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDiskPartition)
+}
+
+// HCL2Spec returns the hcl spec of a DiskPartition.
+// This spec is used by HCL to read the fields of DiskPartition.
+// The decoded values from this spec will then be applied to a FlatDiskPartition.
+func (*FlatDiskPartition) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":            &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"type":            &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"size_mb":         &hcldec.AttrSpec{Name: "size_mb", Type: cty.Number, Required: false},
+		"filesystem":      &hcldec.AttrSpec{Name: "filesystem", Type: cty.String, Required: false},
+		"label":           &hcldec.AttrSpec{Name: "label", Type: cty.String, Required: false},
+		"mount_point":     &hcldec.AttrSpec{Name: "mount_point", Type: cty.String, Required: false},
+		"volume_group":    &hcldec.AttrSpec{Name: "volume_group", Type: cty.String, Required: false},
+		"logical_volumes": &hcldec.BlockListSpec{TypeName: "logical_volumes", Nested: hcldec.ObjectSpec((*FlatDiskLogicalVolume)(nil).HCL2Spec())},
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testDiskLayout is a UEFI layout with a separate /boot and the root and
+// /var filesystems on LVM.
+var testDiskLayout = DiskLayout{
+	Partitions: []DiskPartition{
+		{Name: "esp", Type: "efi", SizeMB: 512, Label: "EFI", MountPoint: "/boot/efi"},
+		{Name: "bios", Type: "bios_boot", SizeMB: 1},
+		{Name: "boot", SizeMB: 1024, FileSystem: "ext4", Label: "boot", MountPoint: "/boot"},
+		{Name: "pv", Type: "lvm", VolumeGroup: "rootvg", LogicalVolumes: []DiskLogicalVolume{
+			{Name: "root", SizeMB: 8192, FileSystem: "xfs", MountPoint: "/"},
+			{Name: "var", FileSystem: "xfs", MountPoint: "/var"},
+		}},
+	},
+}
+
+func TestDiskLayout_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		layout     DiskLayout
+		diskSizeGB int64
+		wantErrs   []string
+	}{
+		{
+			name:       "complete",
+			layout:     testDiskLayout,
+			diskSizeGB: 30,
+		},
+		{
+			name:     "no partitions",
+			layout:   DiskLayout{},
+			wantErrs: []string{"at least one partition is required"},
+		},
+		{
+			name: "no root",
+			layout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "data", FileSystem: "ext4", MountPoint: "/data"},
+			}},
+			wantErrs: []string{"must be mounted at /"},
+		},
+		{
+			name: "size zero before the last partition",
+			layout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "root", FileSystem: "ext4", MountPoint: "/"},
+				{Name: "swap", Type: "swap", SizeMB: 1024},
+			}},
+			wantErrs: []string{"partitions[0].size_mb: only the last partition"},
+		},
+		{
+			name: "does not fit on the disk",
+			layout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "root", SizeMB: 30 * 1024, FileSystem: "ext4", MountPoint: "/"},
+			}},
+			diskSizeGB: 30,
+			wantErrs:   []string{"do not fit on the disk"},
+		},
+		{
+			name: "invalid partitions",
+			layout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "root", FileSystem: "ext4", MountPoint: "/", SizeMB: 1024},
+				{Name: "root", Type: "efi", FileSystem: "ext4", MountPoint: "/", SizeMB: 512},
+				{Name: "bios-boot", Type: "bios_boot", MountPoint: "/boot", SizeMB: 1},
+				{Name: "swap", Type: "swap", MountPoint: "/swap", SizeMB: 1024},
+				{Name: "other", Type: "msdos"},
+			}},
+			wantErrs: []string{
+				`partitions[1].name: "root" is used more than once`,
+				`partitions[1].filesystem: "ext4" is not one of vfat`,
+				`partitions[1].mount_point: "/" is used more than once`,
+				`partitions[2].name: "bios-boot" is not a valid name`,
+				"partitions[2]: filesystem, label and mount_point are not allowed for bios_boot partitions",
+				"partitions[3].mount_point: swap cannot be mounted",
+				`partitions[4].type: "msdos" is not one of`,
+			},
+		},
+		{
+			name: "invalid lvm partition",
+			layout: DiskLayout{Partitions: []DiskPartition{
+				{Name: "boot", SizeMB: 1024, FileSystem: "ext4", Label: "a_label_that_is_too_long", MountPoint: "/boot/"},
+				{Name: "pv", Type: "lvm", SizeMB: 1024, LogicalVolumes: []DiskLogicalVolume{
+					{Name: "root", FileSystem: "vfat", MountPoint: "/"},
+					{Name: "var", SizeMB: 2048, FileSystem: "xfs", MountPoint: "/var"},
+				}},
+			}},
+			wantErrs: []string{
+				`partitions[0].label: "a_label_that_is_too_long" is not a valid ext4 label`,
+				`partitions[0].mount_point: "/boot/" is not a clean absolute path`,
+				`partitions[1].volume_group: "" is not a valid volume group name`,
+				"partitions[1].logical_volumes[0].size_mb: only the last logical volume",
+				`partitions[1].logical_volumes[0].filesystem: "vfat" is not one of ext4, xfs, btrfs, swap`,
+				"partitions[1].logical_volumes: the logical volumes (2048 MiB) do not fit in the partition (1024 MiB)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.layout.Validate("disk_layout", tt.diskSizeGB)
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("Validate() = %v, want %d errors", errs, len(tt.wantErrs))
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), "disk_layout") || !strings.Contains(err.Error(), tt.wantErrs[i]) {
+					t.Errorf("Validate()[%d] = %q, want it to contain %q", i, err, tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiskLayout_sgdiskArgs(t *testing.T) {
+	want := []string{
+		"--zap-all", "--clear",
+		"--new=1:0:+512M", "--typecode=1:EF00", "--change-name=1:esp",
+		"--new=2:0:+1M", "--typecode=2:EF02", "--change-name=2:bios",
+		"--new=3:0:+1024M", "--typecode=3:8300", "--change-name=3:boot",
+		"--new=4:0:0", "--typecode=4:8E00", "--change-name=4:pv",
+		"/dev/sdc",
+	}
+	if got := testDiskLayout.sgdiskArgs("/dev/sdc"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sgdiskArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestDiskLayout_generatedDataKeys(t *testing.T) {
+	want := []string{"PARTUUID_esp", "UUID_esp", "PARTUUID_bios", "PARTUUID_boot", "UUID_boot", "PARTUUID_pv", "UUID_root", "UUID_var"}
+	if got := testDiskLayout.generatedDataKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("generatedDataKeys() = %v, want %v", got, want)
+	}
+}
+
+func Test_mountOrder(t *testing.T) {
+	fss := testDiskLayout.fileSystems("/dev/sdc")
+	root, ok := rootFileSystem(fss)
+	if !ok || root.Device != "/dev/rootvg/root" || root.Partition != "" {
+		t.Errorf("rootFileSystem() = %+v, %v, want the root logical volume", root, ok)
+	}
+
+	var got []string
+	for _, fs := range mountOrder(fss) {
+		got = append(got, fs.Device+" "+fs.MountPoint)
+	}
+	want := []string{"/dev/sdc3 /boot", "/dev/rootvg/var /var", "/dev/sdc1 /boot/efi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mountOrder() = %v, want %v", got, want)
+	}
+}
+
+func Test_mkfsCommand(t *testing.T) {
+	tests := []struct {
+		fs   diskLayoutFileSystem
+		want string
+	}{
+		{diskLayoutFileSystem{Device: "/dev/sdc1", FileSystem: "vfat", Label: "EFI"}, "mkfs.vfat -F 32 -n EFI /dev/sdc1"},
+		{diskLayoutFileSystem{Device: "/dev/sdc3", FileSystem: "ext4", Label: "boot"}, "mkfs.ext4 -F -L boot /dev/sdc3"},
+		{diskLayoutFileSystem{Device: "/dev/rootvg/root", FileSystem: "xfs"}, "mkfs.xfs -f /dev/rootvg/root"},
+		{diskLayoutFileSystem{Device: "/dev/sdc4", FileSystem: "btrfs"}, "mkfs.btrfs -f /dev/sdc4"},
+		{diskLayoutFileSystem{Device: "/dev/sdc2", FileSystem: "swap", Label: "swap"}, "mkswap -L swap /dev/sdc2"},
+	}
+	for _, tt := range tests {
+		if got := mkfsCommand(tt.fs); got != tt.want {
+			t.Errorf("mkfsCommand(%+v) = %q, want %q", tt.fs, got, tt.want)
+		}
+	}
+}
@@ -5,13 +5,21 @@ package chroot
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
 
-	hashiVMSDK "github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-helpers/lang/response"
+	hashiVMSDK "github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines"
+	vmssvms "github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachinescalesetvms"
 	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
@@ -25,52 +33,62 @@ type DiskAttacher interface {
 
 var NewDiskAttacher = func(azureClient client.AzureClientSet, ui packersdk.Ui) DiskAttacher {
 	return &diskAttacher{
-		azcli: azureClient,
-		ui:    ui,
+		azcli:    azureClient,
+		ui:       ui,
+		lockPath: diskLockPath,
 	}
 }
 
+// diskLockPath is the lock file that serializes the changes to the data disks
+// of the VM between the builds running on it.
+var diskLockPath = filepath.Join(os.TempDir(), "packer-azure-chroot-disks.lock")
+
+// maxDiskUpdateAttempts is the number of times the data disks of the VM are
+// read and updated again when they were changed concurrently.
+const maxDiskUpdateAttempts = 10
+
+// diskUpdateRetryDelay is the maximum delay before retrying a concurrent
+// update of the data disks, the actual delay is random.
+var diskUpdateRetryDelay = 2 * time.Second
+
 type diskAttacher struct {
 	azcli client.AzureClientSet
 
-	vm *client.ComputeInfo // store info about this VM so that we don't have to ask metadata service on every call
-	ui packersdk.Ui
+	vm       *client.ComputeInfo // store info about this VM so that we don't have to ask metadata service on every call
+	ui       packersdk.Ui
+	lockPath string
 }
 
 var DiskNotFoundError = errors.New("Disk not found")
 var AzureAPIDiskError = errors.New("Azure API returned invalid disk")
 
-func (da *diskAttacher) DetachDisk(ctx context.Context, diskID string) error {
-	log.Println("Fetching list of disks currently attached to VM")
-	currentDisks, err := da.getDisks(ctx)
-	if err != nil {
-		log.Printf("DetachDisk.getDisks: error: %+v\n", err)
-		return err
-	}
+// errConcurrentUpdate is returned by setDisks when the VM was changed since
+// it was read.
+var errConcurrentUpdate = errors.New("the VM was updated concurrently")
 
-	log.Printf("Removing %q from list of disks currently attached to VM", diskID)
-	newDisks := []hashiVMSDK.DataDisk{}
-	for _, disk := range currentDisks {
-		if disk.ManagedDisk != nil {
-			if disk.ManagedDisk.Id == nil {
-				log.Println("DetachDisks failure: Azure Client returned a disk without an ID")
-				return AzureAPIDiskError
-			}
-			if !strings.EqualFold(*disk.ManagedDisk.Id, diskID) {
-				newDisks = append(newDisks, disk)
+func (da *diskAttacher) DetachDisk(ctx context.Context, diskID string) error {
+	err := da.updateDisks(ctx, func(currentDisks []hashiVMSDK.DataDisk) ([]hashiVMSDK.DataDisk, error) {
+		log.Printf("Removing %q from list of disks currently attached to VM", diskID)
+		newDisks := []hashiVMSDK.DataDisk{}
+		for _, disk := range currentDisks {
+			if disk.ManagedDisk != nil {
+				if disk.ManagedDisk.Id == nil {
+					log.Println("DetachDisks failure: Azure Client returned a disk without an ID")
+					return nil, AzureAPIDiskError
+				}
+				if !strings.EqualFold(*disk.ManagedDisk.Id, diskID) {
+					newDisks = append(newDisks, disk)
+				}
 			}
 		}
-	}
-	if len(currentDisks) == len(newDisks) {
-		return DiskNotFoundError
-	}
-
-	log.Println("Updating new list of disks attached to VM")
-	err = da.setDisks(ctx, newDisks)
+		if len(currentDisks) == len(newDisks) {
+			return nil, DiskNotFoundError
+		}
+		return newDisks, nil
+	})
 	if err != nil {
-		log.Printf("DetachDisk.setDisks: error: %+v\n", err)
+		log.Printf("DetachDisk.updateDisks: error: %+v\n", err)
 		return err
-
 	}
 
 	return nil
@@ -97,104 +115,260 @@ func (da *diskAttacher) WaitForDetach(ctx context.Context, diskID string) error
 }
 
 func (da *diskAttacher) AttachDisk(ctx context.Context, diskID string) (int64, error) {
-	dataDisks, err := da.getDisks(ctx)
-	if err != nil {
-		log.Printf("AttachDisk.getDisks: error: %+v\n", err)
-		return -1, err
-	}
+	var lun int64 = -1
+	err := da.updateDisks(ctx, func(dataDisks []hashiVMSDK.DataDisk) ([]hashiVMSDK.DataDisk, error) {
+		// check to see if disk is already attached, remember lun if found
+		if disk := findDiskInList(dataDisks, diskID); disk != nil {
+			// disk is already attached, just take this lun
+			if disk.Lun == 0 {
+				return nil, errors.New("disk is attached, but lun was not set in VM model (possibly an error in the Azure APIs)")
+			}
+			lun = disk.Lun
+			return nil, nil
+		}
 
-	// check to see if disk is already attached, remember lun if found
-	if disk := findDiskInList(dataDisks, diskID); disk != nil {
-		// disk is already attached, just take this lun
-		if disk.Lun == 0 {
-			return -1, errors.New("disk is attached, but lun was not set in VM model (possibly an error in the Azure APIs)")
+		// disk was not found on VM, go and actually attach it
+		lun = findFreeLun(dataDisks)
+		if lun < 0 {
+			return nil, errors.New("no free lun left on the VM")
 		}
-		return disk.Lun, nil
+
+		// append new data disk to collection
+		return append(dataDisks, hashiVMSDK.DataDisk{
+			CreateOption: hashiVMSDK.DiskCreateOptionTypesAttach,
+			ManagedDisk: &hashiVMSDK.ManagedDiskParameters{
+				Id: &diskID,
+			},
+			Lun: lun,
+		}), nil
+	})
+	if err != nil {
+		log.Printf("AttachDisk.updateDisks: error: %+v\n", err)
+		return -1, err
 	}
 
-	// disk was not found on VM, go and actually attach it
+	return lun, nil
+}
 
-	// TODO This assignment looks like it would do nothing, consider removing it
-	//nolint
-	var lun int64 = -1
+// findFreeLun returns the lowest lun not used by dataDisks, or -1 if all the
+// luns are used.
+func findFreeLun(dataDisks []hashiVMSDK.DataDisk) int64 {
 findFreeLun:
-	for lun = 0; lun < 64; lun++ {
+	for lun := int64(0); lun < 64; lun++ {
 		for _, v := range dataDisks {
 			if v.Lun == lun {
 				continue findFreeLun
 			}
 		}
 		// no datadisk is using this lun
-		break
+		return lun
 	}
+	return -1
+}
 
-	// append new data disk to collection
-	dataDisks = append(dataDisks, hashiVMSDK.DataDisk{
-		CreateOption: hashiVMSDK.DiskCreateOptionTypesAttach,
-		ManagedDisk: &hashiVMSDK.ManagedDiskParameters{
-			Id: &diskID,
-		},
-		Lun: lun,
-	})
+// updateDisks replaces the data disks of this VM with the ones returned by
+// change, unless it returns nil. The update is serialized with the other
+// builds on this host by a file lock, and is conditioned on the ETag of the
+// VM so that a concurrent update from elsewhere is not overwritten: the VM is
+// then read again and change is retried.
+func (da *diskAttacher) updateDisks(ctx context.Context, change func([]hashiVMSDK.DataDisk) ([]hashiVMSDK.DataDisk, error)) error {
+	log.Printf("Waiting for the lock on %s", da.lockPath)
+	unlock, err := lockFile(ctx, da.lockPath)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", da.lockPath, err)
+	}
+	defer unlock()
+
+	for attempt := 1; ; attempt++ {
+		log.Println("Fetching list of disks currently attached to VM")
+		vmResource, err := da.getThisVM(ctx)
+		if err != nil {
+			return err
+		}
+		currentDisks, err := vmResource.dataDisks()
+		if err != nil {
+			return err
+		}
+		disks, err := change(currentDisks)
+		if err != nil || disks == nil {
+			return err
+		}
 
-	// prepare resource object for update operation
-	err = da.setDisks(ctx, dataDisks)
+		log.Println("Updating new list of disks attached to VM")
+		err = da.setDisks(ctx, vmResource, disks)
+		if !errors.Is(err, errConcurrentUpdate) {
+			return err
+		}
+		if attempt == maxDiskUpdateAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := time.Duration(rand.Int64N(int64(diskUpdateRetryDelay)))
+		log.Printf("The VM was updated concurrently, retrying in %s", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// hostVM is the model of the VM Packer runs on. Standalone VMs and the
+// instances of flexible scale sets are VM resources, the instances of uniform
+// scale sets are scale set VMs.
+type hostVM struct {
+	vm     *hashiVMSDK.VirtualMachine
+	vmssVM *vmssvms.VirtualMachineScaleSetVM
+}
+
+func (h hostVM) etag() *string {
+	if h.vmssVM != nil {
+		return h.vmssVM.Etag
+	}
+	return h.vm.Etag
+}
+
+// dataDisks returns the data disks of the VM, using the VM model for the
+// scale set VMs too.
+func (h hostVM) dataDisks() ([]hashiVMSDK.DataDisk, error) {
+	if h.vmssVM != nil {
+		if h.vmssVM.Properties.StorageProfile.DataDisks == nil {
+			return []hashiVMSDK.DataDisk{}, nil
+		}
+		return convertDataDisks[hashiVMSDK.DataDisk](*h.vmssVM.Properties.StorageProfile.DataDisks)
+	}
+	if h.vm.Properties.StorageProfile.DataDisks == nil {
+		return []hashiVMSDK.DataDisk{}, nil
+	}
+	return *h.vm.Properties.StorageProfile.DataDisks, nil
+}
+
+// convertDataDisks converts between the data disk models of the VM and scale
+// set VM APIs, which share the same schema.
+func convertDataDisks[T any](disks interface{}) ([]T, error) {
+	b, err := json.Marshal(disks)
 	if err != nil {
-		log.Printf("AttachDisk.setDisks: error: %+v\n", err)
-		return -1, err
+		return nil, err
+	}
+	out := []T{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
 	}
+	return out, nil
+}
 
-	return lun, nil
+// scaleSetVMID returns the ID of the VM described by info when it is an
+// instance of a uniform scale set, which is managed through the scale set VM
+// API instead of the VM API.
+func scaleSetVMID(info *client.ComputeInfo) (*vmssvms.VirtualMachineScaleSetVirtualMachineId, bool) {
+	if info.VmScaleSetName == "" {
+		return nil, false
+	}
+	id, err := vmssvms.ParseVirtualMachineScaleSetVirtualMachineIDInsensitively("/" + strings.TrimPrefix(info.ResourceID, "/"))
+	if err != nil {
+		// flexible orchestration, the instances are regular VMs
+		return nil, false
+	}
+	return id, true
 }
 
-func (da *diskAttacher) getThisVM(ctx context.Context) (hashiVMSDK.VirtualMachine, error) {
+func (da *diskAttacher) getThisVM(ctx context.Context) (hostVM, error) {
 	// getting resource info for this VM
 	if da.vm == nil {
 		vm, err := da.azcli.MetadataClient().GetComputeInfo()
 		if err != nil {
-			return hashiVMSDK.VirtualMachine{}, err
+			return hostVM{}, err
 		}
 		da.vm = vm
 	}
 	// retrieve actual VM
-	vmID := hashiVMSDK.NewVirtualMachineID(da.azcli.SubscriptionID(), da.vm.ResourceGroupName, da.vm.Name)
 	pollingContext, cancel := context.WithTimeout(ctx, da.azcli.PollingDuration())
 	defer cancel()
+	if vmssVMID, ok := scaleSetVMID(da.vm); ok {
+		vmssVMResource, err := da.azcli.VirtualMachineScaleSetVMsClient().Get(pollingContext, *vmssVMID, vmssvms.DefaultGetOperationOptions())
+		if err != nil {
+			return hostVM{}, err
+		}
+		if vmssVMResource.Model == nil || vmssVMResource.Model.Properties == nil || vmssVMResource.Model.Properties.StorageProfile == nil {
+			return hostVM{}, errors.New("properties.storageProfile is not set on scale set VM, this is unexpected")
+		}
+		return hostVM{vmssVM: vmssVMResource.Model}, nil
+	}
+
+	vmID := hashiVMSDK.NewVirtualMachineID(da.azcli.SubscriptionID(), da.vm.ResourceGroupName, da.vm.Name)
 	vmResource, err := da.azcli.VirtualMachinesClient().Get(pollingContext, vmID, hashiVMSDK.DefaultGetOperationOptions())
 	if err != nil {
-		return hashiVMSDK.VirtualMachine{}, err
+		return hostVM{}, err
 	}
 	if vmResource.Model.Properties.StorageProfile == nil {
-		return hashiVMSDK.VirtualMachine{}, errors.New("properties.storageProfile is not set on VM, this is unexpected")
+		return hostVM{}, errors.New("properties.storageProfile is not set on VM, this is unexpected")
 	}
 
-	return *vmResource.Model, nil
+	return hostVM{vm: vmResource.Model}, nil
 }
 
-func (da diskAttacher) getDisks(ctx context.Context) ([]hashiVMSDK.DataDisk, error) {
+func (da *diskAttacher) getDisks(ctx context.Context) ([]hashiVMSDK.DataDisk, error) {
 	vmResource, err := da.getThisVM(ctx)
 	if err != nil {
 		return []hashiVMSDK.DataDisk{}, err
 	}
 
-	return *vmResource.Properties.StorageProfile.DataDisks, nil
+	return vmResource.dataDisks()
 }
 
-func (da diskAttacher) setDisks(ctx context.Context, disks []hashiVMSDK.DataDisk) error {
-	vmResource, err := da.getThisVM(ctx)
-	if err != nil {
-		return err
+// setDisks updates vmResource with the data disks disks and waits for the
+// update to complete, so that the lock is held until then. It returns
+// errConcurrentUpdate if the VM changed since vmResource was read, or is being
+// updated by someone else.
+func (da *diskAttacher) setDisks(ctx context.Context, vmResource hostVM, disks []hashiVMSDK.DataDisk) error {
+	etag := vmResource.etag()
+	if etag == nil {
+		log.Println("The VM has no ETag, updating it unconditionally")
 	}
 
-	vmResource.Properties.StorageProfile.DataDisks = &disks
-	vmResource.Resources = nil
-
 	// TODO pass in polling context here
 	pollingContext, cancel := context.WithTimeout(ctx, time.Minute*5)
 	defer cancel()
-	vmID := hashiVMSDK.NewVirtualMachineID(da.azcli.SubscriptionID(), da.vm.ResourceGroupName, da.vm.Name)
-	// update the VM resource, attach disk
-	_, err = da.azcli.VirtualMachinesClient().CreateOrUpdate(pollingContext, vmID, vmResource)
+
+	var httpResponse *http.Response
+	var err error
+	if vmResource.vmssVM != nil {
+		vmssVMDisks, convErr := convertDataDisks[vmssvms.DataDisk](disks)
+		if convErr != nil {
+			return convErr
+		}
+		vmResource.vmssVM.Properties.StorageProfile.DataDisks = &vmssVMDisks
+		vmResource.vmssVM.Resources = nil
+
+		options := vmssvms.DefaultUpdateOperationOptions()
+		options.IfMatch = etag
+		vmssVMID, _ := scaleSetVMID(da.vm)
+		// update the scale set VM resource, attach disk
+		var result vmssvms.UpdateOperationResponse
+		result, err = da.azcli.VirtualMachineScaleSetVMsClient().Update(pollingContext, *vmssVMID, *vmResource.vmssVM, options)
+		httpResponse = result.HttpResponse
+		if err == nil {
+			err = result.Poller.PollUntilDone(pollingContext)
+		}
+	} else {
+		vmResource.vm.Properties.StorageProfile.DataDisks = &disks
+		vmResource.vm.Resources = nil
+
+		options := hashiVMSDK.DefaultCreateOrUpdateOperationOptions()
+		options.IfMatch = etag
+		vmID := hashiVMSDK.NewVirtualMachineID(da.azcli.SubscriptionID(), da.vm.ResourceGroupName, da.vm.Name)
+		// update the VM resource, attach disk
+		var result hashiVMSDK.CreateOrUpdateOperationResponse
+		result, err = da.azcli.VirtualMachinesClient().CreateOrUpdate(pollingContext, vmID, *vmResource.vm, options)
+		httpResponse = result.HttpResponse
+		if err == nil {
+			err = result.Poller.PollUntilDone(pollingContext)
+		}
+	}
+	// 409 is returned while another update of the VM is running
+	if err != nil && (response.WasStatusCode(httpResponse, http.StatusPreconditionFailed) || response.WasConflict(httpResponse)) {
+		return errConcurrentUpdate
+	}
 
 	return err
 }
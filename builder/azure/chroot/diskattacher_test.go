@@ -0,0 +1,340 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachinescalesetvms"
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"golang.org/x/oauth2"
+)
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Token(context.Context, *http.Request) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: "token", TokenType: "Bearer"}, nil
+}
+
+func (fakeAuthorizer) AuxiliaryTokens(context.Context, *http.Request) ([]*oauth2.Token, error) {
+	return nil, nil
+}
+
+// fakeVM is a stand-in of the Azure Resource Manager endpoint of a VM that
+// enforces If-Match on updates. Updates are slow so that concurrent attachers
+// read the same version of the VM, and run as long-running operations during
+// which other updates are rejected with 409. The VM is served on path, or as
+// the testVM VM resource if path is not set.
+type fakeVM struct {
+	path      string
+	mu        sync.Mutex
+	etag      int
+	disks     []virtualmachines.DataDisk
+	conflicts int
+	busy      int
+	updates   int
+
+	// updateDuration is how long an update runs after it was accepted,
+	// 20ms if not set. updating is the end of the update in flight.
+	updateDuration time.Duration
+	updating       time.Time
+}
+
+func (f *fakeVM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/operations/update") {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		status := "Succeeded"
+		if time.Now().Before(f.updating) {
+			status = "InProgress"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "0")
+		fmt.Fprintf(w, `{"status": %q}`, status)
+		return
+	}
+
+	path := f.path
+	if path == "" {
+		path = "/resourceGroups/testResourceGroup/providers/Microsoft.Compute/virtualMachines/testVM"
+	}
+	if !strings.HasSuffix(r.URL.Path, path) {
+		http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.write(w)
+	case http.MethodPut:
+		var vm virtualmachines.VirtualMachine
+		if err := json.NewDecoder(r.Body).Decode(&vm); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if time.Now().Before(f.updating) {
+			f.busy++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error": {"code": "OperationNotAllowed", "message": "the VM is being updated"}}`)
+			return
+		}
+		if ifMatch := r.Header.Get("If-Match"); ifMatch != f.etagString() {
+			f.conflicts++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprintf(w, `{"error": {"code": "PreconditionFailed", "message": "etag %s does not match %s"}}`, ifMatch, f.etagString())
+			return
+		}
+		f.updates++
+		f.etag++
+		f.disks = *vm.Properties.StorageProfile.DataDisks
+		updateDuration := f.updateDuration
+		if updateDuration == 0 {
+			updateDuration = 20 * time.Millisecond
+		}
+		f.updating = time.Now().Add(updateDuration)
+		w.Header().Set("Azure-AsyncOperation", "http://"+r.Host+"/operations/update")
+		w.Header().Set("Retry-After", "0")
+		f.write(w)
+	default:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeVM) etagString() string {
+	return fmt.Sprintf(`"%d"`, f.etag)
+}
+
+func (f *fakeVM) write(w http.ResponseWriter) {
+	etag := f.etagString()
+	disks := f.disks
+	state := "Succeeded"
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(virtualmachines.VirtualMachine{
+		Etag:     &etag,
+		Location: "westus2",
+		Properties: &virtualmachines.VirtualMachineProperties{
+			ProvisioningState: &state,
+			StorageProfile:    &virtualmachines.StorageProfile{DataDisks: &disks},
+		},
+	})
+}
+
+func newTestDiskAttacher(t *testing.T, url, lockPath string) *diskAttacher {
+	vmClient, err := virtualmachines.NewVirtualMachinesClientWithBaseURI(environments.NewApiEndpoint("ResourceManager", url, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vmClient.Client.Authorizer = fakeAuthorizer{}
+	vmssVMsClient, err := virtualmachinescalesetvms.NewVirtualMachineScaleSetVMsClientWithBaseURI(environments.NewApiEndpoint("ResourceManager", url, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vmssVMsClient.Client.Authorizer = fakeAuthorizer{}
+	return &diskAttacher{
+		azcli: &client.AzureClientSetMock{
+			VirtualMachinesClientMock: *vmClient,
+			VMSSVMsClientMock:         *vmssVMsClient,
+			MetadataClientMock: client.MetadataClientStub{
+				ComputeInfo: client.ComputeInfo{
+					SubscriptionID:    "testSubscriptionID",
+					ResourceGroupName: "testResourceGroup",
+					Name:              "testVM",
+				},
+			},
+			SubscriptionIDMock:  "testSubscriptionID",
+			PollingDurationMock: time.Minute,
+		},
+		lockPath: lockPath,
+	}
+}
+
+// attachConcurrently attaches count disks in parallel, each with its own
+// disk attacher as separate builds would, and checks that all the disks are
+// attached with distinct luns.
+func attachConcurrently(t *testing.T, vm *fakeVM, count int, lockPath func(int) string) {
+	server := httptest.NewServer(vm)
+	defer server.Close()
+
+	delay := diskUpdateRetryDelay
+	diskUpdateRetryDelay = 10 * time.Millisecond
+	defer func() { diskUpdateRetryDelay = delay }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	luns := make([]int64, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			da := newTestDiskAttacher(t, server.URL, lockPath(i))
+			luns[i], errs[i] = da.AttachDisk(ctx, fmt.Sprintf("/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/disk%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for i := 0; i < count; i++ {
+		if errs[i] != nil {
+			t.Fatalf("AttachDisk(disk%d): %v", i, errs[i])
+		}
+		if seen[luns[i]] {
+			t.Errorf("lun %d was returned twice", luns[i])
+		}
+		seen[luns[i]] = true
+	}
+	if len(vm.disks) != count {
+		t.Errorf("got %d disks attached to the VM, want %d", len(vm.disks), count)
+	}
+	for i := 0; i < count; i++ {
+		disk := findDiskInList(vm.disks, fmt.Sprintf("/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/disk%d", i))
+		if disk == nil {
+			t.Errorf("disk%d is not attached to the VM", i)
+		} else if disk.Lun != luns[i] {
+			t.Errorf("disk%d is attached on lun %d, AttachDisk returned %d", i, disk.Lun, luns[i])
+		}
+	}
+}
+
+func Test_DiskAttacher_AttachDisk_ConcurrentHosts(t *testing.T) {
+	// Without a shared lock, as for builds on different hosts or tools
+	// updating the VM, the concurrent updates are detected with the ETag of
+	// the VM or rejected while another update runs, and retried.
+	dir := t.TempDir()
+	vm := &fakeVM{}
+	attachConcurrently(t, vm, 4, func(i int) string {
+		return filepath.Join(dir, fmt.Sprintf("lock%d", i))
+	})
+	if vm.conflicts+vm.busy == 0 {
+		t.Error("expected the concurrent updates to conflict")
+	}
+}
+
+func Test_DiskAttacher_AttachDisk_SameHost(t *testing.T) {
+	// Builds on the same host are serialized by the lock file and never
+	// conflict. The lock is released once the update completed, the updates
+	// outlast the wait for the lock.
+	lockPath := filepath.Join(t.TempDir(), "lock")
+	vm := &fakeVM{updateDuration: 200 * time.Millisecond}
+	attachConcurrently(t, vm, 4, func(int) string { return lockPath })
+	if vm.conflicts != 0 {
+		t.Errorf("got %d conflicting updates, want none", vm.conflicts)
+	}
+	if vm.busy != 0 {
+		t.Errorf("got %d updates while another one was running, want none", vm.busy)
+	}
+}
+
+func Test_DiskAttacher_DetachDisk(t *testing.T) {
+	vm := &fakeVM{}
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/disk%d", i)
+		vm.disks = append(vm.disks, virtualmachines.DataDisk{Lun: int64(i), ManagedDisk: &virtualmachines.ManagedDiskParameters{Id: &id}})
+	}
+	server := httptest.NewServer(vm)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	da := newTestDiskAttacher(t, server.URL, filepath.Join(t.TempDir(), "lock"))
+
+	err := da.DetachDisk(ctx, "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/DISK1")
+	if err != nil {
+		t.Fatalf("DetachDisk: %v", err)
+	}
+	if len(vm.disks) != 2 || vm.disks[0].Lun != 0 || vm.disks[1].Lun != 2 {
+		t.Errorf("unexpected disks after detach: %+v", vm.disks)
+	}
+
+	err = da.DetachDisk(ctx, "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/disk1")
+	if err != DiskNotFoundError {
+		t.Errorf("got error %v, want %v", err, DiskNotFoundError)
+	}
+	if vm.updates != 1 {
+		t.Errorf("got %d updates of the VM, want 1", vm.updates)
+	}
+}
+
+func Test_DiskAttacher_ScaleSetVM(t *testing.T) {
+	tests := []struct {
+		name string
+		info client.ComputeInfo
+		path string
+	}{
+		{
+			name: "uniform",
+			info: client.ComputeInfo{
+				Name:              "testVMSS_3",
+				ResourceID:        "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/virtualMachineScaleSets/testVMSS/virtualMachines/3",
+				ResourceGroupName: "testResourceGroup",
+				SubscriptionID:    "testSubscriptionID",
+				VmScaleSetName:    "testVMSS",
+			},
+			path: "/resourceGroups/testResourceGroup/providers/Microsoft.Compute/virtualMachineScaleSets/testVMSS/virtualMachines/3",
+		},
+		{
+			name: "flexible",
+			info: client.ComputeInfo{
+				Name:              "testVMSS_1a2b3c4d",
+				ResourceID:        "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/virtualMachines/testVMSS_1a2b3c4d",
+				ResourceGroupName: "testResourceGroup",
+				SubscriptionID:    "testSubscriptionID",
+				VmScaleSetName:    "testVMSS",
+			},
+			path: "/resourceGroups/testResourceGroup/providers/Microsoft.Compute/virtualMachines/testVMSS_1a2b3c4d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := &fakeVM{path: tt.path}
+			server := httptest.NewServer(vm)
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			da := newTestDiskAttacher(t, server.URL, filepath.Join(t.TempDir(), "lock"))
+			da.azcli.(*client.AzureClientSetMock).MetadataClientMock = client.MetadataClientStub{ComputeInfo: tt.info}
+
+			diskID := "/subscriptions/testSubscriptionID/resourceGroups/testResourceGroup/providers/Microsoft.Compute/disks/disk0"
+			lun, err := da.AttachDisk(ctx, diskID)
+			if err != nil {
+				t.Fatalf("AttachDisk: %v", err)
+			}
+			if disk := findDiskInList(vm.disks, diskID); disk == nil || disk.Lun != lun {
+				t.Errorf("disk is not attached on lun %d: %+v", lun, vm.disks)
+			}
+
+			if err := da.DetachDisk(ctx, diskID); err != nil {
+				t.Fatalf("DetachDisk: %v", err)
+			}
+			if err := da.WaitForDetach(ctx, diskID); err != nil {
+				t.Fatalf("WaitForDetach: %v", err)
+			}
+			if vm.updates != 2 {
+				t.Errorf("got %d updates of the VM, want 2", vm.updates)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	posixpath "path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fstabEntry is a line of /etc/fstab.
+type fstabEntry struct {
+	Spec    string
+	File    string
+	VFSType string
+	Options string
+}
+
+// parseFstab parses the entries of an fstab(5) file, skipping comments and
+// blank lines.
+func parseFstab(r io.Reader) ([]fstabEntry, error) {
+	var entries []fstabEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 fields, got %q", line, text)
+		}
+		entry := fstabEntry{
+			Spec:    unescapeFstab(fields[0]),
+			File:    unescapeFstab(fields[1]),
+			VFSType: fields[2],
+			Options: "defaults",
+		}
+		if len(fields) > 3 {
+			entry.Options = fields[3]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+var fstabEscapeRegex = regexp.MustCompile(`\\[0-7]{3}`)
+
+// unescapeFstab replaces the octal escapes of fstab fields, like \040 for a
+// space.
+func unescapeFstab(field string) string {
+	return fstabEscapeRegex.ReplaceAllStringFunc(field, func(escape string) string {
+		c, _ := strconv.ParseUint(escape[1:], 8, 8)
+		return string(rune(c))
+	})
+}
+
+// fstabMounts returns the entries of block devices that are mounted under the
+// root filesystem at boot, parents before their children. The root
+// filesystem, swap, noauto entries and pseudo or network filesystems are left
+// out.
+func fstabMounts(entries []fstabEntry) []fstabEntry {
+	var mounts []fstabEntry
+	for _, e := range entries {
+		if e.VFSType == "swap" || !posixpath.IsAbs(e.File) || posixpath.Clean(e.File) == "/" {
+			continue
+		}
+		if hasFstabOption(e.Options, "noauto") {
+			continue
+		}
+		if !isBlockDeviceSpec(e.Spec) {
+			continue
+		}
+		mounts = append(mounts, e)
+	}
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return pathDepth(mounts[i].File) < pathDepth(mounts[j].File)
+	})
+	return mounts
+}
+
+func hasFstabOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// fstabFilesystems are the filesystem types of the entries that are mounted.
+var fstabFilesystems = map[string]bool{
+	"auto": true, "btrfs": true, "exfat": true, "ext2": true, "ext3": true,
+	"ext4": true, "f2fs": true, "jfs": true, "msdos": true, "ntfs": true,
+	"ntfs3": true, "vfat": true, "xfs": true,
+}
+
+var fstabOptionsRegex = regexp.MustCompile(`^[A-Za-z0-9_.,=:@/+-]+$`)
+
+// validateFstabMount returns an error when the entry is not one that can be
+// mounted safely: the fstab of the image is not trusted, and its fields end
+// up in the commands run on the host.
+func validateFstabMount(e fstabEntry) error {
+	if !fstabFilesystems[e.VFSType] {
+		return fmt.Errorf("unsupported filesystem type %q", e.VFSType)
+	}
+	if !fstabOptionsRegex.MatchString(e.Options) {
+		return fmt.Errorf("unsupported characters in the mount options %q", e.Options)
+	}
+	if strings.ContainsFunc(e.File, unicode.IsControl) {
+		return fmt.Errorf("control characters in the mount point %q", e.File)
+	}
+	return nil
+}
+
+func pathDepth(p string) int {
+	p = posixpath.Clean(p)
+	if p == "/" {
+		return 0
+	}
+	return strings.Count(p, "/")
+}
+
+var fstabTagPrefixes = []string{"UUID=", "LABEL=", "PARTUUID=", "PARTLABEL="}
+
+func isBlockDeviceSpec(spec string) bool {
+	for _, prefix := range fstabTagPrefixes {
+		if strings.HasPrefix(spec, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(spec, "/dev/")
+}
+
+// blockDevice is a device listed by lsblk.
+type blockDevice struct {
+	Name      string
+	UUID      string
+	Label     string
+	PartUUID  string
+	PartLabel string
+	FSType    string
+}
+
+// lsblkColumns are the columns parseLsblk expects.
+const lsblkColumns = "NAME,UUID,LABEL,PARTUUID,PARTLABEL,FSTYPE"
+
+var lsblkPairRegex = regexp.MustCompile(`([A-Z-]+)="((?:[^"\\]|\\.)*)"`)
+
+// parseLsblk parses the output of `lsblk -P -p -o <lsblkColumns>`.
+func parseLsblk(out string) []blockDevice {
+	var devices []blockDevice
+	for _, line := range strings.Split(out, "\n") {
+		var d blockDevice
+		for _, m := range lsblkPairRegex.FindAllStringSubmatch(line, -1) {
+			value := unescapeLsblk(m[2])
+			switch m[1] {
+			case "NAME":
+				d.Name = value
+			case "UUID":
+				d.UUID = value
+			case "LABEL":
+				d.Label = value
+			case "PARTUUID":
+				d.PartUUID = value
+			case "PARTLABEL":
+				d.PartLabel = value
+			case "FSTYPE":
+				d.FSType = value
+			}
+		}
+		if d.Name != "" {
+			devices = append(devices, d)
+		}
+	}
+	return devices
+}
+
+var lsblkEscapeRegex = regexp.MustCompile(`\\x[0-9a-fA-F]{2}`)
+
+// unescapeLsblk replaces the \xHH escapes of lsblk values.
+func unescapeLsblk(value string) string {
+	return lsblkEscapeRegex.ReplaceAllStringFunc(value, func(escape string) string {
+		c, _ := strconv.ParseUint(escape[2:], 16, 8)
+		return string(rune(c))
+	})
+}
+
+// resolveFstabSpec returns the device among devices that the fstab spec
+// refers to. Kernel device names like /dev/sda1 are not resolved: they name
+// the devices of the VM the image runs on, not of the attached disk.
+func resolveFstabSpec(spec string, devices []blockDevice) (string, bool) {
+	spec = strings.ReplaceAll(spec, `"`, "")
+	tag, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		for byTag, t := range map[string]string{
+			"/dev/disk/by-uuid/":      "UUID",
+			"/dev/disk/by-label/":     "LABEL",
+			"/dev/disk/by-partuuid/":  "PARTUUID",
+			"/dev/disk/by-partlabel/": "PARTLABEL",
+		} {
+			if strings.HasPrefix(spec, byTag) {
+				tag, value, ok = t, unescapeLsblk(strings.TrimPrefix(spec, byTag)), true
+			}
+		}
+	}
+
+	for _, d := range devices {
+		var match bool
+		switch {
+		case !ok:
+			match = d.Name == spec || d.Name == lvmMapperDevice(spec)
+		case tag == "UUID":
+			match = strings.EqualFold(d.UUID, value)
+		case tag == "LABEL":
+			match = d.Label == value
+		case tag == "PARTUUID":
+			match = strings.EqualFold(d.PartUUID, value)
+		case tag == "PARTLABEL":
+			match = d.PartLabel == value
+		}
+		if match {
+			return d.Name, true
+		}
+	}
+	return "", false
+}
+
+// lvmMapperDevice returns the /dev/mapper path of a /dev/<vg>/<lv> logical
+// volume path, doubling the dashes of the names like device-mapper does.
+func lvmMapperDevice(device string) string {
+	parts := strings.Split(strings.TrimPrefix(device, "/dev/"), "/")
+	if len(parts) != 2 || parts[0] == "mapper" || parts[0] == "disk" {
+		return ""
+	}
+	escape := func(s string) string { return strings.ReplaceAll(s, "-", "--") }
+	return fmt.Sprintf("/dev/mapper/%s-%s", escape(parts[0]), escape(parts[1]))
+}
@@ -0,0 +1,157 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFstab = `# /etc/fstab: static file system information.
+#
+UUID=1111-root  /               btrfs  subvol=@,defaults     0 0
+UUID="2222-boot" /boot          ext4   defaults              0 2
+
+PARTLABEL=esp   /boot/efi       vfat   umask=0077            0 1
+UUID=1111-root  /home           btrfs  subvol=@home          0 0
+/dev/rootvg/var /var            xfs    defaults,nofail       0 0
+/dev/sda2       /mnt/old        ext4   defaults              0 0
+LABEL=data      /srv/my\040data ext4   noatime               0 0
+/dev/mapper/rootvg-swap none    swap   sw                    0 0
+tmpfs           /tmp            tmpfs  defaults
+proc            /proc           proc   defaults              0 0
+server:/export  /mnt/nfs        nfs    defaults              0 0
+`
+
+func Test_parseFstab(t *testing.T) {
+	entries, err := parseFstab(strings.NewReader(testFstab))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 11 {
+		t.Fatalf("parseFstab() returned %d entries, want 11: %+v", len(entries), entries)
+	}
+	if want := (fstabEntry{Spec: `UUID="2222-boot"`, File: "/boot", VFSType: "ext4", Options: "defaults"}); entries[1] != want {
+		t.Errorf("entries[1] = %+v, want %+v", entries[1], want)
+	}
+	if got := entries[6].File; got != "/srv/my data" {
+		t.Errorf("entries[6].File = %q, want the unescaped path", got)
+	}
+	if got := entries[8].Options; got != "defaults" {
+		t.Errorf("entries[8].Options = %q, want defaults", got)
+	}
+
+	if _, err := parseFstab(strings.NewReader("UUID=1111 /\n")); err == nil {
+		t.Error("parseFstab() of an incomplete line did not fail")
+	}
+}
+
+func Test_fstabMounts(t *testing.T) {
+	entries, err := parseFstab(strings.NewReader(testFstab))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range fstabMounts(entries) {
+		got = append(got, e.File)
+	}
+	want := []string{"/boot", "/home", "/var", "/boot/efi", "/mnt/old", "/srv/my data"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fstabMounts() = %v, want %v", got, want)
+	}
+}
+
+func Test_fstabMounts_noauto(t *testing.T) {
+	entries := []fstabEntry{
+		{Spec: "UUID=1111", File: "/boot", VFSType: "ext4", Options: "defaults"},
+		{Spec: "UUID=2222", File: "/mnt/backup", VFSType: "ext4", Options: "noauto,user"},
+	}
+	if got := fstabMounts(entries); len(got) != 1 || got[0].File != "/boot" {
+		t.Errorf("fstabMounts() = %+v, want only /boot", got)
+	}
+}
+
+func Test_validateFstabMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   fstabEntry
+		wantErr bool
+	}{
+		{name: "btrfs subvolume", entry: fstabEntry{File: "/home", VFSType: "btrfs", Options: "subvol=@home,compress=zstd:1"}},
+		{name: "space in mount point", entry: fstabEntry{File: "/srv/my data", VFSType: "ext4", Options: "defaults"}},
+		{name: "unknown type", entry: fstabEntry{File: "/boot", VFSType: "ext4;reboot", Options: "defaults"}, wantErr: true},
+		{name: "shell in options", entry: fstabEntry{File: "/boot", VFSType: "ext4", Options: "defaults,$(reboot)"}, wantErr: true},
+		{name: "newline in mount point", entry: fstabEntry{File: "/boot\nreboot", VFSType: "ext4", Options: "defaults"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateFstabMount(tt.entry); (err != nil) != tt.wantErr {
+				t.Errorf("validateFstabMount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_shellQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"/dev/sdc1":          "/dev/sdc1",
+		"/mnt/chroot/my dir": "'/mnt/chroot/my dir'",
+		"/a;reboot":          "'/a;reboot'",
+		"/it's":              `'/it'\''s'`,
+	} {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func Test_parseLsblk(t *testing.T) {
+	out := `NAME="/dev/sdc" UUID="" LABEL="" PARTUUID="" PARTLABEL=""
+NAME="/dev/sdc1" UUID="ABCD-EF01" LABEL="" PARTUUID="aaaa-1" PARTLABEL="esp" FSTYPE="vfat"
+NAME="/dev/sdc2" UUID="2222-boot" LABEL="boot" PARTUUID="aaaa-2" PARTLABEL="my\x20boot"
+NAME="/dev/mapper/rootvg-var" UUID="3333-var" LABEL="" PARTUUID="" PARTLABEL=""
+`
+	want := []blockDevice{
+		{Name: "/dev/sdc"},
+		{Name: "/dev/sdc1", UUID: "ABCD-EF01", PartUUID: "aaaa-1", PartLabel: "esp", FSType: "vfat"},
+		{Name: "/dev/sdc2", UUID: "2222-boot", Label: "boot", PartUUID: "aaaa-2", PartLabel: "my boot"},
+		{Name: "/dev/mapper/rootvg-var", UUID: "3333-var"},
+	}
+	if got := parseLsblk(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsblk() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_resolveFstabSpec(t *testing.T) {
+	devices := []blockDevice{
+		{Name: "/dev/sdc1", UUID: "ABCD-EF01", PartUUID: "aaaa-1", PartLabel: "esp"},
+		{Name: "/dev/sdc2", UUID: "2222-boot", Label: "boot", PartUUID: "aaaa-2"},
+		{Name: "/dev/mapper/root--vg-var", UUID: "3333-var"},
+	}
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"UUID=abcd-ef01", "/dev/sdc1"},
+		{`UUID="2222-boot"`, "/dev/sdc2"},
+		{"LABEL=boot", "/dev/sdc2"},
+		{"PARTUUID=AAAA-2", "/dev/sdc2"},
+		{"PARTLABEL=esp", "/dev/sdc1"},
+		{"/dev/disk/by-uuid/3333-var", "/dev/mapper/root--vg-var"},
+		{"/dev/disk/by-partlabel/esp", "/dev/sdc1"},
+		{"/dev/mapper/root--vg-var", "/dev/mapper/root--vg-var"},
+		{"/dev/root-vg/var", "/dev/mapper/root--vg-var"},
+		{"/dev/sdc1", "/dev/sdc1"},
+		{"/dev/sda1", ""},
+		{"UUID=9999", ""},
+		{"LABEL=BOOT", ""},
+	}
+	for _, tt := range tests {
+		got, ok := resolveFstabSpec(tt.spec, devices)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("resolveFstabSpec(%q) = %q, %v, want %q", tt.spec, got, ok, tt.want)
+		}
+	}
+}
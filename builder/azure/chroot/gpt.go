@@ -0,0 +1,224 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const gptSignature = "EFI PART"
+
+// Offsets of the fields of a GPT header, see the UEFI specification.
+const (
+	gptHeaderSizeOffset      = 12
+	gptHeaderCRCOffset       = 16
+	gptMyLBAOffset           = 24
+	gptAlternateLBAOffset    = 32
+	gptFirstUsableLBAOffset  = 40
+	gptLastUsableLBAOffset   = 48
+	gptEntriesLBAOffset      = 72
+	gptEntriesCountOffset    = 80
+	gptEntrySizeOffset       = 84
+	gptEntriesCRCOffset      = 88
+	gptMinHeaderSize         = 92
+	gptEntryFirstLBAOffset   = 32
+	gptEntryLastLBAOffset    = 40
+	mbrPartitionRecordOffset = 446
+	mbrProtectiveType        = 0xEE
+)
+
+// gptTable is the GUID partition table of a disk.
+type gptTable struct {
+	SectorSize int64
+	// header is the primary header, entries the partition entry array.
+	header  []byte
+	entries []byte
+}
+
+// gptDisk is the disk or image file holding a GPT.
+type gptDisk interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// readGPT reads the primary GPT of disk, detecting 512 and 4096 byte
+// sectors, and verifies its checksums.
+func readGPT(disk io.ReaderAt) (*gptTable, error) {
+	for _, sectorSize := range []int64{512, 4096} {
+		sector := make([]byte, sectorSize)
+		if _, err := disk.ReadAt(sector, sectorSize); err != nil {
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return nil, err
+		}
+		if string(sector[:len(gptSignature)]) != gptSignature {
+			continue
+		}
+
+		headerSize := int64(binary.LittleEndian.Uint32(sector[gptHeaderSizeOffset:]))
+		if headerSize < gptMinHeaderSize || headerSize > sectorSize {
+			return nil, fmt.Errorf("invalid GPT header size %d", headerSize)
+		}
+		t := &gptTable{SectorSize: sectorSize, header: sector[:headerSize]}
+		if crc := t.headerCRC(); crc != binary.LittleEndian.Uint32(t.header[gptHeaderCRCOffset:]) {
+			return nil, fmt.Errorf("GPT header checksum mismatch")
+		}
+
+		t.entries = make([]byte, int64(t.entriesCount())*int64(t.entrySize()))
+		if _, err := disk.ReadAt(t.entries, int64(t.u64(gptEntriesLBAOffset))*sectorSize); err != nil {
+			return nil, fmt.Errorf("error reading GPT partition entries: %v", err)
+		}
+		if crc32.ChecksumIEEE(t.entries) != binary.LittleEndian.Uint32(t.header[gptEntriesCRCOffset:]) {
+			return nil, fmt.Errorf("GPT partition entries checksum mismatch")
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("no GPT found")
+}
+
+func (t *gptTable) u64(offset int) uint64 {
+	return binary.LittleEndian.Uint64(t.header[offset:])
+}
+
+func (t *gptTable) entriesCount() uint32 {
+	return binary.LittleEndian.Uint32(t.header[gptEntriesCountOffset:])
+}
+
+func (t *gptTable) entrySize() uint32 {
+	return binary.LittleEndian.Uint32(t.header[gptEntrySizeOffset:])
+}
+
+func (t *gptTable) entry(i int) []byte {
+	size := int(t.entrySize())
+	return t.entries[i*size : (i+1)*size]
+}
+
+// partitionLBAs returns the first and last sectors of the partition at index
+// i, ok is false for unused entries.
+func (t *gptTable) partitionLBAs(i int) (first, last uint64, ok bool) {
+	e := t.entry(i)
+	if bytes.Equal(e[:16], make([]byte, 16)) {
+		return 0, 0, false
+	}
+	return binary.LittleEndian.Uint64(e[gptEntryFirstLBAOffset:]), binary.LittleEndian.Uint64(e[gptEntryLastLBAOffset:]), true
+}
+
+// lastPartition returns the number (index + 1) of the partition that ends
+// last on the disk, 0 when there is none.
+func (t *gptTable) lastPartition() int {
+	var number int
+	var end uint64
+	for i := 0; i < int(t.entriesCount()); i++ {
+		if _, last, ok := t.partitionLBAs(i); ok && last >= end {
+			number, end = i+1, last
+		}
+	}
+	return number
+}
+
+func (t *gptTable) headerCRC() uint32 {
+	h := bytes.Clone(t.header)
+	binary.LittleEndian.PutUint32(h[gptHeaderCRCOffset:], 0)
+	return crc32.ChecksumIEEE(h)
+}
+
+// grow relocates the backup GPT to the end of disk, which is diskSize bytes
+// long, and extends the last partition up to it. It returns the number of the
+// grown partition and whether anything changed.
+func (t *gptTable) grow(disk gptDisk, diskSize int64) (int, bool, error) {
+	number := t.lastPartition()
+	if number == 0 {
+		return 0, false, fmt.Errorf("the GPT has no partitions")
+	}
+
+	entriesSectors := (int64(len(t.entries)) + t.SectorSize - 1) / t.SectorSize
+	alternateLBA := uint64(diskSize/t.SectorSize - 1)
+	backupEntriesLBA := alternateLBA - uint64(entriesSectors)
+	lastUsableLBA := backupEntriesLBA - 1
+
+	oldAlternateLBA := t.u64(gptAlternateLBAOffset)
+	if lastUsableLBA < t.u64(gptLastUsableLBAOffset) {
+		return 0, false, fmt.Errorf("the disk (%d bytes) is smaller than its GPT", diskSize)
+	}
+	_, last, _ := t.partitionLBAs(number - 1)
+	if last > lastUsableLBA {
+		return 0, false, fmt.Errorf("partition %d ends after the last usable sector of the disk", number)
+	}
+	if oldAlternateLBA == alternateLBA && last == lastUsableLBA {
+		return number, false, nil
+	}
+
+	binary.LittleEndian.PutUint64(t.entry(number - 1)[gptEntryLastLBAOffset:], lastUsableLBA)
+	binary.LittleEndian.PutUint64(t.header[gptAlternateLBAOffset:], alternateLBA)
+	binary.LittleEndian.PutUint64(t.header[gptLastUsableLBAOffset:], lastUsableLBA)
+	binary.LittleEndian.PutUint32(t.header[gptEntriesCRCOffset:], crc32.ChecksumIEEE(t.entries))
+	binary.LittleEndian.PutUint32(t.header[gptHeaderCRCOffset:], t.headerCRC())
+
+	backup := &gptTable{SectorSize: t.SectorSize, header: bytes.Clone(t.header), entries: t.entries}
+	binary.LittleEndian.PutUint64(backup.header[gptMyLBAOffset:], alternateLBA)
+	binary.LittleEndian.PutUint64(backup.header[gptAlternateLBAOffset:], t.u64(gptMyLBAOffset))
+	binary.LittleEndian.PutUint64(backup.header[gptEntriesLBAOffset:], backupEntriesLBA)
+	binary.LittleEndian.PutUint32(backup.header[gptHeaderCRCOffset:], backup.headerCRC())
+
+	// Write the backup first, so that a valid table remains if the primary
+	// write is interrupted.
+	type write struct {
+		data []byte
+		lba  uint64
+	}
+	writes := []write{
+		{backup.entries, backupEntriesLBA},
+		{backup.sector(backup.header), alternateLBA},
+		{t.entries, t.u64(gptEntriesLBAOffset)},
+		{t.sector(t.header), t.u64(gptMyLBAOffset)},
+	}
+	if oldAlternateLBA != alternateLBA {
+		// the old backup header is now in the grown partition
+		writes = append(writes, write{make([]byte, t.SectorSize), oldAlternateLBA})
+	}
+	for _, w := range writes {
+		if _, err := disk.WriteAt(w.data, int64(w.lba)*t.SectorSize); err != nil {
+			return 0, false, fmt.Errorf("error writing GPT: %v", err)
+		}
+	}
+	if err := t.updateProtectiveMBR(disk, uint64(diskSize/t.SectorSize)); err != nil {
+		return 0, false, err
+	}
+	return number, true, nil
+}
+
+// sector pads data to a full sector.
+func (t *gptTable) sector(data []byte) []byte {
+	s := make([]byte, t.SectorSize)
+	copy(s, data)
+	return s
+}
+
+// updateProtectiveMBR sets the size of the protective partition of the MBR to
+// cover the disk, as far as the 32 bit field allows.
+func (t *gptTable) updateProtectiveMBR(disk gptDisk, sectors uint64) error {
+	record := make([]byte, 16)
+	if _, err := disk.ReadAt(record, mbrPartitionRecordOffset); err != nil {
+		return fmt.Errorf("error reading protective MBR: %v", err)
+	}
+	if record[4] != mbrProtectiveType {
+		// hybrid MBRs are left alone
+		return nil
+	}
+	size := sectors - 1
+	if size > 0xFFFFFFFF {
+		size = 0xFFFFFFFF
+	}
+	binary.LittleEndian.PutUint32(record[12:], uint32(size))
+	if _, err := disk.WriteAt(record, mbrPartitionRecordOffset); err != nil {
+		return fmt.Errorf("error writing protective MBR: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testGPTPartitionType is the Linux filesystem partition type GUID.
+var testGPTPartitionType = []byte{
+	0xaf, 0x3d, 0xc6, 0x0f, 0x83, 0x84, 0x72, 0x47, 0x8e, 0x79, 0x3d, 0x69, 0xd8, 0x47, 0x7d, 0xe4,
+}
+
+// writeTestGPTImage creates an image file of size bytes at path with a
+// protective MBR and primary and backup GPTs holding partitions, given as
+// first and last sectors.
+func writeTestGPTImage(t *testing.T, path string, sectorSize, size int64, partitions [][2]uint64) {
+	t.Helper()
+
+	const entriesCount, entrySize = 128, 128
+	entries := make([]byte, entriesCount*entrySize)
+	for i, p := range partitions {
+		e := entries[i*entrySize:]
+		copy(e, testGPTPartitionType)
+		e[16] = byte(i + 1) // unique GUID
+		binary.LittleEndian.PutUint64(e[gptEntryFirstLBAOffset:], p[0])
+		binary.LittleEndian.PutUint64(e[gptEntryLastLBAOffset:], p[1])
+	}
+
+	sectors := uint64(size / sectorSize)
+	entriesSectors := uint64(len(entries)) / uint64(sectorSize)
+	if entriesSectors == 0 {
+		entriesSectors = 1
+	}
+	header := func(myLBA, alternateLBA, entriesLBA uint64) []byte {
+		h := make([]byte, sectorSize)
+		copy(h, gptSignature)
+		binary.LittleEndian.PutUint32(h[8:], 0x00010000)
+		binary.LittleEndian.PutUint32(h[gptHeaderSizeOffset:], gptMinHeaderSize)
+		binary.LittleEndian.PutUint64(h[gptMyLBAOffset:], myLBA)
+		binary.LittleEndian.PutUint64(h[gptAlternateLBAOffset:], alternateLBA)
+		binary.LittleEndian.PutUint64(h[gptFirstUsableLBAOffset:], 2+entriesSectors)
+		binary.LittleEndian.PutUint64(h[gptLastUsableLBAOffset:], sectors-2-entriesSectors)
+		h[56] = 0x42 // disk GUID
+		binary.LittleEndian.PutUint64(h[gptEntriesLBAOffset:], entriesLBA)
+		binary.LittleEndian.PutUint32(h[gptEntriesCountOffset:], entriesCount)
+		binary.LittleEndian.PutUint32(h[gptEntrySizeOffset:], entrySize)
+		binary.LittleEndian.PutUint32(h[gptEntriesCRCOffset:], crc32.ChecksumIEEE(entries))
+		binary.LittleEndian.PutUint32(h[gptHeaderCRCOffset:], crc32.ChecksumIEEE(h[:gptMinHeaderSize]))
+		return h
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+
+	mbr := make([]byte, 512)
+	record := mbr[mbrPartitionRecordOffset:]
+	record[4] = mbrProtectiveType
+	binary.LittleEndian.PutUint32(record[8:], 1)
+	binary.LittleEndian.PutUint32(record[12:], uint32(sectors-1))
+	mbr[510], mbr[511] = 0x55, 0xaa
+
+	for _, w := range []struct {
+		data []byte
+		lba  uint64
+	}{
+		{mbr, 0},
+		{header(1, sectors-1, 2), 1},
+		{entries, 2},
+		{entries, sectors - 1 - entriesSectors},
+		{header(sectors-1, 1, sectors-1-entriesSectors), sectors - 1},
+	} {
+		if _, err := f.WriteAt(w.data, int64(w.lba)*sectorSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTestGPTBackup reads the backup GPT header at the last sector of the
+// image and checks that it and the entries it points to are valid.
+func readTestGPTBackup(t *testing.T, f *os.File, sectorSize, size int64) *gptTable {
+	t.Helper()
+
+	h := make([]byte, gptMinHeaderSize)
+	if _, err := f.ReadAt(h, size-sectorSize); err != nil {
+		t.Fatal(err)
+	}
+	if string(h[:len(gptSignature)]) != gptSignature {
+		t.Fatalf("no backup GPT header at the end of the disk")
+	}
+	backup := &gptTable{SectorSize: sectorSize, header: h}
+	if backup.headerCRC() != binary.LittleEndian.Uint32(h[gptHeaderCRCOffset:]) {
+		t.Fatalf("backup GPT header checksum mismatch")
+	}
+	backup.entries = make([]byte, int(backup.entriesCount()*backup.entrySize()))
+	if _, err := f.ReadAt(backup.entries, int64(backup.u64(gptEntriesLBAOffset))*sectorSize); err != nil {
+		t.Fatal(err)
+	}
+	if crc32.ChecksumIEEE(backup.entries) != binary.LittleEndian.Uint32(h[gptEntriesCRCOffset:]) {
+		t.Fatalf("backup GPT entries checksum mismatch")
+	}
+	return backup
+}
+
+func Test_gptTable_grow(t *testing.T) {
+	const MiB = 1 << 20
+	for _, sectorSize := range []int64{512, 4096} {
+		t.Run(fmt.Sprintf("%d byte sectors", sectorSize), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "disk.img")
+			oldSize, newSize := int64(8*MiB), int64(20*MiB)
+			oldSectors, newSectors := uint64(oldSize/sectorSize), uint64(newSize/sectorSize)
+			entriesSectors := uint64(128*128) / uint64(sectorSize)
+			oldLastUsable := oldSectors - 2 - entriesSectors
+
+			writeTestGPTImage(t, path, sectorSize, oldSize, [][2]uint64{
+				{uint64(MiB / sectorSize), uint64(2*MiB/sectorSize) - 1},
+				{uint64(2 * MiB / sectorSize), oldLastUsable},
+			})
+			if err := os.Truncate(path, newSize); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			gpt, err := readGPT(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gpt.SectorSize != sectorSize {
+				t.Errorf("SectorSize = %d, want %d", gpt.SectorSize, sectorSize)
+			}
+			number, grown, err := gpt.grow(f, newSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if number != 2 || !grown {
+				t.Errorf("grow() = %d, %v, want 2, true", number, grown)
+			}
+
+			newLastUsable := newSectors - 2 - entriesSectors
+			primary, err := readGPT(f)
+			if err != nil {
+				t.Fatalf("reading the grown GPT: %v", err)
+			}
+			backup := readTestGPTBackup(t, f, sectorSize, newSize)
+			for name, table := range map[string]*gptTable{"primary": primary, "backup": backup} {
+				if got := table.u64(gptLastUsableLBAOffset); got != newLastUsable {
+					t.Errorf("%s last usable LBA = %d, want %d", name, got, newLastUsable)
+				}
+				first, last, _ := table.partitionLBAs(1)
+				if first != uint64(2*MiB/sectorSize) || last != newLastUsable {
+					t.Errorf("%s partition 2 = %d-%d, want %d-%d", name, first, last, 2*MiB/sectorSize, newLastUsable)
+				}
+				if _, last, _ := table.partitionLBAs(0); last != uint64(2*MiB/sectorSize)-1 {
+					t.Errorf("%s partition 1 was changed", name)
+				}
+			}
+			if got := primary.u64(gptAlternateLBAOffset); got != newSectors-1 {
+				t.Errorf("primary alternate LBA = %d, want %d", got, newSectors-1)
+			}
+			if got := backup.u64(gptMyLBAOffset); got != newSectors-1 {
+				t.Errorf("backup LBA = %d, want %d", got, newSectors-1)
+			}
+			if got := backup.u64(gptAlternateLBAOffset); got != 1 {
+				t.Errorf("backup alternate LBA = %d, want 1", got)
+			}
+			if got := backup.u64(gptEntriesLBAOffset); got != newSectors-1-entriesSectors {
+				t.Errorf("backup entries LBA = %d, want %d", got, newSectors-1-entriesSectors)
+			}
+
+			oldBackup := make([]byte, sectorSize)
+			if _, err := f.ReadAt(oldBackup, int64(oldSectors-1)*sectorSize); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(oldBackup), gptSignature) {
+				t.Errorf("the old backup GPT header was not cleared")
+			}
+
+			record := make([]byte, 16)
+			if _, err := f.ReadAt(record, mbrPartitionRecordOffset); err != nil {
+				t.Fatal(err)
+			}
+			if got := binary.LittleEndian.Uint32(record[12:]); uint64(got) != newSectors-1 {
+				t.Errorf("protective MBR size = %d, want %d", got, newSectors-1)
+			}
+
+			// growing again is a no-op
+			if _, grown, err := primary.grow(f, newSize); err != nil || grown {
+				t.Errorf("second grow() = %v, %v, want false, nil", grown, err)
+			}
+
+			if sectorSize == 512 {
+				checkTestGPTImageWithPartx(t, path, newLastUsable)
+			}
+		})
+	}
+}
+
+// checkTestGPTImageWithPartx checks that libblkid reads the grown table, when
+// partx is installed.
+func checkTestGPTImageWithPartx(t *testing.T, path string, lastUsable uint64) {
+	partx, err := exec.LookPath("partx")
+	if err != nil {
+		t.Log("partx is not installed, not checking the image with it")
+		return
+	}
+	out, err := exec.Command(partx, "--show", "--noheadings", "--output", "NR,END", path).CombinedOutput()
+	if err != nil {
+		t.Fatalf("partx: %v: %s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if got := strings.Fields(lines[len(lines)-1]); len(got) != 2 || got[0] != "2" || got[1] != strconv.FormatUint(lastUsable, 10) {
+		t.Errorf("partx --show = %q, want partition 2 to end at %d", out, lastUsable)
+	}
+}
+
+func Test_readGPT_errors(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "empty.img")
+	if err := os.WriteFile(empty, make([]byte, 1<<20), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := readGPT(f); err == nil {
+		t.Error("readGPT() of an empty disk did not fail")
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.img")
+	writeTestGPTImage(t, corrupt, 512, 4<<20, [][2]uint64{{2048, 4095}})
+	g, err := os.OpenFile(corrupt, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if _, err := g.WriteAt([]byte{0x12}, 2*512+gptEntryLastLBAOffset); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readGPT(g); err == nil {
+		t.Error("readGPT() with corrupt partition entries did not fail")
+	}
+}
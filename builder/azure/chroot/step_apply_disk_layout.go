@@ -0,0 +1,205 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+var _ multistep.Step = &StepApplyDiskLayout{}
+
+// diskLayoutFileSystem is a filesystem created by a DiskLayout, on a partition
+// or on a logical volume.
+type diskLayoutFileSystem struct {
+	// Name of the partition or logical volume.
+	Name       string
+	Device     string
+	FileSystem string
+	Label      string
+	MountPoint string
+	// Partition is the number of the partition, empty for logical volumes.
+	Partition string
+}
+
+// StepApplyDiskLayout partitions the empty disk attached at "device", creates
+// the LVM volumes and the filesystems of the layout and records their UUIDs in
+// the generated data. When the root filesystem is on a logical volume, it
+// replaces "device" with it, like StepSetupLVM does. The other filesystems
+// are put in the state bag for StepMountDiskLayout.
+type StepApplyDiskLayout struct {
+	Layout        DiskLayout
+	GeneratedData *packerbuilderdata.GeneratedData
+
+	// volumeGroups that were created by this step (for cleanup).
+	volumeGroups []string
+
+	run func(multistep.StateBag, string) (string, error)
+}
+
+func NewStepApplyDiskLayout(step *StepApplyDiskLayout) *StepApplyDiskLayout {
+	step.run = runWrappedCommand
+	return step
+}
+
+func (s *StepApplyDiskLayout) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	device := state.Get("device").(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error applying disk_layout: %v", err)
+		log.Printf("StepApplyDiskLayout.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("Partitioning %s", device))
+	if _, err := s.run(state, "sgdisk "+strings.Join(s.Layout.sgdiskArgs(device), " ")); err != nil {
+		return halt(err)
+	}
+	// make sure the partition device nodes exist before using them
+	for _, command := range []string{"partprobe " + device, "udevadm settle"} {
+		if _, err := s.run(state, command); err != nil {
+			return halt(err)
+		}
+	}
+
+	for i, part := range s.Layout.Partitions {
+		if part.partitionType() != partitionTypeLVM {
+			continue
+		}
+		pv := partitionDevice(device, fmt.Sprint(i+1))
+		ui.Say(fmt.Sprintf("LVM: creating volume group %s on %s", part.VolumeGroup, pv))
+		if _, err := s.run(state, "pvcreate -ff -y "+pv); err != nil {
+			return halt(err)
+		}
+		if _, err := s.run(state, fmt.Sprintf("vgcreate %s %s", part.VolumeGroup, pv)); err != nil {
+			return halt(err)
+		}
+		s.volumeGroups = append(s.volumeGroups, part.VolumeGroup)
+		state.Put("lvm_cleanup", s)
+
+		for _, lv := range part.LogicalVolumes {
+			size := "-l 100%FREE"
+			if lv.SizeMB > 0 {
+				size = fmt.Sprintf("-L %dM", lv.SizeMB)
+			}
+			if _, err := s.run(state, fmt.Sprintf("lvcreate -y -n %s %s %s", lv.Name, size, part.VolumeGroup)); err != nil {
+				return halt(err)
+			}
+		}
+	}
+
+	fss := s.Layout.fileSystems(device)
+	for _, fs := range fss {
+		ui.Say(fmt.Sprintf("Creating %s filesystem on %s", fs.FileSystem, fs.Device))
+		if _, err := s.run(state, mkfsCommand(fs)); err != nil {
+			return halt(err)
+		}
+	}
+
+	for i, part := range s.Layout.Partitions {
+		partUUID, err := s.blkid(state, partitionDevice(device, fmt.Sprint(i+1)), "PARTUUID")
+		if err != nil {
+			return halt(err)
+		}
+		s.GeneratedData.Put("PARTUUID_"+part.Name, partUUID)
+	}
+	for _, fs := range fss {
+		uuid, err := s.blkid(state, fs.Device, "UUID")
+		if err != nil {
+			return halt(err)
+		}
+		s.GeneratedData.Put("UUID_"+fs.Name, uuid)
+		ui.Say(fmt.Sprintf(" -> %s: UUID=%s", fs.Name, uuid))
+	}
+
+	root, ok := rootFileSystem(fss)
+	if !ok {
+		return halt(fmt.Errorf("no filesystem is mounted at /"))
+	}
+	if root.Partition == "" {
+		state.Put("device", root.Device)
+		state.Put("lvm_active", true)
+	}
+	state.Put(stateBagKey_DiskLayoutMounts, mountOrder(fss))
+	return multistep.ActionContinue
+}
+
+// blkid returns the value of tag (UUID, PARTUUID...) of device.
+func (s *StepApplyDiskLayout) blkid(state multistep.StateBag, device, tag string) (string, error) {
+	out, err := s.run(state, fmt.Sprintf("blkid -o value -s %s %s", tag, device))
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(out)
+	if value == "" {
+		return "", fmt.Errorf("blkid did not return the %s of %s", tag, device)
+	}
+	return value, nil
+}
+
+func (s *StepApplyDiskLayout) Cleanup(state multistep.StateBag) {
+	if err := s.CleanupFunc(state); err != nil {
+		ui := state.Get("ui").(packersdk.Ui)
+		ui.Error(err.Error())
+	}
+}
+
+// CleanupFunc deactivates the volume groups created by this step.
+func (s *StepApplyDiskLayout) CleanupFunc(state multistep.StateBag) error {
+	if len(s.volumeGroups) == 0 {
+		return nil
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	ui.Say(fmt.Sprintf("LVM: deactivating volume groups: %s", strings.Join(s.volumeGroups, ", ")))
+	if _, err := s.run(state, "vgchange -an "+strings.Join(s.volumeGroups, " ")); err != nil {
+		return fmt.Errorf("LVM: %v", err)
+	}
+	s.volumeGroups = nil
+	return nil
+}
+
+// runWrappedCommand runs command through the command wrapper of the build
+// and returns its standard output.
+func runWrappedCommand(state multistep.StateBag, command string) (string, error) {
+	wrappedCommand := state.Get("wrappedCommand").(common.CommandWrapper)
+	cmdString, err := wrappedCommand(command)
+	if err != nil {
+		return "", fmt.Errorf("error creating command %q: %s", command, err)
+	}
+
+	log.Printf("[DEBUG] running command %s", cmdString)
+	var stdout, stderr bytes.Buffer
+	cmd := common.ShellCommand(cmdString)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v (stderr: %s)", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// shellQuote quotes s for sh when it contains characters the shell would
+// interpret, for arguments taken from the image.
+func shellQuote(s string) string {
+	if shellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
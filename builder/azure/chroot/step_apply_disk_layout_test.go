@@ -0,0 +1,81 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+func TestStepApplyDiskLayout_Run(t *testing.T) {
+	var commands []string
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("device", "/dev/sdc")
+
+	s := &StepApplyDiskLayout{
+		Layout:        testDiskLayout,
+		GeneratedData: &packerbuilderdata.GeneratedData{State: state},
+		run: func(_ multistep.StateBag, command string) (string, error) {
+			commands = append(commands, command)
+			if strings.HasPrefix(command, "blkid") {
+				fields := strings.Fields(command)
+				return fields[len(fields)-1] + "-" + fields[len(fields)-2] + "\n", nil
+			}
+			return "", nil
+		},
+	}
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+
+	wantCommands := []string{
+		"sgdisk " + strings.Join(testDiskLayout.sgdiskArgs("/dev/sdc"), " "),
+		"partprobe /dev/sdc",
+		"udevadm settle",
+		"pvcreate -ff -y /dev/sdc4",
+		"vgcreate rootvg /dev/sdc4",
+		"lvcreate -y -n root -L 8192M rootvg",
+		"lvcreate -y -n var -l 100%FREE rootvg",
+		"mkfs.vfat -F 32 -n EFI /dev/sdc1",
+		"mkfs.ext4 -F -L boot /dev/sdc3",
+		"mkfs.xfs -f /dev/rootvg/root",
+		"mkfs.xfs -f /dev/rootvg/var",
+	}
+	if len(commands) < len(wantCommands) || !reflect.DeepEqual(commands[:len(wantCommands)], wantCommands) {
+		t.Errorf("commands = %v, want them to start with %v", commands, wantCommands)
+	}
+
+	generated := state.Get("generated_data").(map[string]interface{})
+	if got := generated["UUID_root"]; got != "/dev/rootvg/root-UUID" {
+		t.Errorf("UUID_root = %v", got)
+	}
+	if got := generated["PARTUUID_esp"]; got != "/dev/sdc1-PARTUUID" {
+		t.Errorf("PARTUUID_esp = %v", got)
+	}
+	if got := state.Get("device"); got != "/dev/rootvg/root" {
+		t.Errorf("device = %v, want the root logical volume", got)
+	}
+	if _, ok := state.GetOk("lvm_active"); !ok {
+		t.Error("lvm_active is not set")
+	}
+	if got := state.Get(stateBagKey_DiskLayoutMounts).([]diskLayoutFileSystem); len(got) != 3 {
+		t.Errorf("mounts = %+v, want 3 mounts", got)
+	}
+
+	commands = nil
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"vgchange -an rootvg"}; !reflect.DeepEqual(commands, want) {
+		t.Errorf("cleanup commands = %v, want %v", commands, want)
+	}
+}
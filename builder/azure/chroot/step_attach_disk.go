@@ -56,6 +56,7 @@ func (s *StepAttachDisk) Run(ctx context.Context, state multistep.StateBag) mult
 	ui.Say(fmt.Sprintf("Disk available at %q", device))
 	s.attached = true
 	state.Put("device", device)
+	state.Put(stateBagKey_DiskDevice, device)
 	state.Put("attach_cleanup", s)
 	return multistep.ActionContinue
 }
@@ -0,0 +1,194 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
+)
+
+var _ multistep.Step = &StepCopyVHD{}
+
+// vhdCopySASDuration is how long the SAS of the OS disk snapshot is valid,
+// the copy has to complete before it expires.
+const vhdCopySASDuration = 24 * time.Hour
+
+// vhdCopyPollInterval is the delay between two checks of the progress of the
+// copy.
+var vhdCopyPollInterval = 10 * time.Second
+
+// StepCopyVHD copies the snapshot of the OS disk to a page blob, which is a
+// fixed VHD, and puts the URL of the blob in the state bag.
+type StepCopyVHD struct {
+	Destination VHDDestination
+
+	grantAccess  func(context.Context, client.AzureClientSet, snapshots.SnapshotId) (string, error)
+	revokeAccess func(context.Context, client.AzureClientSet, snapshots.SnapshotId) error
+}
+
+func NewStepCopyVHD(step *StepCopyVHD) *StepCopyVHD {
+	step.grantAccess = step.grantSnapshotAccess
+	step.revokeAccess = step.revokeSnapshotAccess
+	return step
+}
+
+func (s *StepCopyVHD) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+	snapshotset := state.Get(stateBagKey_Snapshotset).(Diskset)
+
+	errorMessage := func(format string, params ...interface{}) multistep.StepAction {
+		err := fmt.Errorf("StepCopyVHD.Run: error: "+format, params...)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	osSnapshot := snapshotset.OS()
+	snapshotID := snapshots.NewSnapshotID(azcli.SubscriptionID(), osSnapshot.ResourceGroup, osSnapshot.ResourceName.String())
+
+	ui.Say(fmt.Sprintf("Granting read access to snapshot %q", osSnapshot))
+	sas, err := s.grantAccess(ctx, azcli, snapshotID)
+	if err != nil {
+		return errorMessage("could not grant access to snapshot %q: %v", osSnapshot, err)
+	}
+	defer func() {
+		ui.Say(fmt.Sprintf("Revoking read access to snapshot %q", osSnapshot))
+		if err := s.revokeAccess(ctx, azcli, snapshotID); err != nil {
+			log.Printf("StepCopyVHD.Run: error: %+v", err)
+			ui.Error(fmt.Sprintf("error revoking access to snapshot %q: %v", osSnapshot, err))
+		}
+	}()
+
+	blobsClient, err := azcli.BlobsClient(ctx, s.Destination.StorageAccount)
+	if err != nil {
+		return errorMessage("could not create a client for storage account %q: %v", s.Destination.StorageAccount, err)
+	}
+	blobURL, err := url.JoinPath(blobsClient.Client.BaseUri, s.Destination.ContainerName, s.Destination.BlobName)
+	if err != nil {
+		return errorMessage("could not build the URL of the VHD: %v", err)
+	}
+
+	ui.Say(fmt.Sprintf("Copying the OS disk to %q", blobURL))
+	if err := copyBlob(ctx, azcli, blobsClient, s.Destination.ContainerName, s.Destination.BlobName, sas, ui); err != nil {
+		return errorMessage("could not copy the OS disk to %q: %v", blobURL, err)
+	}
+	ui.Say(fmt.Sprintf("VHD created: %q", blobURL))
+
+	state.Put(stateBagKey_VHD, blobURL)
+	return multistep.ActionContinue
+}
+
+// copyBlob starts the copy of the blob at source to blobName in
+// containerName and waits for its completion.
+func copyBlob(ctx context.Context, azcli client.AzureClientSet, blobsClient *blobs.Client, containerName, blobName, source string, ui packersdk.Ui) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	_, err := blobsClient.Copy(pollingContext, containerName, blobName, blobs.CopyInput{CopySource: source})
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	lastReported := -1
+	for {
+		pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+		props, err := blobsClient.GetProperties(pollingContext, containerName, blobName, blobs.GetPropertiesInput{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		switch props.CopyStatus {
+		case blobs.Success:
+			return nil
+		case blobs.Failed, blobs.Aborted:
+			return fmt.Errorf("copy %s: %s", props.CopyStatus, props.CopyStatusDescription)
+		}
+
+		log.Printf("copyBlob: copy status %q, progress %q", props.CopyStatus, props.CopyProgress)
+		if percent, ok := copyPercent(props.CopyProgress); ok && percent/10 > lastReported/10 {
+			ui.Say(fmt.Sprintf(" -> %d%% copied", percent))
+			lastReported = percent
+		}
+
+		select {
+		case <-time.After(vhdCopyPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// copyPercent returns the percentage of a copy from its x-ms-copy-progress,
+// formatted as "<bytes copied>/<total bytes>".
+func copyPercent(progress string) (int, bool) {
+	copied, total, ok := strings.Cut(progress, "/")
+	if !ok {
+		return 0, false
+	}
+	c, err := strconv.ParseInt(copied, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	t, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || t <= 0 {
+		return 0, false
+	}
+	return int(c * 100 / t), true
+}
+
+// accessURIOperation is the final result of the operation granting access
+// to a snapshot. The SDK decodes it as an AccessUri, which lacks the
+// properties wrapper, so the SAS is read from the raw result.
+type accessURIOperation struct {
+	Properties *struct {
+		Output *struct {
+			AccessSAS *string `json:"accessSAS,omitempty"`
+		} `json:"output,omitempty"`
+	} `json:"properties,omitempty"`
+}
+
+func (s *StepCopyVHD) grantSnapshotAccess(ctx context.Context, azcli client.AzureClientSet, id snapshots.SnapshotId) (string, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+
+	result, err := azcli.SnapshotsClient().GrantAccess(pollingContext, id, snapshots.GrantAccessData{
+		Access:            snapshots.AccessLevelRead,
+		DurationInSeconds: int64(vhdCopySASDuration.Seconds()),
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := result.Poller.PollUntilDone(pollingContext); err != nil {
+		return "", fmt.Errorf("polling after GrantAccess: %+v", err)
+	}
+
+	var operation accessURIOperation
+	if err := result.Poller.FinalResult(&operation); err != nil {
+		return "", fmt.Errorf("performing FinalResult: %+v", err)
+	}
+	if operation.Properties == nil || operation.Properties.Output == nil || operation.Properties.Output.AccessSAS == nil {
+		return "", fmt.Errorf("the result of GrantAccess has no SAS")
+	}
+	return *operation.Properties.Output.AccessSAS, nil
+}
+
+func (s *StepCopyVHD) revokeSnapshotAccess(ctx context.Context, azcli client.AzureClientSet, id snapshots.SnapshotId) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	return azcli.SnapshotsClient().RevokeAccessThenPoll(pollingContext, id)
+}
+
+func (*StepCopyVHD) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,162 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
+)
+
+// fakeBlobService accepts a copy of a blob and reports the copy statuses one
+// by one on each request for its properties.
+type fakeBlobService struct {
+	statuses    []string
+	copySource  string
+	description string
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		f.copySource = r.Header.Get("x-ms-copy-source")
+		w.Header().Set("x-ms-copy-id", "copyid")
+		w.Header().Set("x-ms-copy-status", "pending")
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodHead:
+		status := f.statuses[0]
+		if len(f.statuses) > 1 {
+			f.statuses = f.statuses[1:]
+		}
+		w.Header().Set("x-ms-copy-status", status)
+		w.Header().Set("x-ms-copy-progress", "512/1024")
+		w.Header().Set("x-ms-copy-status-description", f.description)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestStepCopyVHD_Run(t *testing.T) {
+	vhdCopyPollInterval = 0
+
+	tests := []struct {
+		name       string
+		statuses   []string
+		grantErr   error
+		want       multistep.StepAction
+		wantRevoke bool
+	}{
+		{
+			name:       "copy succeeds",
+			statuses:   []string{"pending", "pending", "success"},
+			want:       multistep.ActionContinue,
+			wantRevoke: true,
+		},
+		{
+			name:       "copy fails",
+			statuses:   []string{"pending", "failed"},
+			want:       multistep.ActionHalt,
+			wantRevoke: true,
+		},
+		{
+			name:     "grant access fails",
+			grantErr: errors.New("denied"),
+			want:     multistep.ActionHalt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeBlobService{statuses: tt.statuses, description: "500 InternalError"}
+			server := httptest.NewServer(service)
+			defer server.Close()
+
+			blobsClient, err := blobs.NewWithBaseUri(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var revoked bool
+			s := &StepCopyVHD{
+				Destination: VHDDestination{
+					StorageAccount: "mystorageaccount",
+					ContainerName:  "vhds",
+					BlobName:       "image.vhd",
+				},
+				grantAccess: func(_ context.Context, _ client.AzureClientSet, id snapshots.SnapshotId) (string, error) {
+					if id.SnapshotName != "osdisk-snapshot" {
+						t.Errorf("unexpected snapshot %q", id.SnapshotName)
+					}
+					return "https://sas", tt.grantErr
+				},
+				revokeAccess: func(context.Context, client.AzureClientSet, snapshots.SnapshotId) error {
+					revoked = true
+					return nil
+				},
+			}
+
+			ui, getErr := testUI()
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{
+				SubscriptionIDMock:  "subid1",
+				BlobsClientMock:     blobsClient,
+				PollingDurationMock: time.Minute,
+			})
+			state.Put("ui", ui)
+			state.Put(stateBagKey_Snapshotset, diskset(
+				"/subscriptions/subid1/resourceGroups/rg/providers/Microsoft.Compute/snapshots/osdisk-snapshot"))
+
+			if got := s.Run(context.TODO(), state); got != tt.want {
+				t.Fatalf("StepCopyVHD.Run() = %v, want %v: %s", got, tt.want, getErr())
+			}
+			if revoked != tt.wantRevoke {
+				t.Errorf("revoked = %v, want %v", revoked, tt.wantRevoke)
+			}
+			if tt.want != multistep.ActionContinue {
+				if tt.grantErr == nil && !strings.Contains(getErr(), "500 InternalError") {
+					t.Errorf("expected the copy status description in the error, got %q", getErr())
+				}
+				return
+			}
+
+			if service.copySource != "https://sas" {
+				t.Errorf("copy source = %q, want the snapshot SAS", service.copySource)
+			}
+			want := server.URL + "/vhds/image.vhd"
+			if got := state.Get(stateBagKey_VHD); got != want {
+				t.Errorf("state %q = %v, want %q", stateBagKey_VHD, got, want)
+			}
+		})
+	}
+}
+
+func Test_copyPercent(t *testing.T) {
+	tests := []struct {
+		progress string
+		want     int
+		wantOK   bool
+	}{
+		{"0/1024", 0, true},
+		{"512/1024", 50, true},
+		{"1024/1024", 100, true},
+		{"", 0, false},
+		{"1/0", 0, false},
+		{"a/b", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := copyPercent(tt.progress)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("copyPercent(%q) = %v, %v, want %v, %v", tt.progress, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
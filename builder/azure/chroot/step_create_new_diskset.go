@@ -33,8 +33,11 @@ type StepCreateNewDiskset struct {
 
 	HyperVGeneration string // For OS disk
 
-	// Copy another disk
+	// Copy another disk or a snapshot
 	SourceOSDiskResourceID string
+	// Copy or import the source resolved by the verify step of a managed image
+	// or VHD source
+	SourceOSDisk *osDiskSource
 
 	// Extract from platform image
 	SourcePlatformImage *client.PlatformImage
@@ -52,6 +55,21 @@ type StepCreateNewDiskset struct {
 	create     func(context.Context, client.AzureClientSet, commonids.ManagedDiskId, disks.Disk) error
 }
 
+// osDiskSource is the source of the OS disk for the sources that are only
+// known when the build runs: the managed disk or snapshot to copy, the VHD
+// blob to import along with its storage account, or the managed image to
+// publish to a temporary gallery along with the image version created from it.
+type osDiskSource struct {
+	ResourceID       string
+	BlobURI          string
+	StorageAccountID string
+
+	ManagedImageID        string
+	HyperVGeneration      string
+	OSState               string
+	GalleryImageVersionID string
+}
+
 func NewStepCreateNewDiskset(step *StepCreateNewDiskset) *StepCreateNewDiskset {
 	step.getVersion = step.getSharedImageGalleryVersion
 	step.create = step.createDiskset
@@ -180,6 +198,18 @@ func (s StepCreateNewDiskset) getOSDiskDefinition(subscriptionID string) disks.D
 	case s.SourceOSDiskResourceID != "":
 		disk.Properties.CreationData.CreateOption = disks.DiskCreateOptionCopy
 		disk.Properties.CreationData.SourceResourceId = &s.SourceOSDiskResourceID
+	case s.SourceOSDisk != nil && s.SourceOSDisk.GalleryImageVersionID != "":
+		disk.Properties.CreationData.CreateOption = disks.DiskCreateOptionFromImage
+		disk.Properties.CreationData.GalleryImageReference = &disks.ImageDiskReference{
+			Id: &s.SourceOSDisk.GalleryImageVersionID,
+		}
+	case s.SourceOSDisk != nil && s.SourceOSDisk.BlobURI != "":
+		disk.Properties.CreationData.CreateOption = disks.DiskCreateOptionImport
+		disk.Properties.CreationData.SourceUri = &s.SourceOSDisk.BlobURI
+		disk.Properties.CreationData.StorageAccountId = &s.SourceOSDisk.StorageAccountID
+	case s.SourceOSDisk != nil:
+		disk.Properties.CreationData.CreateOption = disks.DiskCreateOptionCopy
+		disk.Properties.CreationData.SourceResourceId = &s.SourceOSDisk.ResourceID
 	case s.SourceImageResourceID != "":
 		disk.Properties.CreationData.CreateOption = disks.DiskCreateOptionFromImage
 		disk.Properties.CreationData.GalleryImageReference = &disks.ImageDiskReference{
@@ -246,6 +246,99 @@ func TestStepCreateNewDisk_Run(t *testing.T) {
 			want:          multistep.ActionContinue,
 			verifyDiskset: &Diskset{-1: resource("/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName")},
 		},
+		{
+			name: "from VHD",
+			fields: StepCreateNewDiskset{
+				OSDiskID:                 "/subscriptions/SubscriptionID/resourcegroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName",
+				OSDiskStorageAccountType: string(disks.DiskStorageAccountTypesStandardLRS),
+				HyperVGeneration:         string(disks.HyperVGenerationVOne),
+				Location:                 "westus",
+				SourceOSDisk: &osDiskSource{
+					BlobURI:          "https://account.blob.core.windows.net/vhds/source.vhd",
+					StorageAccountID: "/subscriptions/SubscriptionID/resourceGroups/StorageResourceGroup/providers/Microsoft.Storage/storageAccounts/account",
+				},
+			},
+			disks: []disks.Disk{
+				{
+					Location: "westus",
+					Sku: &disks.DiskSku{
+						Name: &standardLRS,
+					},
+					Properties: &disks.DiskProperties{
+						HyperVGeneration: &hyperVGeneration,
+						OsType:           &osType,
+						CreationData: disks.CreationData{
+							CreateOption:     disks.DiskCreateOptionImport,
+							SourceUri:        common.StringPtr("https://account.blob.core.windows.net/vhds/source.vhd"),
+							StorageAccountId: common.StringPtr("/subscriptions/SubscriptionID/resourceGroups/StorageResourceGroup/providers/Microsoft.Storage/storageAccounts/account"),
+						},
+					},
+				},
+			},
+			want:          multistep.ActionContinue,
+			verifyDiskset: &Diskset{-1: resource("/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName")},
+		},
+		{
+			name: "from managed image published to a temporary gallery",
+			fields: StepCreateNewDiskset{
+				OSDiskID:                 "/subscriptions/SubscriptionID/resourcegroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName",
+				OSDiskStorageAccountType: string(disks.DiskStorageAccountTypesStandardLRS),
+				HyperVGeneration:         string(disks.HyperVGenerationVOne),
+				Location:                 "westus",
+				SourceOSDisk: &osDiskSource{
+					ManagedImageID:        "/subscriptions/SubscriptionID/resourceGroups/ImageResourceGroup/providers/Microsoft.Compute/images/image1",
+					GalleryImageVersionID: "/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/galleries/PackerTemp_gallery/images/PackerTemp-source/versions/1.0.0",
+				},
+			},
+			disks: []disks.Disk{
+				{
+					Location: "westus",
+					Sku: &disks.DiskSku{
+						Name: &standardLRS,
+					},
+					Properties: &disks.DiskProperties{
+						HyperVGeneration: &hyperVGeneration,
+						OsType:           &osType,
+						CreationData: disks.CreationData{
+							CreateOption: disks.DiskCreateOptionFromImage,
+							GalleryImageReference: &disks.ImageDiskReference{
+								Id: common.StringPtr("/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/galleries/PackerTemp_gallery/images/PackerTemp-source/versions/1.0.0"),
+							},
+						},
+					},
+				},
+			},
+			want:          multistep.ActionContinue,
+			verifyDiskset: &Diskset{-1: resource("/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName")},
+		},
+		{
+			name: "from managed image source",
+			fields: StepCreateNewDiskset{
+				OSDiskID:                 "/subscriptions/SubscriptionID/resourcegroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName",
+				OSDiskStorageAccountType: string(disks.DiskStorageAccountTypesStandardLRS),
+				HyperVGeneration:         string(disks.HyperVGenerationVOne),
+				Location:                 "westus",
+				SourceOSDisk:             &osDiskSource{ResourceID: "ImageSourceSnapshot"},
+			},
+			disks: []disks.Disk{
+				{
+					Location: "westus",
+					Sku: &disks.DiskSku{
+						Name: &standardLRS,
+					},
+					Properties: &disks.DiskProperties{
+						HyperVGeneration: &hyperVGeneration,
+						OsType:           &osType,
+						CreationData: disks.CreationData{
+							CreateOption:     disks.DiskCreateOptionCopy,
+							SourceResourceId: common.StringPtr("ImageSourceSnapshot"),
+						},
+					},
+				},
+			},
+			want:          multistep.ActionContinue,
+			verifyDiskset: &Diskset{-1: resource("/subscriptions/SubscriptionID/resourceGroups/ResourceGroupName/providers/Microsoft.Compute/disks/TemporaryOSDiskName")},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -260,6 +353,7 @@ func TestStepCreateNewDisk_Run(t *testing.T) {
 				Location:                   tt.fields.Location,
 				Zone:                       tt.fields.Zone,
 				SourceOSDiskResourceID:     tt.fields.SourceOSDiskResourceID,
+				SourceOSDisk:               tt.fields.SourceOSDisk,
 				SourceImageResourceID:      tt.fields.SourceImageResourceID,
 				SourcePlatformImage:        tt.fields.SourcePlatformImage,
 				getVersion: func(ctx context.Context, acs client.AzureClientSet, id galleryimageversions.ImageVersionId) (*galleryimageversions.GalleryImageVersion, error) {
@@ -79,6 +79,10 @@ func (s *StepCreateSnapshotset) Run(ctx context.Context, state multistep.StateBa
 				Incremental: common.BoolPtr(false),
 			},
 		}
+		if arch, ok := state.GetOk(stateBagKey_ImageArchitecture); ok && lun == -1 {
+			architecture := snapshots.Architecture(arch.(string))
+			snapshot.Properties.SupportedCapabilities = &snapshots.SupportedCapabilities{Architecture: &architecture}
+		}
 		snapshotSDKID := snapshots.NewSnapshotID(azcli.SubscriptionID(), ssr.ResourceGroup, ssr.ResourceName.String())
 		err = s.create(ctx, azcli, snapshotSDKID, snapshot)
 		if err != nil {
@@ -18,6 +18,7 @@ import (
 )
 
 func TestStepCreateSnapshot_Run(t *testing.T) {
+	arm64 := snapshots.ArchitectureArmSixFour
 	type fields struct {
 		OSDiskSnapshotID         string
 		DataDiskSnapshotIDPrefix string
@@ -27,6 +28,7 @@ func TestStepCreateSnapshot_Run(t *testing.T) {
 		name              string
 		fields            fields
 		diskset           Diskset
+		architecture      string
 		want              multistep.StepAction
 		wantSnapshotset   Diskset
 		expectedSnapshots []snapshots.Snapshot
@@ -113,6 +115,43 @@ func TestStepCreateSnapshot_Run(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Arm64 image",
+			fields: fields{
+				OSDiskSnapshotID:         "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/snapshots/osdisk-snap",
+				DataDiskSnapshotIDPrefix: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/snapshots/datadisk-snap",
+				Location:                 "region1",
+			},
+			diskset: diskset(
+				"/subscriptions/12345/resourceGroups/group1/providers/Microsoft.Compute/disks/osdisk",
+				"/subscriptions/12345/resourceGroups/group1/providers/Microsoft.Compute/disks/datadisk1"),
+			architecture: "Arm64",
+			expectedSnapshots: []snapshots.Snapshot{
+				{
+					Location: "region1",
+					Properties: &snapshots.SnapshotProperties{
+						CreationData: snapshots.CreationData{
+							SourceResourceId: common.StringPtr("/subscriptions/12345/resourceGroups/group1/providers/Microsoft.Compute/disks/osdisk"),
+							CreateOption:     "Copy",
+						},
+						Incremental: common.BoolPtr(false),
+						SupportedCapabilities: &snapshots.SupportedCapabilities{
+							Architecture: &arm64,
+						},
+					},
+				},
+				{
+					Location: "region1",
+					Properties: &snapshots.SnapshotProperties{
+						CreationData: snapshots.CreationData{
+							SourceResourceId: common.StringPtr("/subscriptions/12345/resourceGroups/group1/providers/Microsoft.Compute/disks/datadisk1"),
+							CreateOption:     "Copy",
+						},
+						Incremental: common.BoolPtr(false),
+					},
+				},
+			},
+		},
 		{
 			name: "invalid ResourceID",
 			fields: fields{
@@ -128,6 +167,9 @@ func TestStepCreateSnapshot_Run(t *testing.T) {
 		state.Put("azureclient", &client.AzureClientSetMock{})
 		state.Put("ui", packersdk.TestUi(t))
 		state.Put(stateBagKey_Diskset, tt.diskset)
+		if tt.architecture != "" {
+			state.Put(stateBagKey_ImageArchitecture, tt.architecture)
+		}
 
 		t.Run(tt.name, func(t *testing.T) {
 			actualSnapshots := []snapshots.Snapshot{}
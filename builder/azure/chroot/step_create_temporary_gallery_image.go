@@ -0,0 +1,198 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleries"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const (
+	temporaryGalleryImageName        = "PackerTemp-source"
+	temporaryGalleryImageVersionName = "1.0.0"
+)
+
+var _ multistep.Step = &StepCreateTemporaryGalleryImage{}
+
+// StepCreateTemporaryGalleryImage publishes a source managed image that was
+// captured from a managed disk to a temporary gallery, so the OS disk can be
+// created from the image version. It does nothing for other sources.
+type StepCreateTemporaryGalleryImage struct {
+	GalleryID string
+	Location  string
+
+	// SourceOSDisk holds the managed image to publish, and is set to the
+	// image version created from it.
+	SourceOSDisk *osDiskSource
+
+	SkipCleanup bool
+
+	galleryID commonids.SharedImageGalleryId
+	imageID   galleryimages.GalleryImageId
+	versionID galleryimageversions.ImageVersionId
+
+	created []string
+
+	createGallery func(context.Context, client.AzureClientSet, commonids.SharedImageGalleryId, galleries.Gallery) error
+	createImage   func(context.Context, client.AzureClientSet, galleryimages.GalleryImageId, galleryimages.GalleryImage) error
+	createVersion func(context.Context, client.AzureClientSet, galleryimageversions.ImageVersionId, galleryimageversions.GalleryImageVersion) error
+	delete        func(context.Context, client.AzureClientSet, string) error
+}
+
+func NewStepCreateTemporaryGalleryImage(step *StepCreateTemporaryGalleryImage) *StepCreateTemporaryGalleryImage {
+	step.createGallery = createGallery
+	step.createImage = createGalleryImage
+	step.createVersion = createGalleryImageVersion
+	step.delete = step.deleteResource
+	return step
+}
+
+func (s *StepCreateTemporaryGalleryImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.SourceOSDisk == nil || s.SourceOSDisk.ManagedImageID == "" {
+		return multistep.ActionContinue
+	}
+
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	errorMessage := func(format string, params ...interface{}) multistep.StepAction {
+		err := fmt.Errorf(format, params...)
+		log.Printf("StepCreateTemporaryGalleryImage.Run: error: %+v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	gallery, err := client.ParseResourceID(s.GalleryID)
+	if err != nil {
+		return errorMessage("Could not parse temporary gallery id %q: %s", s.GalleryID, err)
+	}
+	if !strings.EqualFold(gallery.Provider, "Microsoft.Compute") ||
+		!strings.EqualFold(gallery.ResourceType.String(), "galleries") {
+		return errorMessage("Resource %q is not of type Microsoft.Compute/galleries", s.GalleryID)
+	}
+	galleryName := gallery.ResourceName.String()
+	s.galleryID = commonids.NewSharedImageGalleryID(gallery.Subscription, gallery.ResourceGroup, galleryName)
+	s.imageID = galleryimages.NewGalleryImageID(gallery.Subscription, gallery.ResourceGroup, galleryName, temporaryGalleryImageName)
+	s.versionID = galleryimageversions.NewImageVersionID(gallery.Subscription, gallery.ResourceGroup, galleryName, temporaryGalleryImageName, temporaryGalleryImageVersionName)
+
+	ui.Say(fmt.Sprintf("Creating temporary gallery %q", s.galleryID.ID()))
+	if err := s.createGallery(ctx, azcli, s.galleryID, galleries.Gallery{Location: s.Location}); err != nil {
+		return errorMessage("Failed to create temporary gallery %q: %s", s.galleryID.ID(), err)
+	}
+	s.created = append(s.created, s.galleryID.ID())
+
+	hyperVGeneration := galleryimages.HyperVGeneration(s.SourceOSDisk.HyperVGeneration)
+	image := galleryimages.GalleryImage{
+		Location: s.Location,
+		Properties: &galleryimages.GalleryImageProperties{
+			Identifier: galleryimages.GalleryImageIdentifier{
+				Publisher: "Packer",
+				Offer:     "Temporary",
+				Sku:       galleryName,
+			},
+			OsState:          galleryimages.OperatingSystemStateTypes(s.SourceOSDisk.OSState),
+			OsType:           galleryimages.OperatingSystemTypesLinux,
+			HyperVGeneration: &hyperVGeneration,
+		},
+	}
+	ui.Say(fmt.Sprintf("Creating temporary image definition %q", s.imageID.ID()))
+	if err := s.createImage(ctx, azcli, s.imageID, image); err != nil {
+		return errorMessage("Failed to create temporary image definition %q: %s", s.imageID.ID(), err)
+	}
+	s.created = append(s.created, s.imageID.ID())
+
+	version := galleryimageversions.GalleryImageVersion{
+		Location: s.Location,
+		Properties: &galleryimageversions.GalleryImageVersionProperties{
+			StorageProfile: galleryimageversions.GalleryImageVersionStorageProfile{
+				Source: &galleryimageversions.GalleryArtifactVersionFullSource{
+					Id: &s.SourceOSDisk.ManagedImageID,
+				},
+			},
+			PublishingProfile: &galleryimageversions.GalleryArtifactPublishingProfileBase{
+				TargetRegions: &[]galleryimageversions.TargetRegion{
+					{
+						Name:                 s.Location,
+						RegionalReplicaCount: common.Int64Ptr(1),
+					},
+				},
+				ExcludeFromLatest: common.BoolPtr(true),
+			},
+		},
+	}
+	ui.Say(fmt.Sprintf("Creating temporary image version %q from %q", s.versionID.ID(), s.SourceOSDisk.ManagedImageID))
+	if err := s.createVersion(ctx, azcli, s.versionID, version); err != nil {
+		return errorMessage("Failed to create temporary image version %q: %s", s.versionID.ID(), err)
+	}
+	s.created = append(s.created, s.versionID.ID())
+
+	s.SourceOSDisk.GalleryImageVersionID = s.versionID.ID()
+	return multistep.ActionContinue
+}
+
+func createGallery(ctx context.Context, azcli client.AzureClientSet, id commonids.SharedImageGalleryId, gallery galleries.Gallery) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	return azcli.GalleriesClient().CreateOrUpdateThenPoll(pollingContext, id, gallery)
+}
+
+func createGalleryImage(ctx context.Context, azcli client.AzureClientSet, id galleryimages.GalleryImageId, image galleryimages.GalleryImage) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	return azcli.GalleryImagesClient().CreateOrUpdateThenPoll(pollingContext, id, image)
+}
+
+func createGalleryImageVersion(ctx context.Context, azcli client.AzureClientSet, id galleryimageversions.ImageVersionId, version galleryimageversions.GalleryImageVersion) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	return azcli.GalleryImageVersionsClient().CreateOrUpdateThenPoll(pollingContext, id, version)
+}
+
+// deleteResource deletes the gallery, image definition or image version with
+// the given id.
+func (s *StepCreateTemporaryGalleryImage) deleteResource(ctx context.Context, azcli client.AzureClientSet, id string) error {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	switch id {
+	case s.versionID.ID():
+		return azcli.GalleryImageVersionsClient().DeleteThenPoll(pollingContext, s.versionID)
+	case s.imageID.ID():
+		return azcli.GalleryImagesClient().DeleteThenPoll(pollingContext, s.imageID)
+	case s.galleryID.ID():
+		return azcli.GalleriesClient().DeleteThenPoll(pollingContext, s.galleryID)
+	}
+	return fmt.Errorf("unknown temporary gallery resource %q", id)
+}
+
+func (s *StepCreateTemporaryGalleryImage) Cleanup(state multistep.StateBag) {
+	if s.SkipCleanup {
+		return
+	}
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	// A gallery or image definition can only be deleted once it is empty.
+	for i := len(s.created) - 1; i >= 0; i-- {
+		id := s.created[i]
+		ui.Say(fmt.Sprintf("Deleting %q", id))
+		if err := s.delete(context.TODO(), azcli, id); err != nil {
+			log.Printf("StepCreateTemporaryGalleryImage.Cleanup: error: %+v", err)
+			ui.Error(fmt.Sprintf("error deleting %q: %v.", id, err))
+			return
+		}
+	}
+}
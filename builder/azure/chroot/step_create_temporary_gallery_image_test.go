@@ -0,0 +1,125 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleries"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepCreateTemporaryGalleryImage(t *testing.T) {
+	const (
+		galleryID = "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/galleries/PackerTemp_gallery_1"
+		imageID   = galleryID + "/images/PackerTemp-source"
+		versionID = imageID + "/versions/1.0.0"
+		sourceID  = "/subscriptions/subid1/resourceGroups/rg2/providers/Microsoft.Compute/images/image1"
+	)
+	tests := []struct {
+		name        string
+		source      osDiskSource
+		failVersion bool
+		want        multistep.StepAction
+		wantCreated []string
+		wantDeleted []string
+	}{
+		{
+			name:   "not a managed image captured from a disk",
+			source: osDiskSource{ResourceID: "/subscriptions/subid1/resourceGroups/rg2/providers/Microsoft.Compute/snapshots/snapshot1"},
+			want:   multistep.ActionContinue,
+		},
+		{
+			name:        "managed image captured from a disk",
+			source:      osDiskSource{ManagedImageID: sourceID, HyperVGeneration: "V2", OSState: "Generalized"},
+			want:        multistep.ActionContinue,
+			wantCreated: []string{galleryID, imageID, versionID},
+			wantDeleted: []string{versionID, imageID, galleryID},
+		},
+		{
+			name:        "image version fails",
+			source:      osDiskSource{ManagedImageID: sourceID, HyperVGeneration: "V1", OSState: "Specialized"},
+			failVersion: true,
+			want:        multistep.ActionHalt,
+			wantCreated: []string{galleryID, imageID, versionID},
+			wantDeleted: []string{imageID, galleryID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created, deleted []string
+			source := tt.source
+			s := &StepCreateTemporaryGalleryImage{
+				GalleryID:    galleryID,
+				Location:     "westus2",
+				SourceOSDisk: &source,
+				createGallery: func(_ context.Context, _ client.AzureClientSet, id commonids.SharedImageGalleryId, gallery galleries.Gallery) error {
+					created = append(created, id.ID())
+					if gallery.Location != "westus2" {
+						t.Errorf("gallery location = %q, want westus2", gallery.Location)
+					}
+					return nil
+				},
+				createImage: func(_ context.Context, _ client.AzureClientSet, id galleryimages.GalleryImageId, image galleryimages.GalleryImage) error {
+					created = append(created, id.ID())
+					if got := string(*image.Properties.HyperVGeneration); got != tt.source.HyperVGeneration {
+						t.Errorf("image definition Hyper-V generation = %q, want %q", got, tt.source.HyperVGeneration)
+					}
+					if got := string(image.Properties.OsState); got != tt.source.OSState {
+						t.Errorf("image definition OS state = %q, want %q", got, tt.source.OSState)
+					}
+					return nil
+				},
+				createVersion: func(_ context.Context, _ client.AzureClientSet, id galleryimageversions.ImageVersionId, version galleryimageversions.GalleryImageVersion) error {
+					created = append(created, id.ID())
+					if got := *version.Properties.StorageProfile.Source.Id; got != sourceID {
+						t.Errorf("image version source = %q, want %q", got, sourceID)
+					}
+					if !*version.Properties.PublishingProfile.ExcludeFromLatest {
+						t.Error("expected the image version to be excluded from latest")
+					}
+					if tt.failVersion {
+						return errors.New("version failed")
+					}
+					return nil
+				},
+				delete: func(_ context.Context, _ client.AzureClientSet, id string) error {
+					deleted = append(deleted, id)
+					return nil
+				},
+			}
+
+			ui, _ := testUI()
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{SubscriptionIDMock: "subid1"})
+			state.Put("ui", ui)
+
+			if got := s.Run(context.TODO(), state); got != tt.want {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+			s.Cleanup(state)
+
+			if !reflect.DeepEqual(created, tt.wantCreated) {
+				t.Errorf("created %v, want %v", created, tt.wantCreated)
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("deleted %v, want %v", deleted, tt.wantDeleted)
+			}
+			wantVersionID := ""
+			if tt.want == multistep.ActionContinue && tt.source.ManagedImageID != "" {
+				wantVersionID = versionID
+			}
+			if source.GalleryImageVersionID != wantVersionID {
+				t.Errorf("GalleryImageVersionID = %q, want %q", source.GalleryImageVersionID, wantVersionID)
+			}
+		})
+	}
+}
@@ -26,12 +26,16 @@ type StepEarlyCleanup struct{}
 func (s *StepEarlyCleanup) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 
-	// Cleanup keys in order: unmount filesystem, deactivate LVM, detach disk.
+	// Cleanup keys in order: remove the emulator, unmount filesystem,
+	// deactivate LVM, close LUKS, detach disk.
 	cleanupKeys := []string{
+		"emulation_cleanup",
 		"copy_files_cleanup",
 		"mount_extra_cleanup",
+		"mount_fstab_cleanup",
 		"mount_device_cleanup",
 		"lvm_cleanup",
+		"luks_cleanup",
 		"attach_cleanup",
 	}
 
@@ -19,6 +19,10 @@ import (
 type StepGetSourceImageName struct {
 	// Copy another disk
 	SourceOSDiskResourceID string
+	// Copy a snapshot or the source of a managed image, or import a VHD
+	SourceSnapshotResourceID     string
+	SourceManagedImageResourceID string
+	SourceVHDURI                 string
 
 	// Extract from platform image
 	SourcePlatformImage *client.PlatformImage
@@ -41,10 +45,12 @@ func (s *StepGetSourceImageName) Run(ctx context.Context, state multistep.StateB
 	ui := state.Get("ui").(packersdk.Ui)
 	ui.Say("Getting source image id for the deployment ...")
 
-	if s.SourceOSDiskResourceID != "" {
-		ui.Say(fmt.Sprintf(" -> SourceImageName: '%s'", s.SourceOSDiskResourceID))
-		s.GeneratedData.Put("SourceImageName", s.SourceOSDiskResourceID)
-		return multistep.ActionContinue
+	for _, source := range []string{s.SourceOSDiskResourceID, s.SourceSnapshotResourceID, s.SourceManagedImageResourceID, s.SourceVHDURI} {
+		if source != "" {
+			ui.Say(fmt.Sprintf(" -> SourceImageName: '%s'", source))
+			s.GeneratedData.Put("SourceImageName", source)
+			return multistep.ActionContinue
+		}
 	}
 
 	if s.SourceImageResourceID != "" {
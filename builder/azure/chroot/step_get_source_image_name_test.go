@@ -40,6 +40,30 @@ func TestChrootStepGetSourceImageName(t *testing.T) {
 			},
 			expected: "https://azure/vhd",
 		},
+		{
+			name: "SourceSnapshot",
+			step: &StepGetSourceImageName{
+				SourceSnapshotResourceID: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/snapshots/snapshot",
+				GeneratedData:            &packerbuilderdata.GeneratedData{State: state},
+			},
+			expected: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/snapshots/snapshot",
+		},
+		{
+			name: "SourceManagedImage",
+			step: &StepGetSourceImageName{
+				SourceManagedImageResourceID: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/images/image",
+				GeneratedData:                &packerbuilderdata.GeneratedData{State: state},
+			},
+			expected: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/images/image",
+		},
+		{
+			name: "SourceVHD",
+			step: &StepGetSourceImageName{
+				SourceVHDURI:  "https://account.blob.core.windows.net/vhds/source.vhd",
+				GeneratedData: &packerbuilderdata.GeneratedData{State: state},
+			},
+			expected: "https://account.blob.core.windows.net/vhds/source.vhd",
+		},
 		{
 			name: "MarketPlaceImage",
 			step: &StepGetSourceImageName{
@@ -0,0 +1,167 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepGrowRootPartition{}
+
+// StepGrowRootPartition grows the last partition of the attached disk to the
+// end of the disk when it holds the root filesystem, then the LUKS volume,
+// LVM physical volume and root logical volume on it if any, and the ext4 or
+// xfs root filesystem. It runs after the root filesystem is mounted because
+// xfs can only be grown online.
+type StepGrowRootPartition struct {
+	run      func(multistep.StateBag, string) (string, error)
+	openDisk func(string) (diskFile, error)
+}
+
+// diskFile is an open disk device or image file.
+type diskFile interface {
+	gptDisk
+	io.Seeker
+	io.Closer
+	Sync() error
+}
+
+func NewStepGrowRootPartition(step *StepGrowRootPartition) *StepGrowRootPartition {
+	step.run = runWrappedCommand
+	step.openDisk = func(name string) (diskFile, error) {
+		return os.OpenFile(name, os.O_RDWR, 0)
+	}
+	return step
+}
+
+func (s *StepGrowRootPartition) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	diskDevice := state.Get(stateBagKey_DiskDevice).(string)
+	rootDevice := state.Get("device").(string)
+	mountPath := state.Get("mount_path").(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error growing the root partition: %v", err)
+		log.Printf("StepGrowRootPartition.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	disk, err := s.openDisk(diskDevice)
+	if err != nil {
+		return halt(err)
+	}
+	defer disk.Close()
+	diskSize, err := disk.Seek(0, io.SeekEnd)
+	if err != nil {
+		return halt(err)
+	}
+	gpt, err := readGPT(disk)
+	if err != nil {
+		return halt(fmt.Errorf("%s: %v, only GPT partitioned disks can be grown", diskDevice, err))
+	}
+	number := gpt.lastPartition()
+	if number == 0 {
+		return halt(fmt.Errorf("%s has no partitions", diskDevice))
+	}
+	partition := partitionDevice(diskDevice, strconv.Itoa(number))
+
+	// the partition and the devices stacked on it, like the LUKS volume and
+	// the logical volumes
+	out, err := s.run(state, fmt.Sprintf("lsblk -P -p -o %s %s", lsblkColumns, partition))
+	if err != nil {
+		return halt(err)
+	}
+	stack := parseLsblk(out)
+	root := -1
+	for i, d := range stack {
+		if d.Name == rootDevice || d.Name == lvmMapperDevice(rootDevice) {
+			root = i
+		}
+	}
+	if root == -1 {
+		ui.Say(fmt.Sprintf("The root filesystem is not on the last partition %s of the disk, not growing it", partition))
+		return multistep.ActionContinue
+	}
+
+	_, grown, err := gpt.grow(disk, diskSize)
+	if err != nil {
+		return halt(err)
+	}
+	if err := disk.Sync(); err != nil {
+		return halt(err)
+	}
+	if grown {
+		ui.Say(fmt.Sprintf("Grew partition %s to the end of the disk", partition))
+		// partx updates the partitions in use, unlike partprobe
+		for _, command := range []string{"partx -u " + diskDevice, "udevadm settle"} {
+			if _, err := s.run(state, command); err != nil {
+				return halt(err)
+			}
+		}
+	} else {
+		ui.Say(fmt.Sprintf("Partition %s already ends at the end of the disk", partition))
+	}
+
+	// The commands below are no-ops when there is nothing to grow, so they
+	// also finish an earlier, interrupted attempt.
+	var lvm bool
+	for i, d := range stack[:root] {
+		switch d.FSType {
+		case "crypto_LUKS":
+			mapper := stack[i+1].Name
+			ui.Say(fmt.Sprintf("Growing LUKS volume %s", mapper))
+			if _, err := s.run(state, "cryptsetup resize "+filepath.Base(mapper)); err != nil {
+				return halt(err)
+			}
+		case "LVM2_member":
+			ui.Say(fmt.Sprintf("LVM: growing physical volume %s", d.Name))
+			if _, err := s.run(state, "pvresize "+d.Name); err != nil {
+				return halt(err)
+			}
+			lvm = true
+		}
+	}
+	if lvm {
+		out, err := s.run(state, "lvs --noheadings -o vg_free_count "+rootDevice)
+		if err != nil {
+			return halt(err)
+		}
+		if free := strings.TrimSpace(out); free != "" && free != "0" {
+			ui.Say(fmt.Sprintf("LVM: growing logical volume %s", rootDevice))
+			if _, err := s.run(state, "lvextend -l +100%FREE "+rootDevice); err != nil {
+				return halt(err)
+			}
+		}
+	}
+
+	switch fsType := stack[root].FSType; fsType {
+	case "ext2", "ext3", "ext4":
+		ui.Say(fmt.Sprintf("Growing %s filesystem on %s", fsType, rootDevice))
+		_, err = s.run(state, "resize2fs "+rootDevice)
+	case "xfs":
+		ui.Say(fmt.Sprintf("Growing xfs filesystem on %s", rootDevice))
+		_, err = s.run(state, "xfs_growfs "+mountPath)
+	default:
+		ui.Say(fmt.Sprintf("Not growing the %q filesystem on %s, only ext4 and xfs are supported", fsType, rootDevice))
+	}
+	if err != nil {
+		return halt(err)
+	}
+	return multistep.ActionContinue
+}
+
+func (*StepGrowRootPartition) Cleanup(multistep.StateBag) {}
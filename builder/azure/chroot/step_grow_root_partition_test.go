@@ -0,0 +1,164 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+// testGrowImage creates an 8 MiB GPT image with a partition 1 and a
+// partition 2 ending at the last usable sector, and extends it to 16 MiB.
+func testGrowImage(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "sdc")
+	writeTestGPTImage(t, path, 512, 8<<20, [][2]uint64{{2048, 4095}, {4096, 16350}})
+	if err := os.Truncate(path, 16<<20); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testGrowState(disk, device string) (multistep.StateBag, func() string) {
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, disk)
+	state.Put("device", device)
+	return state, getErr
+}
+
+func testGrowStep(lsblk string, commands *[]string) *StepGrowRootPartition {
+	s := NewStepGrowRootPartition(&StepGrowRootPartition{})
+	s.run = func(_ multistep.StateBag, command string) (string, error) {
+		*commands = append(*commands, command)
+		switch {
+		case strings.HasPrefix(command, "lsblk"):
+			return lsblk, nil
+		case strings.HasPrefix(command, "lvs"):
+			return "  1024\n", nil
+		}
+		return "", nil
+	}
+	return s
+}
+
+func testGrowLastLBA(t *testing.T, disk string) uint64 {
+	f, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gpt, err := readGPT(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, last, _ := gpt.partitionLBAs(1)
+	return last
+}
+
+func TestStepGrowRootPartition_ext4(t *testing.T) {
+	disk := testGrowImage(t)
+	var commands []string
+	s := testGrowStep(fmt.Sprintf(`NAME="%s2" UUID="1111-root" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="ext4"`, disk), &commands)
+	state, getErr := testGrowState(disk, disk+"2")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"lsblk -P -p -o " + lsblkColumns + " " + disk + "2",
+		"partx -u " + disk,
+		"udevadm settle",
+		"resize2fs " + disk + "2",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+	if got, want := testGrowLastLBA(t, disk), uint64(32768-34); got != want {
+		t.Errorf("partition 2 ends at %d, want %d", got, want)
+	}
+
+	// a second run does not change the partition table but still grows the
+	// filesystem
+	commands = nil
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want = []string{
+		"lsblk -P -p -o " + lsblkColumns + " " + disk + "2",
+		"resize2fs " + disk + "2",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestStepGrowRootPartition_lvmOnLUKS(t *testing.T) {
+	disk := testGrowImage(t)
+	var commands []string
+	s := testGrowStep(fmt.Sprintf(`NAME="%s2" UUID="3333-luks" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="crypto_LUKS"
+NAME="/dev/mapper/packer-luks-sdc2" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="LVM2_member"
+NAME="/dev/mapper/vg-swap" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="swap"
+NAME="/dev/mapper/vg-root" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="xfs"`, disk), &commands)
+	state, getErr := testGrowState(disk, "/dev/vg/root")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"lsblk -P -p -o " + lsblkColumns + " " + disk + "2",
+		"partx -u " + disk,
+		"udevadm settle",
+		"cryptsetup resize packer-luks-sdc2",
+		"pvresize /dev/mapper/packer-luks-sdc2",
+		"lvs --noheadings -o vg_free_count /dev/vg/root",
+		"lvextend -l +100%FREE /dev/vg/root",
+		"xfs_growfs /mnt/chroot",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestStepGrowRootPartition_rootNotOnLastPartition(t *testing.T) {
+	disk := testGrowImage(t)
+	var commands []string
+	s := testGrowStep(fmt.Sprintf(`NAME="%s2" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="ext4"`, disk), &commands)
+	state, getErr := testGrowState(disk, disk+"1")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if len(commands) != 1 {
+		t.Errorf("commands = %q, want only lsblk", commands)
+	}
+	if got, want := testGrowLastLBA(t, disk), uint64(16350); got != want {
+		t.Errorf("partition 2 ends at %d, want it unchanged at %d", got, want)
+	}
+}
+
+func TestStepGrowRootPartition_noGPT(t *testing.T) {
+	disk := filepath.Join(t.TempDir(), "sdc")
+	if err := os.WriteFile(disk, make([]byte, 1<<20), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	s := testGrowStep("", &commands)
+	state, getErr := testGrowState(disk, disk+"1")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionHalt {
+		t.Fatalf("Run() = %v, want ActionHalt", got)
+	}
+	if !strings.Contains(getErr(), "only GPT partitioned disks can be grown") {
+		t.Errorf("error = %q", getErr())
+	}
+}
@@ -70,25 +70,18 @@ func (s *StepMountDevice) Run(ctx context.Context, state multistep.StateBag) mul
 		}
 	}
 
-	// When LVM is active, the device is already a full LV path (e.g. /dev/mapper/rhel-root)
-	// and no partition suffix should be appended.
+	// When LVM or LUKS is active, the device is already a full LV or mapper path
+	// (e.g. /dev/mapper/rhel-root) and no partition suffix should be appended.
 	mountPartition := s.MountPartition
 	if _, ok := state.GetOk("lvm_active"); ok {
 		mountPartition = ""
 	}
-
-	var deviceMount string
-	if mountPartition == "" {
-		deviceMount = device
-	} else {
-		switch runtime.GOOS {
-		case "freebsd":
-			deviceMount = fmt.Sprintf("%sp%s", device, mountPartition)
-		default:
-			deviceMount = fmt.Sprintf("%s%s", device, mountPartition)
-		}
+	if _, ok := state.GetOk("luks_active"); ok {
+		mountPartition = ""
 	}
 
+	deviceMount := partitionDevice(device, mountPartition)
+
 	state.Put("deviceMount", deviceMount)
 
 	ui.Say("Mounting the root device...")
@@ -136,6 +129,20 @@ func (s *StepMountDevice) Run(ctx context.Context, state multistep.StateBag) mul
 	return multistep.ActionContinue
 }
 
+// partitionDevice returns the device of the given partition of device, or
+// device itself if partition is empty.
+func partitionDevice(device, partition string) string {
+	if partition == "" {
+		return device
+	}
+	switch runtime.GOOS {
+	case "freebsd":
+		return fmt.Sprintf("%sp%s", device, partition)
+	default:
+		return fmt.Sprintf("%s%s", device, partition)
+	}
+}
+
 func (s *StepMountDevice) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packersdk.Ui)
 	if err := s.CleanupFunc(state); err != nil {
@@ -135,6 +135,63 @@ func TestStepMountDevice_Run_LVMActive(t *testing.T) {
 	}
 }
 
+func TestStepMountDevice_Run_LUKSActive(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "freebsd":
+		break
+	default:
+		t.Skip("Unsupported operating system")
+	}
+	mountPath, err := os.MkdirTemp("", "stepmountdevicetest-luks")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %q", err)
+	}
+	defer func() { _ = os.Remove(mountPath) }()
+
+	step := &StepMountDevice{
+		MountOptions:   []string{"nouuid"},
+		MountPartition: "1", // should be cleared by luks_active
+		MountPath:      mountPath,
+	}
+
+	var gotCommand string
+	var wrapper common.CommandWrapper = func(ran string) (string, error) {
+		gotCommand = ran
+		return "", nil
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("wrappedCommand", wrapper)
+	state.Put("device", "/dev/mapper/packer-luks-sdc2")
+	state.Put("luks_active", true) // LUKS active: mount partition should be ignored
+
+	ui, _ := testUI()
+	state.Put("ui", ui)
+
+	var config Config
+	state.Put("config", &config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	got := step.Run(ctx, state)
+	if got != multistep.ActionContinue {
+		t.Fatalf("Expected 'continue', but got '%v'", got)
+	}
+
+	// When luks_active is set, the device should be used directly without any partition suffix
+	expectedCommand := fmt.Sprintf("mount -o nouuid %s %s", "/dev/mapper/packer-luks-sdc2", mountPath)
+	if gotCommand != expectedCommand {
+		t.Errorf("Expected %q, but got %q", expectedCommand, gotCommand)
+	}
+
+	// Verify deviceMount in state bag
+	deviceMount := state.Get("deviceMount").(string)
+	if deviceMount != "/dev/mapper/packer-luks-sdc2" {
+		t.Errorf("Expected deviceMount %q, but got %q", "/dev/mapper/packer-luks-sdc2", deviceMount)
+	}
+}
+
 func TestStepMountDevice_CleanupFunc_Unmount(t *testing.T) {
 	switch runtime.GOOS {
 	case "linux", "freebsd":
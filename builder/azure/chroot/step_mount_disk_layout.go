@@ -0,0 +1,55 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepMountDiskLayout{}
+
+// StepMountDiskLayout mounts the filesystems of the disk layout other than the
+// root filesystem under "mount_path". They are unmounted with the root
+// filesystem by StepMountDevice, which unmounts recursively.
+type StepMountDiskLayout struct {
+	run func(multistep.StateBag, string) (string, error)
+}
+
+func NewStepMountDiskLayout(step *StepMountDiskLayout) *StepMountDiskLayout {
+	step.run = runWrappedCommand
+	return step
+}
+
+func (s *StepMountDiskLayout) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	mountPath := state.Get("mount_path").(string)
+	mounts, _ := state.Get(stateBagKey_DiskLayoutMounts).([]diskLayoutFileSystem)
+
+	for _, fs := range mounts {
+		target := filepath.Join(mountPath, fs.MountPoint)
+		ui.Say(fmt.Sprintf("Mounting %s at %s", fs.Device, fs.MountPoint))
+		for _, command := range []string{
+			fmt.Sprintf("mkdir -p %s", shellQuote(target)),
+			fmt.Sprintf("mount %s %s", shellQuote(fs.Device), shellQuote(target)),
+		} {
+			if _, err := s.run(state, command); err != nil {
+				err = fmt.Errorf("error mounting %s: %v", fs.MountPoint, err)
+				log.Printf("StepMountDiskLayout.Run: %v", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
+	}
+	return multistep.ActionContinue
+}
+
+func (*StepMountDiskLayout) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,44 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepMountDiskLayout(t *testing.T) {
+	var commands []string
+	s := &StepMountDiskLayout{
+		run: func(_ multistep.StateBag, command string) (string, error) {
+			commands = append(commands, command)
+			return "", nil
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskLayoutMounts, []diskLayoutFileSystem{
+		{Name: "boot", Device: "/dev/sdc2", MountPoint: "/boot"},
+		{Name: "data", Device: "/dev/mapper/vg-data", MountPoint: "/srv/my data;touch /x"},
+	})
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"mkdir -p /mnt/chroot/boot",
+		"mount /dev/sdc2 /mnt/chroot/boot",
+		"mkdir -p '/mnt/chroot/srv/my data;touch /x'",
+		"mount /dev/mapper/vg-data '/mnt/chroot/srv/my data;touch /x'",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepMountFstab{}
+
+// StepMountFstab mounts the filesystems listed in the /etc/fstab of the
+// mounted root filesystem that are on the attached disk, like /boot, /boot/efi
+// or btrfs subvolumes, and unmounts them in reverse order on cleanup.
+type StepMountFstab struct {
+	// mounted are the paths that were mounted by this step, in order.
+	mounted []string
+
+	run      func(multistep.StateBag, string) (string, error)
+	readFile func(string) ([]byte, error)
+}
+
+func NewStepMountFstab(step *StepMountFstab) *StepMountFstab {
+	step.run = runWrappedCommand
+	step.readFile = os.ReadFile
+	return step
+}
+
+func (s *StepMountFstab) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	mountPath := state.Get("mount_path").(string)
+	diskDevice := state.Get(stateBagKey_DiskDevice).(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error mounting the filesystems of /etc/fstab: %v", err)
+		log.Printf("StepMountFstab.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	fstab, err := s.readFile(filepath.Join(mountPath, "etc", "fstab"))
+	if os.IsNotExist(err) {
+		ui.Say("No /etc/fstab found in the root filesystem, not mounting other filesystems")
+		return multistep.ActionContinue
+	}
+	if err != nil {
+		return halt(err)
+	}
+	entries, err := parseFstab(bytes.NewReader(fstab))
+	if err != nil {
+		return halt(err)
+	}
+
+	out, err := s.run(state, fmt.Sprintf("lsblk -P -p -o %s %s", lsblkColumns, diskDevice))
+	if err != nil {
+		return halt(err)
+	}
+	devices := parseLsblk(out)
+
+	state.Put("mount_fstab_cleanup", s)
+	for _, entry := range fstabMounts(entries) {
+		device, ok := resolveFstabSpec(entry.Spec, devices)
+		if !ok {
+			ui.Say(fmt.Sprintf("Not mounting %s: %s is not on the attached disk", entry.File, entry.Spec))
+			continue
+		}
+
+		if err := validateFstabMount(entry); err != nil {
+			ui.Say(fmt.Sprintf("Not mounting %q: %v", entry.File, err))
+			continue
+		}
+		// the mount point is resolved in the image, so that its symbolic
+		// links cannot point the mount at a directory of the host
+		target, err := resolveMountTarget(mountPath, entry.File)
+		if err != nil {
+			return halt(err)
+		}
+
+		ui.Say(fmt.Sprintf("Mounting %s at %s", device, entry.File))
+		if _, err := s.run(state, fmt.Sprintf("mkdir -p %s", shellQuote(target))); err != nil {
+			return halt(err)
+		}
+		if _, err := s.run(state, fmt.Sprintf("mount -t %s -o %s %s %s",
+			entry.VFSType, entry.Options, shellQuote(device), shellQuote(target))); err != nil {
+			return halt(err)
+		}
+		s.mounted = append(s.mounted, target)
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepMountFstab) Cleanup(state multistep.StateBag) {
+	if err := s.CleanupFunc(state); err != nil {
+		ui := state.Get("ui").(packersdk.Ui)
+		ui.Error(err.Error())
+	}
+}
+
+// CleanupFunc unmounts the filesystems mounted by this step, children before
+// their parents.
+func (s *StepMountFstab) CleanupFunc(state multistep.StateBag) error {
+	if len(s.mounted) == 0 {
+		return nil
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	ui.Say("Unmounting the filesystems of /etc/fstab...")
+	for len(s.mounted) > 0 {
+		target := s.mounted[len(s.mounted)-1]
+		if _, err := s.run(state, fmt.Sprintf("umount %s", shellQuote(target))); err != nil {
+			return fmt.Errorf("error unmounting %s: %v", target, err)
+		}
+		s.mounted = s.mounted[:len(s.mounted)-1]
+	}
+	return nil
+}
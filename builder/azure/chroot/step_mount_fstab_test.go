@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepMountFstab(t *testing.T) {
+	var commands []string
+	s := &StepMountFstab{
+		run: func(_ multistep.StateBag, command string) (string, error) {
+			commands = append(commands, command)
+			if strings.HasPrefix(command, "lsblk") {
+				return `NAME="/dev/sdc1" UUID="1111-root" LABEL="" PARTUUID="" PARTLABEL=""
+NAME="/dev/sdc15" UUID="ABCD-EF01" LABEL="" PARTUUID="" PARTLABEL="esp"
+NAME="/dev/sdc16" UUID="2222-boot" LABEL="" PARTUUID="" PARTLABEL=""
+`, nil
+			}
+			return "", nil
+		},
+		readFile: func(name string) ([]byte, error) {
+			if name != "/mnt/chroot/etc/fstab" {
+				t.Errorf("read %q", name)
+			}
+			return []byte(testFstab), nil
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"lsblk -P -p -o " + lsblkColumns + " /dev/sdc",
+		"mkdir -p /mnt/chroot/boot",
+		"mount -t ext4 -o defaults /dev/sdc16 /mnt/chroot/boot",
+		"mkdir -p /mnt/chroot/home",
+		"mount -t btrfs -o subvol=@home /dev/sdc1 /mnt/chroot/home",
+		"mkdir -p /mnt/chroot/boot/efi",
+		"mount -t vfat -o umask=0077 /dev/sdc15 /mnt/chroot/boot/efi",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %v, want %v", commands, want)
+	}
+
+	commands = nil
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"umount /mnt/chroot/boot/efi", "umount /mnt/chroot/home", "umount /mnt/chroot/boot"}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("cleanup commands = %v, want %v", commands, want)
+	}
+}
+
+func TestStepMountFstab_noFstab(t *testing.T) {
+	s := &StepMountFstab{
+		run: func(_ multistep.StateBag, command string) (string, error) {
+			t.Errorf("unexpected command %q", command)
+			return "", nil
+		},
+		readFile: func(string) ([]byte, error) { return nil, os.ErrNotExist },
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+}
+
+func TestStepMountFstab_untrustedFstab(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Symlink("/etc", filepath.Join(root, "boot")); err != nil {
+		t.Fatal(err)
+	}
+
+	var commands []string
+	s := &StepMountFstab{
+		run: func(_ multistep.StateBag, command string) (string, error) {
+			commands = append(commands, command)
+			if strings.HasPrefix(command, "lsblk") {
+				return `NAME="/dev/sdc1" UUID="1111" LABEL="" PARTUUID="" PARTLABEL=""
+`, nil
+			}
+			return "", nil
+		},
+		readFile: func(string) ([]byte, error) {
+			return []byte(`UUID=1111 /boot           ext4       defaults        0 0
+UUID=1111 /a\073reboot    ext4       defaults        0 0
+UUID=1111 /b              ext4;reboot defaults       0 0
+UUID=1111 /c              ext4       rw;reboot       0 0
+UUID=1111 /d              ext4       noauto,defaults 0 0
+UUID=1111 /e              nfs4       defaults        0 0
+`), nil
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", root)
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"lsblk -P -p -o " + lsblkColumns + " /dev/sdc",
+		// the absolute link is resolved in the image, not on the host
+		"mkdir -p " + filepath.Join(root, "etc"),
+		"mount -t ext4 -o defaults /dev/sdc1 " + filepath.Join(root, "etc"),
+		"mkdir -p '" + filepath.Join(root, "a;reboot") + "'",
+		"mount -t ext4 -o defaults /dev/sdc1 '" + filepath.Join(root, "a;reboot") + "'",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepSetupEmulation{}
+
+// StepSetupEmulation detects the architecture of the mounted image and puts
+// it in the state bag. When it differs from the architecture of the host, it
+// registers a binfmt_misc handler running the executables of the image with
+// the qemu user mode emulator, unless the host has one already, and copies
+// the emulator into the chroot. Both are undone in cleanup, before the
+// filesystems are unmounted.
+type StepSetupEmulation struct {
+	// QemuBinary is the statically linked emulator on the host, defaults to
+	// /usr/bin/qemu-<arch>-static.
+	QemuBinary string
+
+	// handler is the binfmt_misc handler registered by this step, copied the
+	// emulator copied into the chroot (for cleanup).
+	handler string
+	copied  string
+
+	hostArch      string
+	binfmtDir     string
+	handlerSuffix func() string
+	run           func(multistep.StateBag, string) (string, error)
+	readFile      func(string) ([]byte, error)
+}
+
+func NewStepSetupEmulation(step *StepSetupEmulation) *StepSetupEmulation {
+	step.hostArch = runtime.GOARCH
+	step.binfmtDir = "/proc/sys/fs/binfmt_misc"
+	step.handlerSuffix = func() string { return fmt.Sprintf("%08x", rand.Uint32()) }
+	step.run = runWrappedCommand
+	step.readFile = os.ReadFile
+	return step
+}
+
+func (s *StepSetupEmulation) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	mountPath := state.Get("mount_path").(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error setting up emulation: %v", err)
+		log.Printf("StepSetupEmulation.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	machine, probe, err := detectImageMachine(mountPath)
+	if err != nil {
+		ui.Say(fmt.Sprintf("Could not detect the architecture of the image (%v), assuming it is the one of the host", err))
+		return multistep.ActionContinue
+	}
+	arch, ok := architectureByMachine(machine)
+	if !ok {
+		ui.Say(fmt.Sprintf("The executables of the image are for an unsupported architecture (%s)", machine))
+		return multistep.ActionContinue
+	}
+	ui.Say(fmt.Sprintf("Detected an %s image from %s", arch.Name, probe))
+	state.Put(stateBagKey_ImageArchitecture, arch.Name)
+
+	// The architecture of a gallery image definition cannot be changed, fail
+	// before provisioning an image that cannot be published to it.
+	if definitionArch, ok := state.GetOk(stateBagKey_SharedImageArchitecture); ok && !strings.EqualFold(arch.Name, definitionArch.(string)) {
+		err := fmt.Errorf("the shared image definition is for %s, but the image is for %s; use an image definition with architecture %s",
+			definitionArch, arch.Name, arch.Name)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if host, ok := architectureByGOARCH(s.hostArch); ok && host.Name == arch.Name {
+		return multistep.ActionContinue
+	}
+	ui.Say(fmt.Sprintf("The image is not for the architecture of the host (%s), using qemu user mode emulation", s.hostArch))
+
+	qemuBinary := s.QemuBinary
+	if qemuBinary == "" {
+		qemuBinary = fmt.Sprintf("/usr/bin/qemu-%s-static", arch.Qemu)
+	}
+
+	// binfmt_misc is usually mounted by systemd
+	if _, err := s.readFile(filepath.Join(s.binfmtDir, "status")); err != nil {
+		if _, err := s.run(state, "mount -t binfmt_misc binfmt_misc "+s.binfmtDir); err != nil {
+			return halt(err)
+		}
+	}
+
+	// Use the handler of the host if there is one, like the ones of the
+	// qemu-user-static packages. When it has the F flag, the kernel opened
+	// the emulator when it was registered and it is not needed in the chroot.
+	interpreter := fmt.Sprintf("/usr/bin/qemu-%s-static", arch.Qemu)
+	copyEmulator := true
+	hostHandler := "qemu-" + arch.Qemu
+	status, err := s.readFile(filepath.Join(s.binfmtDir, hostHandler))
+	if st := parseBinfmtStatus(bytes.NewReader(status)); err == nil && st.Enabled && st.Interpreter != "" {
+		ui.Say(fmt.Sprintf("Using binfmt_misc handler %s of the host", hostHandler))
+		interpreter = st.Interpreter
+		copyEmulator = !strings.Contains(st.Flags, "F")
+	} else {
+		if _, err := os.Stat(qemuBinary); err != nil {
+			return halt(fmt.Errorf("%v, install qemu-user-static on the host or set qemu_binary", err))
+		}
+		// The handlers are global to the host, a name of its own keeps
+		// concurrent builds from registering or unregistering each other's.
+		name := fmt.Sprintf("packer-qemu-%s-%s", arch.Qemu, s.handlerSuffix())
+		registration := arch.binfmtHandler(name, interpreter)
+		ui.Say(fmt.Sprintf("Registering binfmt_misc handler %s", name))
+		if _, err := s.run(state, fmt.Sprintf(`sh -c "printf '%%s' '%s' > %s"`, registration, filepath.Join(s.binfmtDir, "register"))); err != nil {
+			return halt(err)
+		}
+		s.handler = name
+		state.Put("emulation_cleanup", s)
+	}
+
+	if copyEmulator {
+		target := filepath.Join(mountPath, interpreter)
+		if _, err := os.Lstat(target); err == nil {
+			ui.Say(fmt.Sprintf("The image has %s already, not copying the emulator", interpreter))
+			return multistep.ActionContinue
+		}
+		if _, err := os.Stat(qemuBinary); err != nil {
+			return halt(fmt.Errorf("%v, install qemu-user-static on the host or set qemu_binary", err))
+		}
+		ui.Say(fmt.Sprintf("Copying %s to %s in the chroot", qemuBinary, interpreter))
+		for _, command := range []string{
+			"mkdir -p " + filepath.Dir(target),
+			fmt.Sprintf("cp %s %s", qemuBinary, target),
+		} {
+			if _, err := s.run(state, command); err != nil {
+				return halt(err)
+			}
+		}
+		s.copied = target
+		state.Put("emulation_cleanup", s)
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepSetupEmulation) Cleanup(state multistep.StateBag) {
+	if err := s.CleanupFunc(state); err != nil {
+		ui := state.Get("ui").(packersdk.Ui)
+		ui.Error(err.Error())
+	}
+}
+
+// CleanupFunc removes the emulator from the chroot and unregisters the
+// binfmt_misc handler registered by this step.
+func (s *StepSetupEmulation) CleanupFunc(state multistep.StateBag) error {
+	if s.copied != "" {
+		if _, err := s.run(state, "rm -f "+s.copied); err != nil {
+			return fmt.Errorf("error removing the emulator from the chroot: %v", err)
+		}
+		s.copied = ""
+	}
+	if s.handler != "" {
+		ui := state.Get("ui").(packersdk.Ui)
+		ui.Say(fmt.Sprintf("Unregistering binfmt_misc handler %s", s.handler))
+		if _, err := s.run(state, fmt.Sprintf(`sh -c "echo -1 > %s"`, filepath.Join(s.binfmtDir, s.handler))); err != nil {
+			return fmt.Errorf("error unregistering binfmt_misc handler %s: %v", s.handler, err)
+		}
+		s.handler = ""
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+// testEmulationStep returns a step running on an amd64 host with a mounted
+// binfmt_misc in a temporary directory and an emulator at QemuBinary.
+func testEmulationStep(t *testing.T, commands *[]string) *StepSetupEmulation {
+	binfmtDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binfmtDir, "status"), []byte("enabled\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	qemu := filepath.Join(t.TempDir(), "qemu-aarch64-static")
+	if err := os.WriteFile(qemu, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStepSetupEmulation(&StepSetupEmulation{QemuBinary: qemu})
+	s.hostArch = "amd64"
+	s.binfmtDir = binfmtDir
+	s.handlerSuffix = func() string { return "0123abcd" }
+	s.run = func(_ multistep.StateBag, command string) (string, error) {
+		*commands = append(*commands, command)
+		return "", nil
+	}
+	return s
+}
+
+func testEmulationState(mountPath string) (multistep.StateBag, func() string) {
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", mountPath)
+	return state, getErr
+}
+
+func TestStepSetupEmulation(t *testing.T) {
+	var commands []string
+	s := testEmulationStep(t, &commands)
+	root := testUsrMergedRoot(t)
+	state, getErr := testEmulationState(root)
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if got := state.Get(stateBagKey_ImageArchitecture); got != "Arm64" {
+		t.Errorf("image architecture = %v, want Arm64", got)
+	}
+	arch, _ := architectureByMachine(elf.EM_AARCH64)
+	registration := arch.binfmtHandler("packer-qemu-aarch64-0123abcd", "/usr/bin/qemu-aarch64-static")
+	target := filepath.Join(root, "usr", "bin", "qemu-aarch64-static")
+	want := []string{
+		`sh -c "printf '%s' '` + registration + `' > ` + filepath.Join(s.binfmtDir, "register") + `"`,
+		"mkdir -p " + filepath.Join(root, "usr", "bin"),
+		"cp " + s.QemuBinary + " " + target,
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+	if state.Get("emulation_cleanup") != s {
+		t.Error("emulation_cleanup is not set")
+	}
+
+	commands = nil
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	// cleanup is idempotent
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"rm -f " + target,
+		`sh -c "echo -1 > ` + filepath.Join(s.binfmtDir, "packer-qemu-aarch64-0123abcd") + `"`,
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("cleanup commands = %q, want %q", commands, want)
+	}
+}
+
+// Builds running at the same time on the host register handlers of their own,
+// and each one unregisters only the handler it registered.
+func TestStepSetupEmulation_concurrentBuilds(t *testing.T) {
+	var commands []string
+	first := testEmulationStep(t, &commands)
+	first.handlerSuffix = NewStepSetupEmulation(&StepSetupEmulation{}).handlerSuffix
+	second := testEmulationStep(t, &commands)
+	second.handlerSuffix = first.handlerSuffix
+	second.binfmtDir = first.binfmtDir
+
+	firstState, getErr := testEmulationState(testUsrMergedRoot(t))
+	if got := first.Run(context.TODO(), firstState); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	secondState, getErr := testEmulationState(testUsrMergedRoot(t))
+	if got := second.Run(context.TODO(), secondState); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if first.handler == second.handler || !strings.HasPrefix(first.handler, "packer-qemu-aarch64-") {
+		t.Fatalf("handlers = %q and %q, want distinct packer-qemu-aarch64-* handlers", first.handler, second.handler)
+	}
+
+	unregister := `sh -c "echo -1 > ` + filepath.Join(first.binfmtDir, first.handler) + `"`
+	commands = nil
+	if err := first.CleanupFunc(firstState); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range commands {
+		if strings.Contains(command, second.handler) {
+			t.Errorf("cleanup of the first build ran %q, which touches the handler of the second", command)
+		}
+	}
+	if !reflect.DeepEqual(commands[len(commands)-1:], []string{unregister}) {
+		t.Errorf("cleanup commands = %q, want them to end with %q", commands, unregister)
+	}
+}
+
+func TestStepSetupEmulation_hostHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags string
+		want  []string
+	}{
+		{
+			name:  "fix binary",
+			flags: "POCF",
+		},
+		{
+			name:  "interpreter in the chroot",
+			flags: "OC",
+			want:  []string{"mkdir -p /ROOT/usr/libexec/qemu-binfmt", "cp QEMU /ROOT/usr/libexec/qemu-binfmt/aarch64-binfmt-P"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			s := testEmulationStep(t, &commands)
+			if err := os.WriteFile(filepath.Join(s.binfmtDir, "qemu-aarch64"), []byte(`enabled
+interpreter /usr/libexec/qemu-binfmt/aarch64-binfmt-P
+flags: `+tt.flags+`
+offset 0
+`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			root := testUsrMergedRoot(t)
+			state, getErr := testEmulationState(root)
+
+			if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+				t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+			}
+			var want []string
+			r := strings.NewReplacer("/ROOT", root, "QEMU", s.QemuBinary)
+			for _, command := range tt.want {
+				want = append(want, r.Replace(command))
+			}
+			if !reflect.DeepEqual(commands, want) {
+				t.Errorf("commands = %q, want %q", commands, want)
+			}
+			if s.handler != "" {
+				t.Errorf("registered handler %q, want the one of the host", s.handler)
+			}
+		})
+	}
+}
+
+func TestStepSetupEmulation_sameArchitecture(t *testing.T) {
+	var commands []string
+	s := testEmulationStep(t, &commands)
+	s.hostArch = "arm64"
+	state, getErr := testEmulationState(testUsrMergedRoot(t))
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if got := state.Get(stateBagKey_ImageArchitecture); got != "Arm64" {
+		t.Errorf("image architecture = %v, want Arm64", got)
+	}
+	if len(commands) != 0 {
+		t.Errorf("commands = %q, want none", commands)
+	}
+	if _, ok := state.GetOk("emulation_cleanup"); ok {
+		t.Error("emulation_cleanup is set")
+	}
+}
+
+func TestStepSetupEmulation_noEmulator(t *testing.T) {
+	var commands []string
+	s := testEmulationStep(t, &commands)
+	s.QemuBinary = filepath.Join(t.TempDir(), "missing")
+	state, getErr := testEmulationState(testUsrMergedRoot(t))
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionHalt {
+		t.Fatalf("Run() = %v, want ActionHalt", got)
+	}
+	if len(commands) != 0 {
+		t.Errorf("commands = %q, want none", commands)
+	}
+	if !strings.Contains(getErr(), "qemu_binary") {
+		t.Errorf("error = %q, want a hint about qemu_binary", getErr())
+	}
+}
+
+func TestStepSetupEmulation_notDetected(t *testing.T) {
+	var commands []string
+	s := testEmulationStep(t, &commands)
+	state, getErr := testEmulationState(t.TempDir())
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if _, ok := state.GetOk(stateBagKey_ImageArchitecture); ok {
+		t.Error("image architecture is set")
+	}
+}
+
+func TestStepSetupEmulation_sharedImageArchitecture(t *testing.T) {
+	tests := []struct {
+		name                   string
+		definitionArchitecture string
+		want                   multistep.StepAction
+	}{
+		{name: "matching", definitionArchitecture: "Arm64", want: multistep.ActionContinue},
+		{name: "case insensitive", definitionArchitecture: "ARM64", want: multistep.ActionContinue},
+		{name: "mismatch", definitionArchitecture: "x64", want: multistep.ActionHalt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			s := testEmulationStep(t, &commands)
+			state, getErr := testEmulationState(testUsrMergedRoot(t))
+			state.Put(stateBagKey_SharedImageArchitecture, tt.definitionArchitecture)
+
+			if got := s.Run(context.TODO(), state); got != tt.want {
+				t.Fatalf("Run() = %v, want %v: %s", got, tt.want, getErr())
+			}
+			if tt.want == multistep.ActionHalt {
+				if !strings.Contains(getErr(), "architecture Arm64") {
+					t.Errorf("error = %q, want a hint about the architecture", getErr())
+				}
+				if len(commands) != 0 {
+					t.Errorf("commands = %q, want none", commands)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepTrimFilesystems{}
+
+// zeroFillFile is the file that fills the free space of filesystems that
+// cannot be trimmed.
+const zeroFillFile = ".packer-zero-fill"
+
+// StepTrimFilesystems discards the free space of the filesystems of the
+// attached disk that are mounted under "mount_path", so that the blocks of
+// files deleted during provisioning are not captured. Filesystems that cannot
+// be trimmed, or all of them when the disk does not support discard, have
+// their free space filled with zeros instead.
+type StepTrimFilesystems struct {
+	run func(multistep.StateBag, string) (string, error)
+}
+
+func NewStepTrimFilesystems(step *StepTrimFilesystems) *StepTrimFilesystems {
+	step.run = runWrappedCommand
+	return step
+}
+
+// mountedFileSystem is a filesystem listed by findmnt.
+type mountedFileSystem struct {
+	Target string
+	Source string
+	FSType string
+}
+
+func (s *StepTrimFilesystems) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	mountPath := state.Get("mount_path").(string)
+	diskDevice := state.Get(stateBagKey_DiskDevice).(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error trimming filesystems: %v", err)
+		log.Printf("StepTrimFilesystems.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	out, err := s.run(state, "lsblk -b -d -n -o DISC-MAX "+shellQuote(diskDevice))
+	if err != nil {
+		return halt(err)
+	}
+	discard := strings.TrimSpace(out) != "" && strings.TrimSpace(out) != "0"
+	if !discard {
+		ui.Say(fmt.Sprintf("%s does not support discard, zero-filling the free space instead of trimming", diskDevice))
+	}
+
+	out, err = s.run(state, fmt.Sprintf("lsblk -P -p -o %s %s", lsblkColumns, shellQuote(diskDevice)))
+	if err != nil {
+		return halt(err)
+	}
+	devices := parseLsblk(out)
+
+	out, err = s.run(state, "findmnt -R -r -n -v -o TARGET,SOURCE,FSTYPE "+shellQuote(mountPath))
+	if err != nil {
+		return halt(err)
+	}
+
+	var total int64
+	for _, fs := range diskFileSystems(parseFindmnt(out), devices) {
+		var reclaimed int64
+		if discard {
+			out, err := s.run(state, "fstrim -v "+shellQuote(fs.Target))
+			if err == nil {
+				reclaimed = parseFstrimBytes(out)
+				ui.Say(fmt.Sprintf("Trimmed %s (%s): %d bytes", fs.Target, fs.FSType, reclaimed))
+				total += reclaimed
+				continue
+			}
+			ui.Say(fmt.Sprintf("Could not trim %s (%s), zero-filling its free space: %v", fs.Target, fs.FSType, err))
+		}
+		reclaimed, err = s.zeroFill(state, fs.Target)
+		if err != nil {
+			return halt(err)
+		}
+		ui.Say(fmt.Sprintf("Zero-filled %s (%s): %d bytes", fs.Target, fs.FSType, reclaimed))
+		total += reclaimed
+	}
+	ui.Say(fmt.Sprintf("Reclaimed %d bytes in total", total))
+	return multistep.ActionContinue
+}
+
+// zeroFill fills the free space of the filesystem mounted at target with
+// zeros and returns the number of bytes written.
+func (s *StepTrimFilesystems) zeroFill(state multistep.StateBag, target string) (int64, error) {
+	file := filepath.Join(target, zeroFillFile)
+	defer func() {
+		if _, err := s.run(state, "rm -f "+shellQuote(file)); err != nil {
+			log.Printf("StepTrimFilesystems: %v", err)
+		}
+	}()
+
+	// dd stops with an error once the filesystem is full, which is expected
+	if _, err := s.run(state, fmt.Sprintf("dd if=/dev/zero of=%s bs=1M conv=fsync status=none", shellQuote(file))); err != nil {
+		log.Printf("StepTrimFilesystems: zero-filling %s: %v", target, err)
+	}
+	out, err := s.run(state, "stat -c %s "+shellQuote(file))
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error reading the size of %s: %v", file, err)
+	}
+	return size, nil
+}
+
+func (*StepTrimFilesystems) Cleanup(multistep.StateBag) {}
+
+// parseFindmnt parses the output of
+// `findmnt -R -r -n -v -o TARGET,SOURCE,FSTYPE`.
+func parseFindmnt(out string) []mountedFileSystem {
+	var mounts []mountedFileSystem
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		mounts = append(mounts, mountedFileSystem{
+			Target: unescapeLsblk(fields[0]),
+			Source: unescapeLsblk(fields[1]),
+			FSType: fields[2],
+		})
+	}
+	return mounts
+}
+
+// diskFileSystems returns the mounts whose source is one of devices, once per
+// device: bind mounts and btrfs subvolumes share the filesystem of the first
+// mount of their device.
+func diskFileSystems(mounts []mountedFileSystem, devices []blockDevice) []mountedFileSystem {
+	onDisk := map[string]bool{}
+	for _, d := range devices {
+		onDisk[d.Name] = true
+	}
+	var fss []mountedFileSystem
+	seen := map[string]bool{}
+	for _, m := range mounts {
+		if !onDisk[m.Source] || seen[m.Source] {
+			continue
+		}
+		seen[m.Source] = true
+		fss = append(fss, m)
+	}
+	return fss
+}
+
+var fstrimBytesRegex = regexp.MustCompile(`\((\d+) bytes\) trimmed`)
+
+// parseFstrimBytes returns the number of bytes reported by `fstrim -v`.
+func parseFstrimBytes(out string) int64 {
+	m := fstrimBytesRegex.FindStringSubmatch(out)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(m[1], 10, 64)
+	return n
+}
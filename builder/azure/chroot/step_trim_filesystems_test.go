@@ -0,0 +1,218 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+const testTrimLsblk = `NAME="/dev/sdc" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE=""
+NAME="/dev/sdc1" UUID="1111-root" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="btrfs"
+NAME="/dev/sdc15" UUID="ABCD-EF01" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="vfat"
+`
+
+const testTrimFindmnt = `/mnt/chroot /dev/sdc1 btrfs
+/mnt/chroot/home /dev/sdc1 btrfs
+/mnt/chroot/boot/efi /dev/sdc15 vfat
+/mnt/chroot/proc proc proc
+/mnt/chroot/dev udev devtmpfs
+/mnt/chroot/opt\x20data /dev/sda1 ext4
+`
+
+func testTrimRunner(discMax string, failFstrim map[string]bool, commands *[]string) func(multistep.StateBag, string) (string, error) {
+	return func(_ multistep.StateBag, command string) (string, error) {
+		*commands = append(*commands, command)
+		switch {
+		case strings.HasPrefix(command, "lsblk -b -d"):
+			return discMax + "\n", nil
+		case strings.HasPrefix(command, "lsblk"):
+			return testTrimLsblk, nil
+		case strings.HasPrefix(command, "findmnt"):
+			return testTrimFindmnt, nil
+		case strings.HasPrefix(command, "fstrim -v "):
+			target := strings.TrimPrefix(command, "fstrim -v ")
+			if failFstrim[target] {
+				return "", errors.New("the discard operation is not supported")
+			}
+			return target + ": 1 GiB (1073741824 bytes) trimmed\n", nil
+		case strings.HasPrefix(command, "dd "):
+			return "", errors.New("No space left on device")
+		case strings.HasPrefix(command, "stat "):
+			return "4096\n", nil
+		}
+		return "", nil
+	}
+}
+
+func TestStepTrimFilesystems(t *testing.T) {
+	var commands []string
+	s := &StepTrimFilesystems{
+		run: testTrimRunner("2147450880", map[string]bool{"/mnt/chroot/boot/efi": true}, &commands),
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"lsblk -b -d -n -o DISC-MAX /dev/sdc",
+		"lsblk -P -p -o " + lsblkColumns + " /dev/sdc",
+		"findmnt -R -r -n -v -o TARGET,SOURCE,FSTYPE /mnt/chroot",
+		"fstrim -v /mnt/chroot",
+		"fstrim -v /mnt/chroot/boot/efi",
+		"dd if=/dev/zero of=/mnt/chroot/boot/efi/.packer-zero-fill bs=1M conv=fsync status=none",
+		"stat -c %s /mnt/chroot/boot/efi/.packer-zero-fill",
+		"rm -f /mnt/chroot/boot/efi/.packer-zero-fill",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestStepTrimFilesystems_noDiscard(t *testing.T) {
+	var commands []string
+	s := &StepTrimFilesystems{
+		run: testTrimRunner("0", nil, &commands),
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	for _, command := range commands {
+		if strings.HasPrefix(command, "fstrim") {
+			t.Errorf("ran %q on a disk without discard support", command)
+		}
+	}
+	var zeroFilled []string
+	for _, command := range commands {
+		if strings.HasPrefix(command, "rm -f ") {
+			zeroFilled = append(zeroFilled, strings.TrimPrefix(command, "rm -f "))
+		}
+	}
+	want := []string{"/mnt/chroot/.packer-zero-fill", "/mnt/chroot/boot/efi/.packer-zero-fill"}
+	if !reflect.DeepEqual(zeroFilled, want) {
+		t.Errorf("zero-filled %q, want %q", zeroFilled, want)
+	}
+}
+
+func TestStepTrimFilesystems_zeroFillError(t *testing.T) {
+	var commands []string
+	run := testTrimRunner("0", nil, &commands)
+	s := &StepTrimFilesystems{
+		run: func(state multistep.StateBag, command string) (string, error) {
+			if strings.HasPrefix(command, "stat ") {
+				commands = append(commands, command)
+				return "", errors.New("no such file")
+			}
+			return run(state, command)
+		},
+	}
+
+	ui, _ := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("mount_path", "/mnt/chroot")
+	state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionHalt {
+		t.Fatalf("Run() = %v, want ActionHalt", got)
+	}
+	if got := commands[len(commands)-1]; got != "rm -f /mnt/chroot/.packer-zero-fill" {
+		t.Errorf("last command = %q, want the zero-fill file to be removed", got)
+	}
+}
+
+func Test_diskFileSystems(t *testing.T) {
+	got := diskFileSystems(parseFindmnt(testTrimFindmnt), parseLsblk(testTrimLsblk))
+	want := []mountedFileSystem{
+		{Target: "/mnt/chroot", Source: "/dev/sdc1", FSType: "btrfs"},
+		{Target: "/mnt/chroot/boot/efi", Source: "/dev/sdc15", FSType: "vfat"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diskFileSystems() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseFindmnt(t *testing.T) {
+	got := parseFindmnt(`/mnt/chroot/opt\x20data /dev/sdc2 ext4` + "\n\n")
+	want := []mountedFileSystem{{Target: "/mnt/chroot/opt data", Source: "/dev/sdc2", FSType: "ext4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFindmnt() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseFstrimBytes(t *testing.T) {
+	tests := []struct {
+		out  string
+		want int64
+	}{
+		{out: "/mnt/chroot: 1.2 GiB (1288490188 bytes) trimmed\n", want: 1288490188},
+		{out: "/mnt/chroot/boot: 0 B (0 bytes) trimmed on /dev/sdc16\n", want: 0},
+		{out: "", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseFstrimBytes(tt.out); got != tt.want {
+			t.Errorf("parseFstrimBytes(%q) = %d, want %d", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestStepTrimFilesystems_untrustedTarget(t *testing.T) {
+	target := "'/mnt/chroot/my data;touch /x'"
+	file := "'/mnt/chroot/my data;touch /x/.packer-zero-fill'"
+	tests := []struct {
+		discMax string
+		want    []string
+	}{
+		{discMax: "2147450880", want: []string{"fstrim -v " + target}},
+		{discMax: "0", want: []string{
+			"dd if=/dev/zero of=" + file + " bs=1M conv=fsync status=none",
+			"stat -c %s " + file,
+			"rm -f " + file,
+		}},
+	}
+	for _, tt := range tests {
+		var commands []string
+		run := testTrimRunner(tt.discMax, nil, &commands)
+		s := &StepTrimFilesystems{
+			run: func(state multistep.StateBag, command string) (string, error) {
+				if strings.HasPrefix(command, "findmnt") {
+					commands = append(commands, command)
+					return "/mnt/chroot /dev/sdc1 btrfs\n/mnt/chroot/my\\x20data;touch\\x20/x /dev/sdc15 vfat\n", nil
+				}
+				return run(state, command)
+			},
+		}
+
+		ui, getErr := testUI()
+		state := new(multistep.BasicStateBag)
+		state.Put("ui", ui)
+		state.Put("mount_path", "/mnt/chroot")
+		state.Put(stateBagKey_DiskDevice, "/dev/sdc")
+
+		if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+			t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+		}
+		if got := commands[len(commands)-len(tt.want):]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commands = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepUnlockLUKS{}
+
+// StepUnlockLUKS detects a LUKS container on the attached disk or on one of
+// its partitions and opens it with the configured key. It replaces "device"
+// with the mapper device and sets "luks_active", so that StepSetupLVM looks
+// for volume groups inside the container and StepMountDevice does not append
+// a partition number. The container is closed in cleanup, after LVM is
+// deactivated and before the disk is detached.
+type StepUnlockLUKS struct {
+	// Passphrase is the key of the container. When empty, the key is read
+	// from the Key Vault secret KeyVaultSecretID.
+	Passphrase       string
+	KeyVaultSecretID string
+	ClientConfig     *client.Config
+	// MountPartition is the partition holding the container when the disk
+	// has several LUKS partitions.
+	MountPartition string
+
+	// mapperName is the name of the opened container (for cleanup).
+	mapperName string
+
+	run       func(multistep.StateBag, string) (string, error)
+	getSecret func(ctx context.Context, config *client.Config, secretID string) (string, error)
+}
+
+func NewStepUnlockLUKS(step *StepUnlockLUKS) *StepUnlockLUKS {
+	step.run = runWrappedCommand
+	step.getSecret = getKeyVaultSecret
+	return step
+}
+
+func (s *StepUnlockLUKS) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	device := state.Get("device").(string)
+
+	halt := func(err error) multistep.StepAction {
+		err = fmt.Errorf("error unlocking LUKS volume: %v", err)
+		log.Printf("StepUnlockLUKS.Run: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	// make sure udev has probed the partitions of the freshly attached disk
+	if _, err := s.run(state, "udevadm settle"); err != nil {
+		return halt(err)
+	}
+	out, err := s.run(state, fmt.Sprintf("lsblk -P -p -o %s %s", lsblkColumns, device))
+	if err != nil {
+		return halt(err)
+	}
+	container, err := s.findContainer(device, parseLsblk(out))
+	if err != nil {
+		return halt(err)
+	}
+	if container == "" {
+		ui.Say(fmt.Sprintf("No LUKS volume found on %s", device))
+		return multistep.ActionContinue
+	}
+
+	key := s.Passphrase
+	if key == "" {
+		ui.Say("Reading the LUKS key from Key Vault...")
+		key, err = s.getSecret(ctx, s.ClientConfig, s.KeyVaultSecretID)
+		if err != nil {
+			return halt(fmt.Errorf("error reading luks_key_vault_secret_id: %v", err))
+		}
+		packersdk.LogSecretFilter.Set(key)
+	}
+
+	// The key is passed in a file readable only by the current user so that
+	// it does not show up in the process list or the logs.
+	keyFile, err := os.CreateTemp("", "packer-luks-key")
+	if err != nil {
+		return halt(err)
+	}
+	defer os.Remove(keyFile.Name())
+	_, err = keyFile.WriteString(key)
+	if cerr := keyFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return halt(err)
+	}
+
+	mapperName := "packer-luks-" + filepath.Base(container)
+	ui.Say(fmt.Sprintf("Opening LUKS volume %s as /dev/mapper/%s", container, mapperName))
+	if _, err := s.run(state, fmt.Sprintf("cryptsetup open --key-file %s %s %s", keyFile.Name(), container, mapperName)); err != nil {
+		return halt(err)
+	}
+	s.mapperName = mapperName
+	state.Put("luks_cleanup", s)
+
+	state.Put("device", "/dev/mapper/"+mapperName)
+	state.Put("luks_active", true)
+	return multistep.ActionContinue
+}
+
+// findContainer returns the LUKS device among the devices of the disk: the
+// disk itself, the partition MountPartition or the only LUKS partition.
+func (s *StepUnlockLUKS) findContainer(device string, devices []blockDevice) (string, error) {
+	var containers []string
+	for _, d := range devices {
+		if d.FSType == "crypto_LUKS" {
+			containers = append(containers, d.Name)
+		}
+	}
+	switch len(containers) {
+	case 0:
+		return "", nil
+	case 1:
+		return containers[0], nil
+	}
+	if s.MountPartition != "" {
+		partition := partitionDevice(device, s.MountPartition)
+		for _, c := range containers {
+			if c == partition {
+				return c, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("found several LUKS volumes on %s (%v), set mount_partition to the one holding the root filesystem", device, containers)
+}
+
+func (s *StepUnlockLUKS) Cleanup(state multistep.StateBag) {
+	if err := s.CleanupFunc(state); err != nil {
+		ui := state.Get("ui").(packersdk.Ui)
+		ui.Error(err.Error())
+	}
+}
+
+// CleanupFunc closes the LUKS container opened by this step.
+func (s *StepUnlockLUKS) CleanupFunc(state multistep.StateBag) error {
+	if s.mapperName == "" {
+		return nil
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	ui.Say(fmt.Sprintf("Closing LUKS volume %s", s.mapperName))
+	if _, err := s.run(state, "cryptsetup close "+s.mapperName); err != nil {
+		return fmt.Errorf("error closing LUKS volume %s: %v", s.mapperName, err)
+	}
+	s.mapperName = ""
+	return nil
+}
+
+// getKeyVaultSecret returns the value of the Key Vault secret secretID.
+func getKeyVaultSecret(ctx context.Context, config *client.Config, secretID string) (string, error) {
+	vaultURI, name, version, err := client.ParseSecretURI(secretID)
+	if err != nil {
+		return "", err
+	}
+	secretsClient, err := client.NewAuthorizedSecretsClient(ctx, config, vaultURI)
+	if err != nil {
+		return "", err
+	}
+	secret, err := client.GetSecret(ctx, secretsClient, name, version)
+	if err != nil {
+		return "", err
+	}
+	if secret.Model == nil {
+		return "", fmt.Errorf("the secret %q has no value", secretID)
+	}
+	return secret.Model.Value, nil
+}
@@ -0,0 +1,213 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+const testLsblkLUKS = `NAME="/dev/sdc" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE=""
+NAME="/dev/sdc1" UUID="2222-boot" LABEL="" PARTUUID="aaaa-1" PARTLABEL="" FSTYPE="ext4"
+NAME="/dev/sdc2" UUID="3333-luks" LABEL="" PARTUUID="aaaa-2" PARTLABEL="" FSTYPE="crypto_LUKS"
+`
+
+// fakeLUKSRunner records the commands it runs and the content of the key file
+// passed to cryptsetup open.
+type fakeLUKSRunner struct {
+	lsblk    string
+	commands []string
+	key      string
+	keyFile  string
+}
+
+func (r *fakeLUKSRunner) run(_ multistep.StateBag, command string) (string, error) {
+	if strings.HasPrefix(command, "cryptsetup open --key-file ") {
+		fields := strings.Fields(command)
+		r.keyFile = fields[3]
+		key, err := os.ReadFile(r.keyFile)
+		if err != nil {
+			return "", err
+		}
+		r.key = string(key)
+		command = strings.Replace(command, r.keyFile, "KEYFILE", 1)
+	}
+	r.commands = append(r.commands, command)
+	if strings.HasPrefix(command, "lsblk") {
+		return r.lsblk, nil
+	}
+	return "", nil
+}
+
+func TestStepUnlockLUKS(t *testing.T) {
+	r := &fakeLUKSRunner{lsblk: testLsblkLUKS}
+	s := &StepUnlockLUKS{
+		Passphrase: "s3cr3t",
+		run:        r.run,
+		getSecret: func(context.Context, *client.Config, string) (string, error) {
+			t.Error("unexpected Key Vault call")
+			return "", nil
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("device", "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	want := []string{
+		"udevadm settle",
+		"lsblk -P -p -o " + lsblkColumns + " /dev/sdc",
+		"cryptsetup open --key-file KEYFILE /dev/sdc2 packer-luks-sdc2",
+	}
+	if !reflect.DeepEqual(r.commands, want) {
+		t.Errorf("commands = %v, want %v", r.commands, want)
+	}
+	if r.key != "s3cr3t" {
+		t.Errorf("key = %q, want %q", r.key, "s3cr3t")
+	}
+	if _, err := os.Stat(r.keyFile); !os.IsNotExist(err) {
+		t.Errorf("key file %s was not removed: %v", r.keyFile, err)
+	}
+	if got := state.Get("device"); got != "/dev/mapper/packer-luks-sdc2" {
+		t.Errorf("device = %v, want /dev/mapper/packer-luks-sdc2", got)
+	}
+	if _, ok := state.GetOk("luks_active"); !ok {
+		t.Error("luks_active is not set")
+	}
+	if state.Get("luks_cleanup") != s {
+		t.Error("luks_cleanup is not set")
+	}
+
+	r.commands = nil
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	// cleanup is idempotent
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cryptsetup close packer-luks-sdc2"}; !reflect.DeepEqual(r.commands, want) {
+		t.Errorf("cleanup commands = %v, want %v", r.commands, want)
+	}
+}
+
+func TestStepUnlockLUKS_keyVault(t *testing.T) {
+	r := &fakeLUKSRunner{lsblk: `NAME="/dev/sdc" UUID="3333-luks" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="crypto_LUKS"`}
+	config := &client.Config{}
+	s := &StepUnlockLUKS{
+		KeyVaultSecretID: "https://myvault.vault.azure.net/secrets/luks",
+		ClientConfig:     config,
+		run:              r.run,
+		getSecret: func(_ context.Context, c *client.Config, secretID string) (string, error) {
+			if c != config || secretID != "https://myvault.vault.azure.net/secrets/luks" {
+				t.Errorf("getSecret(%v, %q)", c, secretID)
+			}
+			return "from-key-vault", nil
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("device", "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if r.key != "from-key-vault" {
+		t.Errorf("key = %q, want %q", r.key, "from-key-vault")
+	}
+	if got := state.Get("device"); got != "/dev/mapper/packer-luks-sdc" {
+		t.Errorf("device = %v, want /dev/mapper/packer-luks-sdc", got)
+	}
+}
+
+func TestStepUnlockLUKS_keyVaultError(t *testing.T) {
+	r := &fakeLUKSRunner{lsblk: testLsblkLUKS}
+	s := &StepUnlockLUKS{
+		KeyVaultSecretID: "https://myvault.vault.azure.net/secrets/luks",
+		run:              r.run,
+		getSecret: func(context.Context, *client.Config, string) (string, error) {
+			return "", errors.New("forbidden")
+		},
+	}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("device", "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionHalt {
+		t.Fatalf("Run() = %v, want ActionHalt", got)
+	}
+	if !strings.Contains(getErr(), "forbidden") {
+		t.Errorf("error = %q, want the Key Vault error", getErr())
+	}
+	if _, ok := state.GetOk("luks_cleanup"); ok {
+		t.Error("luks_cleanup is set")
+	}
+}
+
+func TestStepUnlockLUKS_noLUKS(t *testing.T) {
+	r := &fakeLUKSRunner{lsblk: `NAME="/dev/sdc" UUID="" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE=""
+NAME="/dev/sdc1" UUID="1111-root" LABEL="" PARTUUID="" PARTLABEL="" FSTYPE="ext4"`}
+	s := &StepUnlockLUKS{Passphrase: "s3cr3t", run: r.run}
+
+	ui, getErr := testUI()
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", ui)
+	state.Put("device", "/dev/sdc")
+
+	if got := s.Run(context.TODO(), state); got != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue: %s", got, getErr())
+	}
+	if got := state.Get("device"); got != "/dev/sdc" {
+		t.Errorf("device = %v, want /dev/sdc", got)
+	}
+	if _, ok := state.GetOk("luks_active"); ok {
+		t.Error("luks_active is set")
+	}
+	if err := s.CleanupFunc(state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStepUnlockLUKS_findContainer(t *testing.T) {
+	devices := parseLsblk(`NAME="/dev/sdc1" FSTYPE="crypto_LUKS"
+NAME="/dev/sdc2" FSTYPE="crypto_LUKS"`)
+
+	tests := []struct {
+		name           string
+		mountPartition string
+		want           string
+		wantErr        bool
+	}{
+		{name: "mount partition", mountPartition: "2", want: "/dev/sdc2"},
+		{name: "no mount partition", wantErr: true},
+		{name: "mount partition not LUKS", mountPartition: "3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &StepUnlockLUKS{MountPartition: tt.mountPartition}
+			got, err := s.findContainer("/dev/sdc", devices)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("findContainer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/vaults"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+var _ multistep.Step = &StepVerifyPermissions{}
+
+// StepVerifyPermissions checks that the identity Packer authenticates with has
+// the RBAC permissions needed for the build before any resources are created.
+type StepVerifyPermissions struct {
+	Required []client.RequiredPermission
+	// VHDStorageAccount is the storage account of vhd_destination. Its
+	// resource ID is looked up to check the permission to write the blob.
+	VHDStorageAccount string
+	// LUKSKeyVault is the name of the Key Vault holding the LUKS passphrase.
+	// Its resource ID is looked up to check the permission to read the secret.
+	LUKSKeyVault string
+
+	list         func(ctx context.Context, azcli client.AzureClientSet, scope string) ([]permissions.Permission, error)
+	listAccounts func(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error)
+	listVaults   func(context.Context, client.AzureClientSet) ([]vaults.Vault, error)
+}
+
+func NewStepVerifyPermissions(step *StepVerifyPermissions) *StepVerifyPermissions {
+	step.list = step.listPermissions
+	step.listAccounts = listStorageAccounts
+	step.listVaults = listKeyVaults
+	return step
+}
+
+func (s *StepVerifyPermissions) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	halt := func(err error) multistep.StepAction {
+		log.Printf("StepVerifyPermissions.Run: error: %+v", err)
+		err = fmt.Errorf("Failed to check permissions: %v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Checking permissions ...")
+	required := append([]client.RequiredPermission{}, s.Required...)
+	if s.VHDStorageAccount != "" {
+		account, err := findStorageAccount(ctx, azcli, s.listAccounts, s.VHDStorageAccount)
+		if err != nil {
+			return halt(err)
+		}
+		required = append(required, client.RequiredPermission{
+			Scope:      *account.Id,
+			Action:     "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+			DataAction: true,
+			Reason:     "copying the OS disk to vhd_destination",
+		})
+	}
+	if s.LUKSKeyVault != "" {
+		vault, err := findKeyVault(ctx, azcli, s.listVaults, s.LUKSKeyVault)
+		if err != nil {
+			return halt(err)
+		}
+		required = append(required, client.RequiredPermission{
+			Scope:      *vault.Id,
+			Action:     "Microsoft.KeyVault/vaults/secrets/getSecret/action",
+			DataAction: true,
+			Reason:     "reading the LUKS passphrase from luks_key_vault_secret_id",
+		})
+	}
+
+	list := func(ctx context.Context, scope string) ([]permissions.Permission, error) {
+		return s.list(ctx, azcli, scope)
+	}
+	missing, err := client.FindMissingPermissions(ctx, list, required)
+	if err != nil {
+		return halt(err)
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("The identity used by Packer is missing permissions required for this build:\n%s",
+			client.FormatMissingPermissions(missing))
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepVerifyPermissions) listPermissions(ctx context.Context, azcli client.AzureClientSet, scope string) ([]permissions.Permission, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	return client.NewPermissionsLister(azcli.PermissionsClient())(pollingContext, scope)
+}
+
+func (*StepVerifyPermissions) Cleanup(multistep.StateBag) {}
+
+// requiredPermissions returns the RBAC actions the chroot build performs for
+// config while running on the VM described by info.
+func requiredPermissions(config Config, info *client.ComputeInfo) []client.RequiredPermission {
+	var required []client.RequiredPermission
+	add := func(scope, action, reason string) {
+		if scope != "" {
+			required = append(required, client.RequiredPermission{Scope: scope, Action: action, Reason: reason})
+		}
+	}
+
+	if vmssVMID, ok := scaleSetVMID(info); ok {
+		add(vmssVMID.ID(), "Microsoft.Compute/virtualMachineScaleSets/virtualMachines/write", "attaching disks to the Packer VM")
+	} else {
+		add(info.GetResourceID(), "Microsoft.Compute/virtualMachines/write", "attaching disks to the Packer VM")
+	}
+	add(resourceGroupScope(config.TemporaryOSDiskID), "Microsoft.Compute/disks/write", "creating the temporary OS disk")
+	switch config.sourceType {
+	case sourceSharedImage:
+		add(resourceGroupScope(config.TemporaryDataDiskIDPrefix), "Microsoft.Compute/disks/write", "creating the temporary data disks")
+	case sourceManagedImage:
+		add(config.Source, "Microsoft.Compute/images/read", "reading the source image")
+	case sourceSnapshot:
+		add(config.Source, "Microsoft.Compute/snapshots/read", "copying the source snapshot")
+	case sourceVHD:
+		add(commonids.NewSubscriptionID(info.SubscriptionID).ID(), "Microsoft.Storage/storageAccounts/read", "finding the storage account of the source VHD")
+	}
+
+	if config.SkipCreateImage {
+		return required
+	}
+
+	if config.ImageResourceID != "" {
+		add(resourceGroupScope(config.ImageResourceID), "Microsoft.Compute/images/write", "creating the managed image")
+	}
+	e, _ := config.SharedImageGalleryDestination.Validate("")
+	hasValidSharedImage := len(e) == 0
+	hasValidVHD := len(config.VHDDestination.Validate("")) == 0
+	if hasValidSharedImage || hasValidVHD {
+		add(resourceGroupScope(config.TemporaryOSDiskSnapshotID), "Microsoft.Compute/snapshots/write", "creating the temporary snapshots")
+	}
+	if hasValidVHD {
+		add(resourceGroupScope(config.TemporaryOSDiskSnapshotID), "Microsoft.Compute/snapshots/beginGetAccess/action", "reading the OS disk snapshot to copy it to the VHD")
+	}
+	if hasValidSharedImage {
+		galleryImageID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/galleries/%s/images/%s",
+			info.SubscriptionID,
+			config.SharedImageGalleryDestination.ResourceGroup,
+			config.SharedImageGalleryDestination.GalleryName,
+			config.SharedImageGalleryDestination.ImageName)
+		add(galleryImageID, "Microsoft.Compute/galleries/images/versions/write", "publishing to the shared image gallery")
+	}
+
+	return required
+}
+
+// vhdStorageAccount returns the storage account of the VHD the build copies
+// the OS disk to, if any.
+func vhdStorageAccount(config Config) string {
+	if config.SkipCreateImage || len(config.VHDDestination.Validate("")) > 0 {
+		return ""
+	}
+	return config.VHDDestination.StorageAccount
+}
+
+// luksKeyVault returns the name of the Key Vault holding the LUKS passphrase,
+// if any.
+func luksKeyVault(config Config) string {
+	vaultURI, _, _, err := client.ParseSecretURI(config.LUKSKeyVaultSecretID)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(vaultURI)
+	if err != nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(u.Hostname(), ".")
+	return name
+}
+
+func findKeyVault(ctx context.Context, azcli client.AzureClientSet,
+	list func(context.Context, client.AzureClientSet) ([]vaults.Vault, error), name string) (*vaults.Vault, error) {
+	vs, err := list(ctx, azcli)
+	if err != nil {
+		return nil, err
+	}
+	for _, vault := range vs {
+		if vault.Name != nil && vault.Id != nil && strings.EqualFold(*vault.Name, name) {
+			return &vault, nil
+		}
+	}
+	return nil, fmt.Errorf("Key Vault %q not found in subscription %q", name, azcli.SubscriptionID())
+}
+
+func listKeyVaults(ctx context.Context, azcli client.AzureClientSet) ([]vaults.Vault, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	result, err := azcli.VaultsClient().ListBySubscriptionComplete(pollingContext, commonids.NewSubscriptionID(azcli.SubscriptionID()), vaults.DefaultListBySubscriptionOperationOptions())
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// resourceGroupScope returns the ID of the resource group containing the
+// resource with the given ID, or an empty string if the ID cannot be parsed.
+func resourceGroupScope(resourceID string) string {
+	r, err := client.ParseResourceID(resourceID)
+	if err != nil {
+		return ""
+	}
+	return commonids.NewResourceGroupID(r.Subscription, r.ResourceGroup).ID()
+}
@@ -0,0 +1,311 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/vaults"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func Test_StepVerifyPermissions_Run(t *testing.T) {
+	diskScope := "/subscriptions/subid1/resourceGroups/rg1"
+	required := []client.RequiredPermission{
+		{Scope: diskScope, Action: "Microsoft.Compute/disks/write", Reason: "creating the temporary OS disk"},
+		{Scope: diskScope, Action: "Microsoft.Compute/images/write", Reason: "creating the managed image"},
+	}
+
+	tests := []struct {
+		name       string
+		granted    []string
+		listError  error
+		want       multistep.StepAction
+		errormatch string
+	}{
+		{
+			name:    "HappyPath",
+			granted: []string{"Microsoft.Compute/*"},
+			want:    multistep.ActionContinue,
+		},
+		{
+			name:       "MissingPermission",
+			granted:    []string{"Microsoft.Compute/disks/*"},
+			want:       multistep.ActionHalt,
+			errormatch: "Microsoft.Compute/images/write",
+		},
+		{
+			name:       "ListError",
+			listError:  fmt.Errorf("403"),
+			want:       multistep.ActionHalt,
+			errormatch: "Failed to check permissions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			s := StepVerifyPermissions{
+				Required: required,
+				list: func(ctx context.Context, azcli client.AzureClientSet, scope string) ([]permissions.Permission, error) {
+					calls++
+					if scope != diskScope {
+						t.Errorf("Unexpected scope %q", scope)
+					}
+					return []permissions.Permission{{Actions: &tt.granted}}, tt.listError
+				},
+			}
+
+			ui, getErr := testUI()
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{})
+			state.Put("ui", ui)
+
+			got := s.Run(context.TODO(), state)
+			if got != tt.want {
+				t.Errorf("StepVerifyPermissions.Run() = %v, want %v", got, tt.want)
+			}
+			if calls != 1 {
+				t.Errorf("Expected permissions to be listed once per scope, got %d calls", calls)
+			}
+			if tt.errormatch != "" {
+				errs := getErr()
+				if !regexp.MustCompile(tt.errormatch).MatchString(errs) {
+					t.Errorf("Expected the error output (%q) to match %q", errs, tt.errormatch)
+				}
+				if _, ok := state.GetOk("error"); !ok {
+					t.Fatal("Expected 'error' to be set in statebag after failure")
+				}
+			}
+		})
+	}
+}
+
+func Test_requiredPermissions(t *testing.T) {
+	info := &client.ComputeInfo{
+		Name:              "packervm",
+		ResourceGroupName: "vmrg",
+		SubscriptionID:    "subid1",
+	}
+	config := Config{
+		TemporaryOSDiskID:         "/subscriptions/subid1/resourceGroups/diskrg/providers/Microsoft.Compute/disks/osdisk",
+		TemporaryOSDiskSnapshotID: "/subscriptions/subid1/resourceGroups/snaprg/providers/Microsoft.Compute/snapshots/ossnap",
+		ImageResourceID:           "/subscriptions/subid1/resourceGroups/imagerg/providers/Microsoft.Compute/images/image",
+		SharedImageGalleryDestination: SharedImageGalleryDestination{
+			ResourceGroup: "galleryrg",
+			GalleryName:   "gallery",
+			ImageName:     "image",
+			ImageVersion:  "1.0.0",
+		},
+	}
+
+	want := map[string]string{
+		"Microsoft.Compute/virtualMachines/write":           info.GetResourceID(),
+		"Microsoft.Compute/disks/write":                     "/subscriptions/subid1/resourceGroups/diskrg",
+		"Microsoft.Compute/images/write":                    "/subscriptions/subid1/resourceGroups/imagerg",
+		"Microsoft.Compute/snapshots/write":                 "/subscriptions/subid1/resourceGroups/snaprg",
+		"Microsoft.Compute/galleries/images/versions/write": "/subscriptions/subid1/resourceGroups/galleryrg/providers/Microsoft.Compute/galleries/gallery/images/image",
+	}
+	got := requiredPermissions(config, info)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d permissions, got %d: %+v", len(want), len(got), got)
+	}
+	for _, p := range got {
+		if want[p.Action] != p.Scope {
+			t.Errorf("Expected %s on %q, got %q", p.Action, want[p.Action], p.Scope)
+		}
+	}
+
+	config.SkipCreateImage = true
+	for _, p := range requiredPermissions(config, info) {
+		switch p.Action {
+		case "Microsoft.Compute/images/write", "Microsoft.Compute/galleries/images/versions/write":
+			t.Errorf("Expected %s not to be required with skip_create_image", p.Action)
+		}
+	}
+
+	info.VmScaleSetName = "packervmss"
+	info.ResourceID = "/subscriptions/subid1/resourceGroups/vmrg/providers/Microsoft.Compute/virtualMachineScaleSets/packervmss/virtualMachines/3"
+	p := requiredPermissions(config, info)[0]
+	if p.Action != "Microsoft.Compute/virtualMachineScaleSets/virtualMachines/write" || p.Scope != info.ResourceID {
+		t.Errorf("Expected the scale set VM to be updated on a uniform scale set instance, got %s on %q", p.Action, p.Scope)
+	}
+}
+
+func Test_requiredPermissions_source(t *testing.T) {
+	info := &client.ComputeInfo{
+		Name:              "packervm",
+		ResourceGroupName: "vmrg",
+		SubscriptionID:    "subid1",
+	}
+	tests := []struct {
+		sourceType sourceType
+		source     string
+		action     string
+		scope      string
+	}{
+		{
+			sourceType: sourceManagedImage,
+			source:     "/subscriptions/subid1/resourceGroups/imagerg/providers/Microsoft.Compute/images/source",
+			action:     "Microsoft.Compute/images/read",
+			scope:      "/subscriptions/subid1/resourceGroups/imagerg/providers/Microsoft.Compute/images/source",
+		},
+		{
+			sourceType: sourceSnapshot,
+			source:     "/subscriptions/subid1/resourceGroups/snaprg/providers/Microsoft.Compute/snapshots/source",
+			action:     "Microsoft.Compute/snapshots/read",
+			scope:      "/subscriptions/subid1/resourceGroups/snaprg/providers/Microsoft.Compute/snapshots/source",
+		},
+		{
+			sourceType: sourceVHD,
+			source:     "https://account.blob.core.windows.net/vhds/source.vhd",
+			action:     "Microsoft.Storage/storageAccounts/read",
+			scope:      "/subscriptions/subid1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sourceType), func(t *testing.T) {
+			config := Config{
+				Source:     tt.source,
+				sourceType: tt.sourceType,
+			}
+			config.SkipCreateImage = true
+			for _, p := range requiredPermissions(config, info) {
+				if p.Action == tt.action {
+					if p.Scope != tt.scope {
+						t.Errorf("Expected %s on %q, got %q", tt.action, tt.scope, p.Scope)
+					}
+					return
+				}
+			}
+			t.Errorf("Expected %s to be required", tt.action)
+		})
+	}
+}
+
+func Test_StepVerifyPermissions_Run_vhdStorageAccount(t *testing.T) {
+	accountID := "/subscriptions/subid1/resourceGroups/storagerg/providers/Microsoft.Storage/storageAccounts/vhdaccount"
+	tests := []struct {
+		name        string
+		dataActions []string
+		want        multistep.StepAction
+		errormatch  string
+	}{
+		{
+			name:        "Granted",
+			dataActions: []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/*"},
+			want:        multistep.ActionContinue,
+		},
+		{
+			name:        "ReadOnly",
+			dataActions: []string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"},
+			want:        multistep.ActionHalt,
+			errormatch:  "blobs/write",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StepVerifyPermissions{
+				VHDStorageAccount: "vhdaccount",
+				list: func(ctx context.Context, azcli client.AzureClientSet, scope string) ([]permissions.Permission, error) {
+					if scope != accountID {
+						t.Errorf("Unexpected scope %q", scope)
+					}
+					return []permissions.Permission{{DataActions: &tt.dataActions}}, nil
+				},
+				listAccounts: func(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error) {
+					return []storageaccounts.StorageAccount{
+						{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/otherrg/providers/Microsoft.Storage/storageAccounts/other"), Name: common.StringPtr("other")},
+						{Id: common.StringPtr(accountID), Name: common.StringPtr("vhdaccount")},
+					}, nil
+				},
+			}
+
+			ui, getErr := testUI()
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{})
+			state.Put("ui", ui)
+
+			if got := s.Run(context.TODO(), state); got != tt.want {
+				t.Errorf("StepVerifyPermissions.Run() = %v, want %v: %s", got, tt.want, getErr())
+			}
+			if tt.errormatch != "" && !regexp.MustCompile(tt.errormatch).MatchString(getErr()) {
+				t.Errorf("Expected the error output (%q) to match %q", getErr(), tt.errormatch)
+			}
+		})
+	}
+}
+
+func Test_StepVerifyPermissions_Run_luksKeyVault(t *testing.T) {
+	vaultID := "/subscriptions/subid1/resourceGroups/vaultrg/providers/Microsoft.KeyVault/vaults/myvault"
+	tests := []struct {
+		name        string
+		dataActions []string
+		want        multistep.StepAction
+		errormatch  string
+	}{
+		{
+			name:        "Granted",
+			dataActions: []string{"Microsoft.KeyVault/vaults/secrets/getSecret/action"},
+			want:        multistep.ActionContinue,
+		},
+		{
+			name:        "MetadataOnly",
+			dataActions: []string{"Microsoft.KeyVault/vaults/secrets/readMetadata/action"},
+			want:        multistep.ActionHalt,
+			errormatch:  "getSecret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StepVerifyPermissions{
+				LUKSKeyVault: "myvault",
+				list: func(ctx context.Context, azcli client.AzureClientSet, scope string) ([]permissions.Permission, error) {
+					if scope != vaultID {
+						t.Errorf("Unexpected scope %q", scope)
+					}
+					return []permissions.Permission{{DataActions: &tt.dataActions}}, nil
+				},
+				listVaults: func(context.Context, client.AzureClientSet) ([]vaults.Vault, error) {
+					return []vaults.Vault{
+						{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/vaultrg/providers/Microsoft.KeyVault/vaults/other"), Name: common.StringPtr("other")},
+						{Id: common.StringPtr(vaultID), Name: common.StringPtr("MyVault")},
+					}, nil
+				},
+			}
+
+			ui, getErr := testUI()
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{})
+			state.Put("ui", ui)
+
+			if got := s.Run(context.TODO(), state); got != tt.want {
+				t.Errorf("StepVerifyPermissions.Run() = %v, want %v: %s", got, tt.want, getErr())
+			}
+			if tt.errormatch != "" && !regexp.MustCompile(tt.errormatch).MatchString(getErr()) {
+				t.Errorf("Expected the error output (%q) to match %q", getErr(), tt.errormatch)
+			}
+		})
+	}
+}
+
+func Test_luksKeyVault(t *testing.T) {
+	for id, want := range map[string]string{
+		"": "",
+		"https://myvault.vault.azure.net/secrets/luks-key":            "myvault",
+		"https://myvault.privatelink.vaultcore.azure.net/secrets/k/1": "myvault",
+	} {
+		var config Config
+		config.LUKSKeyVaultSecretID = id
+		if got := luksKeyVault(config); got != want {
+			t.Errorf("luksKeyVault(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
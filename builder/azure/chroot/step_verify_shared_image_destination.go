@@ -73,7 +73,7 @@ func (s *StepVerifySharedImageDestination) Run(ctx context.Context, state multis
 		return errorMessage("Error retrieving shared image %q: ID field in response is empty", imageURI)
 	}
 	if image.Properties == nil {
-		return errorMessage("Could not retrieve shared image properties for image %q.", image.Id)
+		return errorMessage("Could not retrieve shared image properties for image %q.", *image.Id)
 	}
 
 	location := image.Location
@@ -102,6 +102,12 @@ func (s *StepVerifySharedImageDestination) Run(ctx context.Context, state multis
 		image.Location,
 	))
 
+	architecture := galleryimages.ArchitectureXSixFour
+	if image.Properties.Architecture != nil {
+		architecture = *image.Properties.Architecture
+	}
+	state.Put(stateBagKey_SharedImageArchitecture, string(architecture))
+
 	// TODO Suggest moving gallery image ID to common IDs library
 	// so we don't have to define two different versions of the same resource ID
 	galleryImageIDForList := galleryimageversions.NewGalleryImageID(
@@ -122,7 +128,7 @@ func (s *StepVerifySharedImageDestination) Run(ctx context.Context, state multis
 
 	for _, version := range versions {
 		if version.Name == nil {
-			return errorMessage("Could not retrieve versions for image %q: unexpected nil name", image.Id)
+			return errorMessage("Could not retrieve versions for image %q: unexpected nil name", *image.Id)
 		}
 		if *version.Name == s.Image.ImageVersion {
 			return errorMessage("Shared image version %q already exists for image %q.", s.Image.ImageVersion, *image.Id)
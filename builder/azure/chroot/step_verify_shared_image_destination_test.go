@@ -28,6 +28,9 @@ func TestStepVerifySharedImageDestination_Run(t *testing.T) {
 		fields  fields
 		want    multistep.StepAction
 		wantErr string
+		// wantArchitecture is the architecture of the image definition put in
+		// the state bag
+		wantArchitecture string
 	}{
 		{
 			name: "happy path",
@@ -41,6 +44,21 @@ func TestStepVerifySharedImageDestination_Run(t *testing.T) {
 				},
 				Location: "region1",
 			},
+			wantArchitecture: "x64",
+		},
+		{
+			name: "Arm64 image",
+			want: multistep.ActionContinue,
+			fields: fields{
+				Image: SharedImageGalleryDestination{
+					ResourceGroup: "rg",
+					GalleryName:   "gallery",
+					ImageName:     "armimage",
+					ImageVersion:  "1.2.3",
+				},
+				Location: "region1",
+			},
+			wantArchitecture: "Arm64",
 		},
 		{
 			name:    "not found",
@@ -120,6 +138,16 @@ func TestStepVerifySharedImageDestination_Run(t *testing.T) {
 								OsType: galleryimages.OperatingSystemTypesLinux,
 							},
 						}, nil
+					case id.ImageName == "armimage" && id.GalleryName == "gallery" && id.ResourceGroupName == "rg":
+						arm64 := galleryimages.ArchitectureArmSixFour
+						return &galleryimages.GalleryImage{
+							Id:       common.StringPtr("arm-image-resourceid-goes-here"),
+							Location: "region1",
+							Properties: &galleryimages.GalleryImageProperties{
+								OsType:       galleryimages.OperatingSystemTypesLinux,
+								Architecture: &arm64,
+							},
+						}, nil
 					case id.ImageName == "windowsimage" && id.GalleryName == "gallery" && id.ResourceGroupName == "rg":
 						return &galleryimages.GalleryImage{
 							Id:       common.StringPtr("windows-image-resourceid-goes-here"),
@@ -151,6 +179,12 @@ func TestStepVerifySharedImageDestination_Run(t *testing.T) {
 			} else if tt.wantErr != "" {
 				t.Errorf("Expected error, but didn't get any")
 			}
+
+			if tt.wantArchitecture != "" {
+				if got := state.Get(stateBagKey_SharedImageArchitecture); got != tt.wantArchitecture {
+					t.Errorf("Architecture = %v, want %v", got, tt.wantArchitecture)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepVerifySourceImage checks that the source managed image is in the
+// subscription, location and zone of this VM, and records the snapshot or VHD
+// blob the image was created from as the source of the OS disk: Azure cannot
+// create a managed disk from a managed image directly. An image captured from a
+// managed disk is recorded instead, to be published to a temporary gallery by
+// StepCreateTemporaryGalleryImage.
+type StepVerifySourceImage struct {
+	SourceImageResourceID string
+	Location              string
+	Zone                  string
+
+	// OSDisk is set to the source of the OS disk of the image.
+	OSDisk *osDiskSource
+
+	get  func(context.Context, client.AzureClientSet, images.ImageId) (*images.Image, error)
+	list func(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error)
+}
+
+func NewStepVerifySourceImage(step *StepVerifySourceImage) *StepVerifySourceImage {
+	step.get = step.getImage
+	step.list = listStorageAccounts
+	return step
+}
+
+func (s *StepVerifySourceImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	errorMessage := func(format string, params ...interface{}) multistep.StepAction {
+		err := fmt.Errorf(format, params...)
+		log.Printf("StepVerifySourceImage.Run: error: %+v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Checking source image location and zone")
+	resource, err := client.ParseResourceID(s.SourceImageResourceID)
+	if err != nil {
+		return errorMessage("Could not parse resource id %q: %s", s.SourceImageResourceID, err)
+	}
+
+	if !strings.EqualFold(resource.Subscription, azcli.SubscriptionID()) {
+		return errorMessage("Source image resource %q is in a different subscription than this VM (%q). "+
+			"Packer does not know how to handle that.",
+			s.SourceImageResourceID, azcli.SubscriptionID())
+	}
+
+	if !(strings.EqualFold(resource.Provider, "Microsoft.Compute") && strings.EqualFold(resource.ResourceType.String(), "images")) {
+		return errorMessage("Resource ID %q is not a managed image resource", s.SourceImageResourceID)
+	}
+
+	imageID := images.NewImageID(azcli.SubscriptionID(), resource.ResourceGroup, resource.ResourceName.String())
+	image, err := s.get(ctx, azcli, imageID)
+	if err != nil {
+		return errorMessage("Unable to retrieve image (%q): %s", s.SourceImageResourceID, err)
+	}
+
+	if !strings.EqualFold(image.Location, s.Location) {
+		return errorMessage("Source image resource %q is in a different location (%q) than this VM (%q). "+
+			"Packer does not know how to handle that.",
+			s.SourceImageResourceID,
+			image.Location,
+			s.Location)
+	}
+	if err := verifySourceZone(image.ExtendedLocation, s.Zone); err != nil {
+		return errorMessage("Source image resource %q %s", s.SourceImageResourceID, err)
+	}
+
+	if image.Properties == nil || image.Properties.StorageProfile == nil {
+		return errorMessage("Source image %q has no storage profile", s.SourceImageResourceID)
+	}
+	storageProfile := image.Properties.StorageProfile
+	if storageProfile.DataDisks != nil && len(*storageProfile.DataDisks) > 0 {
+		ui.Say(fmt.Sprintf("Source image has %d data disks, only the OS disk is used", len(*storageProfile.DataDisks)))
+	}
+
+	osDisk := storageProfile.OsDisk
+	switch {
+	case osDisk == nil:
+		return errorMessage("Source image %q has no OS disk", s.SourceImageResourceID)
+	case osDisk.Snapshot != nil && osDisk.Snapshot.Id != nil:
+		s.OSDisk.ResourceID = *osDisk.Snapshot.Id
+	case osDisk.ManagedDisk != nil && osDisk.ManagedDisk.Id != nil:
+		// The disk may have changed since the image was captured, a copy of
+		// it would not be the image. A gallery image version created from the
+		// image holds its content.
+		ui.Say(fmt.Sprintf("Source image was captured from managed disk %q, publishing it to a temporary gallery", *osDisk.ManagedDisk.Id))
+		s.OSDisk.ManagedImageID = s.SourceImageResourceID
+		s.OSDisk.HyperVGeneration = string(images.HyperVGenerationTypesVOne)
+		if image.Properties.HyperVGeneration != nil {
+			s.OSDisk.HyperVGeneration = string(*image.Properties.HyperVGeneration)
+		}
+		s.OSDisk.OSState = string(osDisk.OsState)
+	case osDisk.BlobUri != nil:
+		accountName, err := parseVHDURI(*osDisk.BlobUri)
+		if err != nil {
+			return errorMessage("Could not parse the VHD URI %q of source image %q: %s", *osDisk.BlobUri, s.SourceImageResourceID, err)
+		}
+		account, err := findStorageAccount(ctx, azcli, s.list, accountName)
+		if err != nil {
+			return errorMessage("Unable to find the storage account of the VHD of source image %q: %s", s.SourceImageResourceID, err)
+		}
+		s.OSDisk.BlobURI = *osDisk.BlobUri
+		s.OSDisk.StorageAccountID = *account.Id
+	default:
+		return errorMessage("Source image %q does not reference the snapshot, managed disk or VHD it was created from", s.SourceImageResourceID)
+	}
+
+	log.Printf("StepVerifySourceImage.Run: using OS disk source %+v", *s.OSDisk)
+	return multistep.ActionContinue
+}
+
+func (s *StepVerifySourceImage) getImage(ctx context.Context, azcli client.AzureClientSet, id images.ImageId) (*images.Image, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	imageResult, err := azcli.ImagesClient().Get(pollingContext, id, images.DefaultGetOperationOptions())
+	if err != nil {
+		return nil, err
+	}
+	if imageResult.Model == nil {
+		return nil, client.NullModelSDKErr
+	}
+	return imageResult.Model, nil
+}
+
+func (*StepVerifySourceImage) Cleanup(multistep.StateBag) {}
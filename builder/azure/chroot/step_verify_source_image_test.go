@@ -0,0 +1,169 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/edgezones"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func Test_StepVerifySourceImage_Run(t *testing.T) {
+	image := func(location string, osDisk images.ImageOSDisk) *images.Image {
+		return &images.Image{
+			Location: location,
+			Properties: &images.ImageProperties{
+				StorageProfile: &images.ImageStorageProfile{
+					OsDisk: &osDisk,
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		imageID    string
+		image      *images.Image
+		want       multistep.StepAction
+		wantOSDisk osDiskSource
+		errormatch string
+	}{
+		{
+			name:    "FromSnapshot",
+			imageID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image: image("westus2", images.ImageOSDisk{
+				Snapshot:    &images.SubResource{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1")},
+				ManagedDisk: &images.SubResource{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk1")},
+			}),
+			want:       multistep.ActionContinue,
+			wantOSDisk: osDiskSource{ResourceID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1"},
+		},
+		{
+			name:    "FromDisk",
+			imageID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image: image("westus2", images.ImageOSDisk{
+				ManagedDisk: &images.SubResource{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk1")},
+				OsState:     images.OperatingSystemStateTypesGeneralized,
+			}),
+			want: multistep.ActionContinue,
+			wantOSDisk: osDiskSource{
+				ManagedImageID:   "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+				HyperVGeneration: "V1",
+				OSState:          "Generalized",
+			},
+		},
+		{
+			name:    "FromDiskV2",
+			imageID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image: func() *images.Image {
+				i := image("westus2", images.ImageOSDisk{
+					ManagedDisk: &images.SubResource{Id: common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk1")},
+					OsState:     images.OperatingSystemStateTypesSpecialized,
+				})
+				generation := images.HyperVGenerationTypesVTwo
+				i.Properties.HyperVGeneration = &generation
+				return i
+			}(),
+			want: multistep.ActionContinue,
+			wantOSDisk: osDiskSource{
+				ManagedImageID:   "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+				HyperVGeneration: "V2",
+				OSState:          "Specialized",
+			},
+		},
+		{
+			name:    "FromVHD",
+			imageID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image: image("westus2", images.ImageOSDisk{
+				BlobUri: common.StringPtr("https://account.blob.core.windows.net/vhds/source.vhd"),
+			}),
+			want: multistep.ActionContinue,
+			wantOSDisk: osDiskSource{
+				BlobURI:          "https://account.blob.core.windows.net/vhds/source.vhd",
+				StorageAccountID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/account",
+			},
+		},
+		{
+			name:       "NoSource",
+			imageID:    "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image:      image("westus2", images.ImageOSDisk{}),
+			want:       multistep.ActionHalt,
+			errormatch: "does not reference",
+		},
+		{
+			name:       "OtherLocation",
+			imageID:    "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image:      image("eastus", images.ImageOSDisk{}),
+			want:       multistep.ActionHalt,
+			errormatch: "different location",
+		},
+		{
+			name:    "EdgeZone",
+			imageID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			image: func() *images.Image {
+				i := image("westus2", images.ImageOSDisk{})
+				i.ExtendedLocation = &edgezones.Model{Name: "losangeles"}
+				return i
+			}(),
+			want:       multistep.ActionHalt,
+			errormatch: "edge zone \"losangeles\"",
+		},
+		{
+			name:       "NotAnImage",
+			imageID:    "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk1",
+			want:       multistep.ActionHalt,
+			errormatch: "not a managed image",
+		},
+		{
+			name:       "OtherSubscription",
+			imageID:    "/subscriptions/subid2/resourceGroups/rg1/providers/Microsoft.Compute/images/image1",
+			want:       multistep.ActionHalt,
+			errormatch: "different subscription",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StepVerifySourceImage{
+				SourceImageResourceID: tt.imageID,
+				Location:              "westus2",
+				OSDisk:                &osDiskSource{},
+				get: func(ctx context.Context, azcli client.AzureClientSet, id images.ImageId) (*images.Image, error) {
+					if tt.image == nil {
+						t.Fatalf("expected getImage to not be called but it was")
+					}
+					return tt.image, nil
+				},
+				list: testStorageAccounts,
+			}
+
+			ui, getErr := testUI()
+
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{
+				SubscriptionIDMock: "subid1",
+			})
+			state.Put("ui", ui)
+
+			got := s.Run(context.TODO(), state)
+			if got != tt.want {
+				t.Errorf("StepVerifySourceImage.Run() = %v, want %v", got, tt.want)
+			}
+			if *s.OSDisk != tt.wantOSDisk {
+				t.Errorf("OSDisk = %+v, want %+v", *s.OSDisk, tt.wantOSDisk)
+			}
+
+			if tt.errormatch != "" {
+				errs := getErr()
+				if !regexp.MustCompile(tt.errormatch).MatchString(errs) {
+					t.Errorf("Expected the error output (%q) to match %q", errs, tt.errormatch)
+				}
+			}
+		})
+	}
+}
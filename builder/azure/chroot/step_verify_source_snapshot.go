@@ -0,0 +1,95 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepVerifySourceSnapshot checks that the source snapshot exists in the
+// subscription, location and zone of this VM.
+type StepVerifySourceSnapshot struct {
+	SourceSnapshotResourceID string
+	Location                 string
+	Zone                     string
+
+	get func(context.Context, client.AzureClientSet, snapshots.SnapshotId) (*snapshots.Snapshot, error)
+}
+
+func NewStepVerifySourceSnapshot(step *StepVerifySourceSnapshot) *StepVerifySourceSnapshot {
+	step.get = step.getSnapshot
+	return step
+}
+
+func (s StepVerifySourceSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	errorMessage := func(format string, params ...interface{}) multistep.StepAction {
+		err := fmt.Errorf(format, params...)
+		log.Printf("StepVerifySourceSnapshot.Run: error: %+v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Checking source snapshot location and zone")
+	resource, err := client.ParseResourceID(s.SourceSnapshotResourceID)
+	if err != nil {
+		return errorMessage("Could not parse resource id %q: %s", s.SourceSnapshotResourceID, err)
+	}
+
+	if !strings.EqualFold(resource.Subscription, azcli.SubscriptionID()) {
+		return errorMessage("Source snapshot resource %q is in a different subscription than this VM (%q). "+
+			"Packer does not know how to handle that.",
+			s.SourceSnapshotResourceID, azcli.SubscriptionID())
+	}
+
+	if !(strings.EqualFold(resource.Provider, "Microsoft.Compute") && strings.EqualFold(resource.ResourceType.String(), "snapshots")) {
+		return errorMessage("Resource ID %q is not a snapshot resource", s.SourceSnapshotResourceID)
+	}
+
+	snapshotID := snapshots.NewSnapshotID(azcli.SubscriptionID(), resource.ResourceGroup, resource.ResourceName.String())
+	snapshot, err := s.get(ctx, azcli, snapshotID)
+	if err != nil {
+		return errorMessage("Unable to retrieve snapshot (%q): %s", s.SourceSnapshotResourceID, err)
+	}
+
+	if !strings.EqualFold(snapshot.Location, s.Location) {
+		return errorMessage("Source snapshot resource %q is in a different location (%q) than this VM (%q). "+
+			"Packer does not know how to handle that.",
+			s.SourceSnapshotResourceID,
+			snapshot.Location,
+			s.Location)
+	}
+	if err := verifySourceZone(snapshot.ExtendedLocation, s.Zone); err != nil {
+		return errorMessage("Source snapshot resource %q %s", s.SourceSnapshotResourceID, err)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s StepVerifySourceSnapshot) getSnapshot(ctx context.Context, azcli client.AzureClientSet, id snapshots.SnapshotId) (*snapshots.Snapshot, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	snapshotResult, err := azcli.SnapshotsClient().Get(pollingContext, id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotResult.Model == nil {
+		return nil, client.NullModelSDKErr
+	}
+	return snapshotResult.Model, nil
+}
+
+func (StepVerifySourceSnapshot) Cleanup(multistep.StateBag) {}
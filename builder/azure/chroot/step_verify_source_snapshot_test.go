@@ -0,0 +1,127 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/edgezones"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func Test_StepVerifySourceSnapshot_Run(t *testing.T) {
+	snapshotWithCorrectLocation := snapshots.Snapshot{
+		Location: "westus2",
+	}
+	tests := []struct {
+		name             string
+		snapshotID       string
+		location         string
+		getSnapshot      *snapshots.Snapshot
+		getSnapshotError error
+		want             multistep.StepAction
+		errormatch       string
+	}{
+		{
+			name:        "HappyPath",
+			snapshotID:  "/subscriptions/subid1/resourcegroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1",
+			location:    "westus2",
+			getSnapshot: &snapshotWithCorrectLocation,
+			want:        multistep.ActionContinue,
+		},
+		{
+			name:       "NotAResourceID",
+			snapshotID: "/other",
+			location:   "westus2",
+			want:       multistep.ActionHalt,
+			errormatch: "Could not parse resource id",
+		},
+		{
+			name:             "SnapshotNotFound",
+			snapshotID:       "/subscriptions/subid1/resourcegroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1",
+			location:         "westus2",
+			getSnapshotError: fmt.Errorf("404"),
+			want:             multistep.ActionHalt,
+			errormatch:       "Unable to retrieve",
+		},
+		{
+			name:       "NotASnapshot",
+			snapshotID: "/subscriptions/subid1/resourcegroups/rg1/providers/Microsoft.Compute/disks/disk1",
+			location:   "westus2",
+			want:       multistep.ActionHalt,
+			errormatch: "not a snapshot",
+		},
+		{
+			name:       "OtherSubscription",
+			snapshotID: "/subscriptions/subid2/resourcegroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1",
+			location:   "westus2",
+			want:       multistep.ActionHalt,
+			errormatch: "different subscription",
+		},
+		{
+			name:        "OtherLocation",
+			snapshotID:  "/subscriptions/subid1/resourcegroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1",
+			location:    "eastus",
+			getSnapshot: &snapshotWithCorrectLocation,
+			want:        multistep.ActionHalt,
+			errormatch:  "different location",
+		},
+		{
+			name:       "EdgeZone",
+			snapshotID: "/subscriptions/subid1/resourcegroups/rg1/providers/Microsoft.Compute/snapshots/snapshot1",
+			location:   "westus2",
+			getSnapshot: &snapshots.Snapshot{
+				Location:         "westus2",
+				ExtendedLocation: &edgezones.Model{Name: "losangeles"},
+			},
+			want:       multistep.ActionHalt,
+			errormatch: "edge zone",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StepVerifySourceSnapshot{
+				SourceSnapshotResourceID: tt.snapshotID,
+				Location:                 tt.location,
+				get: func(ctx context.Context, azcli client.AzureClientSet, id snapshots.SnapshotId) (*snapshots.Snapshot, error) {
+					if tt.getSnapshot == nil && tt.getSnapshotError == nil {
+						t.Fatalf("expected getSnapshot to not be called but it was")
+					}
+					return tt.getSnapshot, tt.getSnapshotError
+				},
+			}
+
+			ui, getErr := testUI()
+
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{
+				SubscriptionIDMock: "subid1",
+			})
+			state.Put("ui", ui)
+
+			got := s.Run(context.TODO(), state)
+			if got != tt.want {
+				t.Errorf("StepVerifySourceSnapshot.Run() = %v, want %v", got, tt.want)
+			}
+
+			if tt.errormatch != "" {
+				errs := getErr()
+				if !regexp.MustCompile(tt.errormatch).MatchString(errs) {
+					t.Errorf("Expected the error output (%q) to match %q", errs, tt.errormatch)
+				}
+			}
+
+			if got == multistep.ActionHalt {
+				if _, ok := state.GetOk("error"); !ok {
+					t.Fatal("Expected 'error' to be set in statebag after failure")
+				}
+			}
+		})
+	}
+}
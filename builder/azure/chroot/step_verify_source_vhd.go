@@ -0,0 +1,140 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/edgezones"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepVerifySourceVHD checks that the storage account of the source VHD blob
+// is in the subscription, location and zone of this VM, and records the blob
+// as the import source of the OS disk.
+type StepVerifySourceVHD struct {
+	SourceVHDURI string
+	Location     string
+	Zone         string
+
+	// OSDisk is set to the blob and its storage account.
+	OSDisk *osDiskSource
+
+	list func(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error)
+}
+
+func NewStepVerifySourceVHD(step *StepVerifySourceVHD) *StepVerifySourceVHD {
+	step.list = listStorageAccounts
+	return step
+}
+
+func (s *StepVerifySourceVHD) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	azcli := state.Get("azureclient").(client.AzureClientSet)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	errorMessage := func(format string, params ...interface{}) multistep.StepAction {
+		err := fmt.Errorf(format, params...)
+		log.Printf("StepVerifySourceVHD.Run: error: %+v", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Checking source VHD location and zone")
+	accountName, err := parseVHDURI(s.SourceVHDURI)
+	if err != nil {
+		return errorMessage("Could not parse VHD URI %q: %s", s.SourceVHDURI, err)
+	}
+
+	account, err := findStorageAccount(ctx, azcli, s.list, accountName)
+	if err != nil {
+		return errorMessage("Unable to find the storage account of the source VHD (%q): %s", s.SourceVHDURI, err)
+	}
+
+	if !strings.EqualFold(account.Location, s.Location) {
+		return errorMessage("Storage account %q of the source VHD is in a different location (%q) than this VM (%q). "+
+			"Packer does not know how to handle that.",
+			accountName,
+			account.Location,
+			s.Location)
+	}
+	if err := verifySourceZone(account.ExtendedLocation, s.Zone); err != nil {
+		return errorMessage("Storage account %q of the source VHD %s", accountName, err)
+	}
+
+	s.OSDisk.BlobURI = s.SourceVHDURI
+	s.OSDisk.StorageAccountID = *account.Id
+	return multistep.ActionContinue
+}
+
+func (*StepVerifySourceVHD) Cleanup(multistep.StateBag) {}
+
+// parseVHDURI returns the name of the storage account of the blob at uri.
+func parseVHDURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	accountName, suffix, ok := strings.Cut(u.Hostname(), ".")
+	if !ok || !strings.HasPrefix(suffix, "blob.") {
+		return "", fmt.Errorf("%q is not a blob storage endpoint", u.Host)
+	}
+	if container, blob, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/"); !ok || container == "" || blob == "" {
+		return "", fmt.Errorf("%q is not a blob path", u.Path)
+	}
+	return accountName, nil
+}
+
+// verifySourceZone returns an error when a source with extendedLocation
+// cannot be used from the availability zone zone of this VM: resources in an
+// edge zone are only available to the VMs of that edge zone.
+func verifySourceZone(extendedLocation *edgezones.Model, zone string) error {
+	if extendedLocation == nil || extendedLocation.Name == "" {
+		return nil
+	}
+	vmZone := "no availability zone"
+	if zone != "" {
+		vmZone = fmt.Sprintf("availability zone %q", zone)
+	}
+	return fmt.Errorf("is in edge zone %q, but this VM is in %s of its region. "+
+		"Packer does not know how to handle that.", extendedLocation.Name, vmZone)
+}
+
+// findStorageAccount returns the storage account named name in the
+// subscription of this VM.
+func findStorageAccount(ctx context.Context, azcli client.AzureClientSet,
+	list func(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error), name string) (*storageaccounts.StorageAccount, error) {
+	accounts, err := list(ctx, azcli)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.Name != nil && account.Id != nil && strings.EqualFold(*account.Name, name) {
+			return &account, nil
+		}
+	}
+	return nil, fmt.Errorf("storage account %q not found in subscription %q", name, azcli.SubscriptionID())
+}
+
+func listStorageAccounts(ctx context.Context, azcli client.AzureClientSet) ([]storageaccounts.StorageAccount, error) {
+	pollingContext, cancel := context.WithTimeout(ctx, azcli.PollingDuration())
+	defer cancel()
+	result, err := azcli.StorageAccountsClient().ListComplete(pollingContext, commonids.NewSubscriptionID(azcli.SubscriptionID()))
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func Test_parseVHDURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{"https://account.blob.core.windows.net/vhds/source.vhd", "account", false},
+		{"https://account.blob.core.usgovcloudapi.net/vhds/dir/source.vhd?sv=2020-08-04&sig=abc", "account", false},
+		{"https://account.file.core.windows.net/vhds/source.vhd", "", true},
+		{"https://account.blob.core.windows.net/vhds", "", true},
+		{"ftp://account.blob.core.windows.net/vhds/source.vhd", "", true},
+		{"publisher:offer:sku:version", "", true},
+		{"/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Compute/disks/disk1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := parseVHDURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVHDURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVHDURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testStorageAccounts(context.Context, client.AzureClientSet) ([]storageaccounts.StorageAccount, error) {
+	return []storageaccounts.StorageAccount{
+		{
+			Id:       common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/other"),
+			Name:     common.StringPtr("other"),
+			Location: "eastus",
+		},
+		{
+			Id:       common.StringPtr("/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/account"),
+			Name:     common.StringPtr("account"),
+			Location: "westus2",
+		},
+	}, nil
+}
+
+func Test_StepVerifySourceVHD_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		uri        string
+		location   string
+		want       multistep.StepAction
+		wantOSDisk osDiskSource
+		errormatch string
+	}{
+		{
+			name:     "HappyPath",
+			uri:      "https://account.blob.core.windows.net/vhds/source.vhd",
+			location: "westus2",
+			want:     multistep.ActionContinue,
+			wantOSDisk: osDiskSource{
+				BlobURI:          "https://account.blob.core.windows.net/vhds/source.vhd",
+				StorageAccountID: "/subscriptions/subid1/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/account",
+			},
+		},
+		{
+			name:       "OtherLocation",
+			uri:        "https://other.blob.core.windows.net/vhds/source.vhd",
+			location:   "westus2",
+			want:       multistep.ActionHalt,
+			errormatch: "different location",
+		},
+		{
+			name:       "AccountNotFound",
+			uri:        "https://missing.blob.core.windows.net/vhds/source.vhd",
+			location:   "westus2",
+			want:       multistep.ActionHalt,
+			errormatch: `storage account "missing" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StepVerifySourceVHD{
+				SourceVHDURI: tt.uri,
+				Location:     tt.location,
+				OSDisk:       &osDiskSource{},
+				list:         testStorageAccounts,
+			}
+
+			ui, getErr := testUI()
+
+			state := new(multistep.BasicStateBag)
+			state.Put("azureclient", &client.AzureClientSetMock{
+				SubscriptionIDMock: "subid1",
+			})
+			state.Put("ui", ui)
+
+			got := s.Run(context.TODO(), state)
+			if got != tt.want {
+				t.Errorf("StepVerifySourceVHD.Run() = %v, want %v", got, tt.want)
+			}
+			if *s.OSDisk != tt.wantOSDisk {
+				t.Errorf("OSDisk = %+v, want %+v", *s.OSDisk, tt.wantOSDisk)
+			}
+
+			if tt.errormatch != "" {
+				errs := getErr()
+				if !regexp.MustCompile(tt.errormatch).MatchString(errs) {
+					t.Errorf("Expected the error output (%q) to match %q", errs, tt.errormatch)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type VHDDestination
+
+package chroot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// VHDDestination models a page blob in a storage account container that the
+// OS disk is copied to as a fixed VHD, for example for a marketplace
+// submission.
+type VHDDestination struct {
+	// The name of the storage account, which must be in the cloud environment
+	// of the build. The identity Packer authenticates with needs the `Storage
+	// Blob Data Contributor` role on the container.
+	StorageAccount string `mapstructure:"storage_account" required:"true"`
+	// The name of the container, which must exist.
+	ContainerName string `mapstructure:"container_name" required:"true"`
+	// The name of the blob, for example `myimage-{{timestamp}}.vhd`. An
+	// existing blob is overwritten.
+	BlobName string `mapstructure:"blob_name" required:"true"`
+}
+
+var (
+	storageAccountNameRegex = regexp.MustCompile(`^[a-z0-9]{3,24}$`)
+	containerNameRegex      = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+)
+
+// Validate validates that the values of the destination are valid (without
+// checking them on the network)
+func (vd VHDDestination) Validate(prefix string) (errs []error) {
+	if vd.StorageAccount == "" {
+		errs = append(errs, fmt.Errorf("%s.storage_account is required", prefix))
+	} else if !storageAccountNameRegex.MatchString(vd.StorageAccount) {
+		errs = append(errs, fmt.Errorf("%s.storage_account: %q is not a valid storage account name", prefix, vd.StorageAccount))
+	}
+	if vd.ContainerName == "" {
+		errs = append(errs, fmt.Errorf("%s.container_name is required", prefix))
+	} else if !containerNameRegex.MatchString(vd.ContainerName) || strings.Contains(vd.ContainerName, "--") {
+		errs = append(errs, fmt.Errorf("%s.container_name: %q is not a valid container name", prefix, vd.ContainerName))
+	}
+	if vd.BlobName == "" {
+		errs = append(errs, fmt.Errorf("%s.blob_name is required", prefix))
+	} else if len(vd.BlobName) > 1024 || strings.HasSuffix(vd.BlobName, "/") {
+		errs = append(errs, fmt.Errorf("%s.blob_name: %q is not a valid blob name", prefix, vd.BlobName))
+	}
+	return
+}
@@ -0,0 +1,35 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package chroot
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatVHDDestination is an auto-generated flat version of VHDDestination.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatVHDDestination struct {
+	StorageAccount *string `mapstructure:"storage_account" required:"true" cty:"storage_account" hcl:"storage_account"`
+	ContainerName  *string `mapstructure:"container_name" required:"true" cty:"container_name" hcl:"container_name"`
+	BlobName       *string `mapstructure:"blob_name" required:"true" cty:"blob_name" hcl:"blob_name"`
+}
+
+// FlatMapstructure returns a new FlatVHDDestination.
+// FlatVHDDestination is an auto-generated flat version of VHDDestination.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*VHDDestination) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatVHDDestination)
+}
+
+// HCL2Spec returns the hcl spec of a VHDDestination.
+// This spec is used by HCL to read the fields of VHDDestination.
+// The decoded values from this spec will then be applied to a FlatVHDDestination.
+func (*FlatVHDDestination) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"storage_account": &hcldec.AttrSpec{Name: "storage_account", Type: cty.String, Required: false},
+		"container_name":  &hcldec.AttrSpec{Name: "container_name", Type: cty.String, Required: false},
+		"blob_name":       &hcldec.AttrSpec{Name: "blob_name", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package chroot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVHDDestination_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		vd       VHDDestination
+		wantErrs []string
+	}{
+		{
+			name: "complete",
+			vd: VHDDestination{
+				StorageAccount: "mystorageaccount",
+				ContainerName:  "vhds",
+				BlobName:       "images/image.vhd",
+			},
+		},
+		{
+			name:     "missing",
+			vd:       VHDDestination{},
+			wantErrs: []string{"storage_account is required", "container_name is required", "blob_name is required"},
+		},
+		{
+			name: "invalid names",
+			vd: VHDDestination{
+				StorageAccount: "My_Account",
+				ContainerName:  "my--vhds",
+				BlobName:       "images/",
+			},
+			wantErrs: []string{"not a valid storage account name", "not a valid container name", "not a valid blob name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.vd.Validate("vhd_destination")
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("Validate() = %v, want %d errors", errs, len(tt.wantErrs))
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), "vhd_destination.") || !strings.Contains(err.Error(), tt.wantErrs[i]) {
+					t.Errorf("Validate()[%d] = %q, want it to contain %q", i, err, tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package common
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
 )
 
 // Artifact is an artifact implementation that contains built Managed Images or Disks.
@@ -69,12 +71,42 @@ func (a *Artifact) State(name string) interface{} {
 	return a.StateData[name]
 }
 
+// deleteBlob deletes the blob at blobURL, like
+// https://<account>.blob.core.windows.net/<container>/<blob>.
+func (a *Artifact) deleteBlob(blobURL string) error {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return err
+	}
+	accountName, _, _ := strings.Cut(u.Hostname(), ".")
+	containerName, blobName, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if !ok || blobName == "" {
+		return fmt.Errorf("not the URL of a blob")
+	}
+
+	pollingContext, cancel := context.WithTimeout(context.Background(), a.AzureClientSet.PollingDuration())
+	defer cancel()
+	blobsClient, err := a.AzureClientSet.BlobsClient(pollingContext, accountName)
+	if err != nil {
+		return err
+	}
+	_, err = blobsClient.Delete(pollingContext, containerName, blobName, blobs.DeleteInput{})
+	return err
+}
+
 func (a *Artifact) Destroy() error {
 	errs := make([]error, 0)
 
 	for _, resource := range a.Resources {
 		log.Printf("Deleting resource %s", resource)
 
+		if strings.HasPrefix(resource, "https://") {
+			if err := a.deleteBlob(resource); err != nil {
+				errs = append(errs, fmt.Errorf("Unable to delete blob (%s): %v", resource, err))
+			}
+			continue
+		}
+
 		id, err := client.ParseResourceID(resource)
 		if err != nil {
 			return fmt.Errorf("Unable to parse resource id (%s): %v", resource, err)
@@ -4,7 +4,14 @@
 package common
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
 )
 
 func TestArtifact_String(t *testing.T) {
@@ -22,3 +29,33 @@ func TestArtifact_String(t *testing.T) {
 		t.Errorf("Artifact.String() = %v, want %v", got, want)
 	}
 }
+
+func TestArtifact_Destroy_blob(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	blobsClient, err := blobs.NewWithBaseUri(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Artifact{
+		Resources: []string{"https://myaccount.blob.core.windows.net/vhds/images/my%20image.vhd"},
+		AzureClientSet: &client.AzureClientSetMock{
+			BlobsClientMock:     blobsClient,
+			PollingDurationMock: time.Minute,
+		},
+	}
+	if err := a.Destroy(); err != nil {
+		t.Fatalf("Destroy() = %v", err)
+	}
+	if want := []string{"/vhds/images/my image.vhd"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted %q, want %q", deleted, want)
+	}
+}
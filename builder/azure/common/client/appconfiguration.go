@@ -0,0 +1,30 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+// AppConfigurationURI returns the URI of the App Configuration store named
+// storeName in the cloud environment env, for example
+// https://mystore.azconfig.io.
+func AppConfigurationURI(env environments.Environment, storeName string) (string, error) {
+	if env.AppConfiguration == nil {
+		return "", fmt.Errorf("App Configuration is not available in the %q cloud environment", env.Name)
+	}
+	suffix, ok := env.AppConfiguration.DomainSuffix()
+	if !ok || suffix == nil || *suffix == "" {
+		return "", fmt.Errorf("the %q cloud environment does not define an App Configuration DNS suffix", env.Name)
+	}
+	return fmt.Sprintf("https://%s.%s", storeName, *suffix), nil
+}
+
+// ValidateAppConfigurationURI checks that uri is an absolute https URI that
+// can be used as the endpoint of an App Configuration store.
+func ValidateAppConfigurationURI(uri string) error {
+	return validateServiceURI("App Configuration endpoint", uri)
+}
@@ -0,0 +1,42 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+func Test_AppConfigurationURI(t *testing.T) {
+	tests := map[string]*environments.Environment{
+		"https://store1.azconfig.io":       environments.AzurePublic(),
+		"https://store1.azconfig.azure.cn": environments.AzureChina(),
+		"https://store1.azconfig.azure.us": environments.AzureUSGovernment(),
+	}
+	for want, env := range tests {
+		got, err := AppConfigurationURI(*env, "store1")
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", env.Name, err)
+		}
+		if got != want {
+			t.Errorf("AppConfigurationURI(%s) = %q, want %q", env.Name, got, want)
+		}
+	}
+
+	if _, err := AppConfigurationURI(environments.Environment{Name: "Custom"}, "store1"); err == nil {
+		t.Error("Expected an error for an environment without App Configuration")
+	}
+}
+
+func Test_ValidateAppConfigurationURI(t *testing.T) {
+	if err := ValidateAppConfigurationURI("https://store1.azconfig.io"); err != nil {
+		t.Errorf("Expected the endpoint to be valid, got %v", err)
+	}
+	for _, uri := range []string{"store1.azconfig.io", "http://store1.azconfig.io", "https://store1.azconfig.io/kv"} {
+		if err := ValidateAppConfigurationURI(uri); err == nil {
+			t.Errorf("Expected %q to be invalid", uri)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/hashicorp/go-azure-sdk/sdk/auth"
@@ -24,6 +25,8 @@ type AzureAuthOptions struct {
 	OidcRequestToken   string
 	TenantID           string
 	SubscriptionID     string
+	// Transport is used for token requests when set, see Config.Transport
+	Transport http.RoundTripper
 }
 
 func BuildResourceManagerAuthorizer(ctx context.Context, authOpts AzureAuthOptions, env environments.Environment) (auth.Authorizer, error) {
@@ -50,6 +53,16 @@ func BuildKeyVaultAuthorizer(ctx context.Context, authOpts AzureAuthOptions, env
 	return authorizer, nil
 }
 
+// BuildAppConfigurationAuthorizer returns an authorizer for the App
+// Configuration store at endpoint, tokens are scoped to the store.
+func BuildAppConfigurationAuthorizer(ctx context.Context, authOpts AzureAuthOptions, env environments.Environment, endpoint string) (auth.Authorizer, error) {
+	authorizer, err := buildAuthorizer(ctx, authOpts, env, environments.NewApiEndpoint("AppConfiguration", endpoint, nil))
+	if err != nil {
+		return nil, fmt.Errorf("building App Configuration authorizer from credentials: %+v", err)
+	}
+	return authorizer, nil
+}
+
 func buildAuthorizer(ctx context.Context, authOpts AzureAuthOptions, env environments.Environment, api environments.Api) (auth.Authorizer, error) {
 	var authConfig auth.Credentials
 	switch authOpts.AuthType {
@@ -101,11 +114,15 @@ func buildAuthorizer(ctx context.Context, authOpts AzureAuthOptions, env environ
 	default:
 		return nil, fmt.Errorf("Unexpected AuthType %s set when trying to create Azure Client", authOpts.AuthType)
 	}
-	authorizer, err := auth.NewAuthorizerFromCredentials(ctx, authConfig, api)
+	if authOpts.Transport == nil {
+		return auth.NewAuthorizerFromCredentials(ctx, authConfig, api)
+	}
+	// Managed identity tokens use auth.MetadataClient, which is never proxied.
+	authorizer, err := auth.NewAuthorizerFromCredentials(withTokenTransport(ctx, authOpts.Transport), authConfig, api)
 	if err != nil {
 		return nil, err
 	}
-	return authorizer, nil
+	return transportAuthorizer{Authorizer: authorizer, transport: authOpts.Transport}, nil
 }
 
 func GetObjectIdFromToken(token string) (string, error) {
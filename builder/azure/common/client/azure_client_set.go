@@ -12,15 +12,22 @@ import (
 
 	"github.com/hashicorp/packer-plugin-sdk/useragent"
 
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
-	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/disks"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleries"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachinescalesetvms"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/vaults"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
 	"github.com/hashicorp/go-azure-sdk/sdk/auth"
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
 	version "github.com/hashicorp/packer-plugin-azure/version"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
 )
 
 type AzureClientSet interface {
@@ -30,11 +37,22 @@ type AzureClientSet interface {
 	SnapshotsClient() snapshots.SnapshotsClient
 	ImagesClient() images.ImagesClient
 
+	GalleriesClient() galleries.GalleriesClient
 	GalleryImagesClient() galleryimages.GalleryImagesClient
 	GalleryImageVersionsClient() galleryimageversions.GalleryImageVersionsClient
 
 	VirtualMachinesClient() virtualmachines.VirtualMachinesClient
 	VirtualMachineImagesClient() virtualmachineimages.VirtualMachineImagesClient
+	VirtualMachineScaleSetVMsClient() virtualmachinescalesetvms.VirtualMachineScaleSetVMsClient
+
+	PermissionsClient() permissions.PermissionsClient
+
+	StorageAccountsClient() storageaccounts.StorageAccountsClient
+	// BlobsClient returns a client for the blobs of the storage account
+	// accountName, authenticated with Azure AD
+	BlobsClient(ctx context.Context, accountName string) (*blobs.Client, error)
+
+	VaultsClient() vaults.VaultsClient
 
 	// SubscriptionID returns the subscription ID that this client set was created for
 	SubscriptionID() string
@@ -49,6 +67,8 @@ var _ AzureClientSet = &azureClientSet{}
 
 type azureClientSet struct {
 	authorizer                 auth.Authorizer
+	authOptions                AzureAuthOptions
+	cloudEnv                   *environments.Environment
 	subscriptionID             string
 	PollingDelay               time.Duration
 	pollingDuration            time.Duration
@@ -58,8 +78,13 @@ type azureClientSet struct {
 	imagesClient               images.ImagesClient
 	virtualMachinesClient      virtualmachines.VirtualMachinesClient
 	virtualMachineImagesClient virtualmachineimages.VirtualMachineImagesClient
+	vmssVMsClient              virtualmachinescalesetvms.VirtualMachineScaleSetVMsClient
+	galleriesClient            galleries.GalleriesClient
 	galleryImagesClient        galleryimages.GalleryImagesClient
 	galleryImageVersionsClient galleryimageversions.GalleryImageVersionsClient
+	permissionsClient          permissions.PermissionsClient
+	storageAccountsClient      storageaccounts.StorageAccountsClient
+	vaultsClient               vaults.VaultsClient
 }
 
 func New(c Config, say func(string)) (AzureClientSet, error) {
@@ -79,6 +104,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		OidcRequestUrl:     c.OidcRequestURL,
 		OidcRequestToken:   c.OidcRequestToken,
 		SubscriptionID:     c.SubscriptionID,
+		Transport:          c.Transport(),
 	}
 	cloudEnv := c.cloudEnvironment
 	resourceManagerEndpoint, _ := cloudEnv.ResourceManager.Endpoint()
@@ -93,6 +119,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	imagesClient.Client.Authorizer = authorizer
+	ConfigureTransport(imagesClient.Client, c.Transport())
 	imagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), imagesClient.Client.UserAgent)
 
 	galleryImageVersionsClient, err := galleryimageversions.NewGalleryImageVersionsClientWithBaseURI(cloudEnv.ResourceManager)
@@ -100,6 +127,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	galleryImageVersionsClient.Client.Authorizer = authorizer
+	ConfigureTransport(galleryImageVersionsClient.Client, c.Transport())
 	galleryImageVersionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImageVersionsClient.Client.UserAgent)
 
 	galleryImagesClient, err := galleryimages.NewGalleryImagesClientWithBaseURI(cloudEnv.ResourceManager)
@@ -107,6 +135,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	galleryImagesClient.Client.Authorizer = authorizer
+	ConfigureTransport(galleryImagesClient.Client, c.Transport())
 	galleryImagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImagesClient.Client.UserAgent)
 
 	disksClient, err := disks.NewDisksClientWithBaseURI(cloudEnv.ResourceManager)
@@ -114,6 +143,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	disksClient.Client.Authorizer = authorizer
+	ConfigureTransport(disksClient.Client, c.Transport())
 	disksClient.Client.UserAgent = useragent.String(version.AzurePluginVersion.FormattedVersion())
 
 	snapshotsClient, err := snapshots.NewSnapshotsClientWithBaseURI(cloudEnv.ResourceManager)
@@ -121,6 +151,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	snapshotsClient.Client.Authorizer = authorizer
+	ConfigureTransport(snapshotsClient.Client, c.Transport())
 	snapshotsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), snapshotsClient.Client.UserAgent)
 
 	virtualMachinesClient, err := virtualmachines.NewVirtualMachinesClientWithBaseURI(cloudEnv.ResourceManager)
@@ -128,6 +159,7 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	virtualMachinesClient.Client.Authorizer = authorizer
+	ConfigureTransport(virtualMachinesClient.Client, c.Transport())
 	virtualMachinesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), virtualMachinesClient.Client.UserAgent)
 
 	virtualMachineImagesClient, err := virtualmachineimages.NewVirtualMachineImagesClientWithBaseURI(cloudEnv.ResourceManager)
@@ -135,19 +167,67 @@ func new(c Config, say func(string)) (*azureClientSet, error) {
 		return nil, err
 	}
 	virtualMachineImagesClient.Client.Authorizer = authorizer
+	ConfigureTransport(virtualMachineImagesClient.Client, c.Transport())
 	virtualMachineImagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), virtualMachinesClient.Client.UserAgent)
 
+	vmssVMsClient, err := virtualmachinescalesetvms.NewVirtualMachineScaleSetVMsClientWithBaseURI(cloudEnv.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	vmssVMsClient.Client.Authorizer = authorizer
+	ConfigureTransport(vmssVMsClient.Client, c.Transport())
+	vmssVMsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), vmssVMsClient.Client.UserAgent)
+
+	permissionsClient, err := permissions.NewPermissionsClientWithBaseURI(cloudEnv.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	permissionsClient.Client.Authorizer = authorizer
+	ConfigureTransport(permissionsClient.Client, c.Transport())
+	permissionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), permissionsClient.Client.UserAgent)
+
+	storageAccountsClient, err := storageaccounts.NewStorageAccountsClientWithBaseURI(cloudEnv.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	storageAccountsClient.Client.Authorizer = authorizer
+	ConfigureTransport(storageAccountsClient.Client, c.Transport())
+	storageAccountsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), storageAccountsClient.Client.UserAgent)
+
+	galleriesClient, err := galleries.NewGalleriesClientWithBaseURI(cloudEnv.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	galleriesClient.Client.Authorizer = authorizer
+	ConfigureTransport(galleriesClient.Client, c.Transport())
+	galleriesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleriesClient.Client.UserAgent)
+
+	vaultsClient, err := vaults.NewVaultsClientWithBaseURI(cloudEnv.ResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	vaultsClient.Client.Authorizer = authorizer
+	ConfigureTransport(vaultsClient.Client, c.Transport())
+	vaultsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), vaultsClient.Client.UserAgent)
+
 	return &azureClientSet{
 		authorizer:                 authorizer,
+		authOptions:                authOptions,
+		cloudEnv:                   cloudEnv,
 		subscriptionID:             c.SubscriptionID,
 		PollingDelay:               time.Second,
 		imagesClient:               *imagesClient,
+		galleriesClient:            *galleriesClient,
 		galleryImagesClient:        *galleryImagesClient,
 		galleryImageVersionsClient: *galleryImageVersionsClient,
 		disksClient:                *disksClient,
 		virtualMachinesClient:      *virtualMachinesClient,
 		virtualMachineImagesClient: *virtualMachineImagesClient,
+		vmssVMsClient:              *vmssVMsClient,
 		snapshotsClient:            *snapshotsClient,
+		permissionsClient:          *permissionsClient,
+		storageAccountsClient:      *storageAccountsClient,
+		vaultsClient:               *vaultsClient,
 		pollingDuration:            time.Minute * 15,
 		ResourceManagerEndpoint:    *resourceManagerEndpoint,
 	}, nil
@@ -189,6 +269,14 @@ func (s azureClientSet) VirtualMachineImagesClient() virtualmachineimages.Virtua
 	return s.virtualMachineImagesClient
 }
 
+func (s azureClientSet) VirtualMachineScaleSetVMsClient() virtualmachinescalesetvms.VirtualMachineScaleSetVMsClient {
+	return s.vmssVMsClient
+}
+
+func (s azureClientSet) GalleriesClient() galleries.GalleriesClient {
+	return s.galleriesClient
+}
+
 func (s azureClientSet) GalleryImagesClient() galleryimages.GalleryImagesClient {
 	return s.galleryImagesClient
 }
@@ -198,6 +286,37 @@ func (s azureClientSet) GalleryImageVersionsClient() galleryimageversions.Galler
 
 }
 
+func (s azureClientSet) PermissionsClient() permissions.PermissionsClient {
+	return s.permissionsClient
+}
+
+func (s azureClientSet) StorageAccountsClient() storageaccounts.StorageAccountsClient {
+	return s.storageAccountsClient
+}
+
+func (s azureClientSet) VaultsClient() vaults.VaultsClient {
+	return s.vaultsClient
+}
+
+func (s azureClientSet) BlobsClient(ctx context.Context, accountName string) (*blobs.Client, error) {
+	endpoint, err := BlobStorageURI(*s.cloudEnv, accountName)
+	if err != nil {
+		return nil, err
+	}
+	authorizer, err := BuildStorageAuthorizer(ctx, s.authOptions, *s.cloudEnv)
+	if err != nil {
+		return nil, err
+	}
+	blobsClient, err := blobs.NewWithBaseUri(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	blobsClient.Client.Authorizer = authorizer
+	ConfigureTransport(blobsClient.Client, s.authOptions.Transport)
+	blobsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), blobsClient.Client.UserAgent)
+	return blobsClient, nil
+}
+
 func ParsePlatformImageURN(urn string) (image *PlatformImage, err error) {
 	if !platformImageRegex.Match([]byte(urn)) {
 		return nil, fmt.Errorf("%q is not a valid platform image specifier", urn)
@@ -4,16 +4,23 @@
 package client
 
 import (
+	"context"
 	"time"
 
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/images"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
-	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachines"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/disks"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-02/snapshots"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleries"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
 	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachinescalesetvms"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/vaults"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/storage/2023-01-01/storageaccounts"
 	"github.com/hashicorp/go-azure-sdk/sdk/auth"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/blobs"
 )
 
 var _ AzureClientSet = &AzureClientSetMock{}
@@ -25,8 +32,14 @@ type AzureClientSetMock struct {
 	ImagesClientMock               images.ImagesClient
 	VirtualMachinesClientMock      virtualmachines.VirtualMachinesClient
 	VirtualMachineImagesClientMock virtualmachineimages.VirtualMachineImagesClient
+	VMSSVMsClientMock              virtualmachinescalesetvms.VirtualMachineScaleSetVMsClient
+	GalleriesClientMock            galleries.GalleriesClient
 	GalleryImagesClientMock        galleryimages.GalleryImagesClient
 	GalleryImageVersionsClientMock galleryimageversions.GalleryImageVersionsClient
+	PermissionsClientMock          permissions.PermissionsClient
+	StorageAccountsClientMock      storageaccounts.StorageAccountsClient
+	BlobsClientMock                *blobs.Client
+	VaultsClientMock               vaults.VaultsClient
 	MetadataClientMock             MetadataClientAPI
 	SubscriptionIDMock             string
 	PollingDurationMock            time.Duration
@@ -58,6 +71,16 @@ func (m *AzureClientSetMock) VirtualMachinesClient() virtualmachines.VirtualMach
 	return m.VirtualMachinesClientMock
 }
 
+// VirtualMachineScaleSetVMsClient returns a VirtualMachineScaleSetVMsClient
+func (m *AzureClientSetMock) VirtualMachineScaleSetVMsClient() virtualmachinescalesetvms.VirtualMachineScaleSetVMsClient {
+	return m.VMSSVMsClientMock
+}
+
+// GalleriesClient returns a GalleriesClient
+func (m *AzureClientSetMock) GalleriesClient() galleries.GalleriesClient {
+	return m.GalleriesClientMock
+}
+
 // GalleryImagesClient returns a GalleryImagesClient
 func (m *AzureClientSetMock) GalleryImagesClient() galleryimages.GalleryImagesClient {
 	return m.GalleryImagesClientMock
@@ -68,6 +91,26 @@ func (m *AzureClientSetMock) GalleryImageVersionsClient() galleryimageversions.G
 	return m.GalleryImageVersionsClientMock
 }
 
+// PermissionsClient returns a PermissionsClient
+func (m *AzureClientSetMock) PermissionsClient() permissions.PermissionsClient {
+	return m.PermissionsClientMock
+}
+
+// StorageAccountsClient returns a StorageAccountsClient
+func (m *AzureClientSetMock) StorageAccountsClient() storageaccounts.StorageAccountsClient {
+	return m.StorageAccountsClientMock
+}
+
+// VaultsClient returns a VaultsClient
+func (m *AzureClientSetMock) VaultsClient() vaults.VaultsClient {
+	return m.VaultsClientMock
+}
+
+// BlobsClient returns BlobsClientMock, whatever the storage account
+func (m *AzureClientSetMock) BlobsClient(context.Context, string) (*blobs.Client, error) {
+	return m.BlobsClientMock, nil
+}
+
 // MetadataClient returns a MetadataClient
 func (m *AzureClientSetMock) MetadataClient() MetadataClientAPI {
 	return m.MetadataClientMock
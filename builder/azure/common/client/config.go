@@ -92,10 +92,36 @@ type Config struct {
 	// Works with normal authentication (`az login`) and service principals (`az login --service-principal --username APP_ID --password PASSWORD --tenant TENANT_ID`).
 	// Ignores all other configurations if enabled.
 	UseAzureCLIAuth bool `mapstructure:"use_azure_cli_auth" required:"false"`
+
+	// Network fields
+
+	// The URL of an HTTP proxy used for all requests to Azure, for example
+	// `http://proxy.example.com:3128`. This applies to Resource Manager,
+	// storage, Key Vault and token requests. If not set, the `HTTPS_PROXY`
+	// and `HTTP_PROXY` environment variables are honored. Requests to the
+	// Azure Instance Metadata Service (169.254.169.254) are never proxied.
+	HTTPProxy string `mapstructure:"http_proxy" required:"false"`
+	// A comma separated list of hosts, domains and CIDR ranges that should
+	// be reached directly instead of through `http_proxy`, in the same format
+	// as the `NO_PROXY` environment variable. If not set, `NO_PROXY` is honored.
+	NoProxy string `mapstructure:"no_proxy" required:"false"`
+	// The path to a file containing one or more PEM encoded CA certificates
+	// that are trusted in addition to the system roots when connecting to
+	// Azure, for example the root certificate of a TLS inspecting proxy.
+	CABundleFile string `mapstructure:"ca_bundle_file" required:"false"`
+	// The path to a file to which a line of JSON is appended for every HTTP
+	// request sent to Azure, including retries and token requests. Each line
+	// records the method, the URL with SAS signatures and tokens redacted, the
+	// Resource Manager operation, the status code, the duration, the
+	// `x-ms-request-id` and `x-ms-correlation-request-id` headers and the retry
+	// number. Request and response bodies are never written. The correlation
+	// IDs can be handed to Microsoft support when a deployment fails.
+	TraceFile string `mapstructure:"azure_trace_file" required:"false"`
+	transport http.RoundTripper
 }
 
 // allow override for unit tests
-var findTenantID = FindTenantID
+var findTenantID = FindTenantIDWithTransport
 
 const (
 	AuthTypeMSI             = "ManagedIdentity"
@@ -125,6 +151,59 @@ func (c *Config) AuthType() string {
 	return c.authType
 }
 
+// Transport returns the http.RoundTripper shared by all Azure clients, or nil
+// when no network or trace options are configured and the SDK defaults should
+// be used.
+// It is set by FillParameters.
+func (c *Config) Transport() http.RoundTripper {
+	return c.transport
+}
+
+// PrepareTransport builds the HTTP transport returned by Transport from the
+// proxy, CA bundle and trace settings, as FillParameters does. Clients that
+// do not authenticate with the Azure credentials, like the ones using a SAS
+// token or a storage account key, call it instead of FillParameters.
+func (c *Config) PrepareTransport() error {
+	if c.transport != nil {
+		return nil
+	}
+	return c.setTransport()
+}
+
+// Close syncs and closes the azure_trace_file opened by FillParameters, once
+// every configuration of the build tracing to the same file is closed.
+// Builders and data sources call it when they are done with their clients.
+func (c *Config) Close() error {
+	if t, ok := c.transport.(*traceTransport); ok {
+		return t.Close()
+	}
+	return nil
+}
+
+func (c *Config) useCustomTransport() bool {
+	return c.HTTPProxy != "" || c.NoProxy != "" || c.CABundleFile != ""
+}
+
+func (c *Config) setTransport() error {
+	if !c.useCustomTransport() && c.TraceFile == "" {
+		return nil
+	}
+	transport, err := newTransport(c.HTTPProxy, c.NoProxy, c.CABundleFile)
+	if err != nil {
+		return err
+	}
+	c.transport = transport
+
+	if c.TraceFile != "" {
+		tracer, err := newTraceTransport(transport, c.TraceFile)
+		if err != nil {
+			return err
+		}
+		c.transport = tracer
+	}
+	return nil
+}
+
 func (c *Config) setCloudEnvironment() error {
 	if c.MetadataHost == "" {
 		if v := os.Getenv("ARM_METADATA_URL"); v != "" {
@@ -171,6 +250,18 @@ func (c *Config) setCloudEnvironment() error {
 
 //nolint:ineffassign //this triggers a false positive because errs is passed by reference
 func (c Config) Validate(errs *packersdk.MultiError) {
+	if c.HTTPProxy != "" {
+		if err := validateProxyURL(c.HTTPProxy); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+
+	if c.CABundleFile != "" {
+		if _, err := loadCABundle(c.CABundleFile); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+
 	/////////////////////////////////////////////
 	// Authentication via OAUTH
 
@@ -243,6 +334,10 @@ func (c Config) UseMSI() bool {
 // FillParameters capture the user intent from the supplied parameter set in AuthType, retrieves the TenantID and CloudEnvironment if not specified.
 // The SubscriptionID is also retrieved in case MSI auth is requested.
 func (c *Config) FillParameters() error {
+	if err := c.PrepareTransport(); err != nil {
+		return err
+	}
+
 	if c.authType == "" {
 		if c.UseCLI() {
 			c.authType = AuthTypeAzureCLI
@@ -288,7 +383,7 @@ func (c *Config) FillParameters() error {
 
 	// Get Tenant ID from Access token
 	if c.TenantID == "" && !c.UseAzureCLIAuth {
-		tenantID, err := findTenantID(*c.cloudEnvironment, c.SubscriptionID)
+		tenantID, err := findTenantID(*c.cloudEnvironment, c.SubscriptionID, c.transport)
 		if err != nil {
 			return err
 		}
@@ -323,13 +418,19 @@ func getIDsFromAzureCLI() (string, string, error) {
 // unauthenticated request to the Get Subscription Details endpoint and parses
 // the value from WWW-Authenticate header.
 func FindTenantID(env environments.Environment, subscriptionID string) (string, error) {
+	return FindTenantIDWithTransport(env, subscriptionID, nil)
+}
+
+// FindTenantIDWithTransport is FindTenantID sending the request using
+// transport, or the default transport when it is nil.
+func FindTenantIDWithTransport(env environments.Environment, subscriptionID string, transport http.RoundTripper) (string, error) {
 	const hdrKey = "WWW-Authenticate"
 	resourceManagerEndpoint, _ := env.ResourceManager.Endpoint()
 	if resourceManagerEndpoint == nil {
 		return "", fmt.Errorf("invalid environment passed to FindTenantID")
 	}
 	getSubscriptionsEndpoint := fmt.Sprintf("%s/subscriptions/%s?api-version=2022-12-01", *resourceManagerEndpoint, subscriptionID)
-	httpClient := &http.Client{}
+	httpClient := &http.Client{Transport: transport}
 	req, err := http.NewRequest("GET", getSubscriptionsEndpoint, nil)
 	if err != nil {
 		return "", fmt.Errorf("Could not create request to find tenant ID: %s", err.Error())
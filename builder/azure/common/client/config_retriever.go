@@ -13,8 +13,6 @@ import (
 var getSubscriptionFromIMDS = _getSubscriptionFromIMDS
 
 func _getSubscriptionFromIMDS() (string, error) {
-	client := &http.Client{}
-
 	req, _ := http.NewRequest("GET", "http://169.254.169.254/metadata/instance/compute", nil)
 	req.Header.Add("Metadata", "True")
 
@@ -23,7 +21,7 @@ func _getSubscriptionFromIMDS() (string, error) {
 	q.Add("api-version", "2017-08-01")
 
 	req.URL.RawQuery = q.Encode()
-	resp, err := client.Do(req)
+	resp, err := imdsHTTPClient.Do(req)
 	if err != nil {
 		return "", err
 	}
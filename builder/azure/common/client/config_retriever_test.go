@@ -5,6 +5,7 @@ package client
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/hashicorp/go-azure-sdk/sdk/environments"
@@ -32,7 +33,7 @@ func TestConfigRetrieverFillsTenantIDWhenEmpty(t *testing.T) {
 	}
 
 	retrievedTid := "my-tenant-id"
-	findTenantID = func(environments.Environment, string) (string, error) { return retrievedTid, nil }
+	findTenantID = func(environments.Environment, string, http.RoundTripper) (string, error) { return retrievedTid, nil }
 	getSubscriptionFromIMDS = func() (string, error) { return "unittest", nil }
 	if err := c.FillParameters(); err != nil {
 		t.Errorf("Unexpected error when calling c.FillParameters: %v", err)
@@ -49,7 +50,9 @@ func TestConfigRetrieverReturnsErrorWhenTenantIDEmptyAndRetrievalFails(t *testin
 		t.Errorf("Expected TenantID to be %q but got %q", expected, c.TenantID)
 	}
 	errorString := "sorry, I failed"
-	findTenantID = func(environments.Environment, string) (string, error) { return "", errors.New(errorString) }
+	findTenantID = func(environments.Environment, string, http.RoundTripper) (string, error) {
+		return "", errors.New(errorString)
+	}
 	getSubscriptionFromIMDS = func() (string, error) { return "unittest", nil }
 	if err := c.FillParameters(); err != nil && err.Error() != errorString {
 		t.Errorf("Unexpected error when calling c.FillParameters: %v", err)
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"io"
 	mrand "math/rand"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -196,7 +197,7 @@ func Test_ClientConfig_AzureCli_with_subscription_id_set(t *testing.T) {
 
 func Test_ClientConfig_GitHubOIDC(t *testing.T) {
 	retrievedTid := "my-tenant-id"
-	findTenantID = func(environments.Environment, string) (string, error) { return retrievedTid, nil }
+	findTenantID = func(environments.Environment, string, http.RoundTripper) (string, error) { return retrievedTid, nil }
 	cfg := Config{
 		cloudEnvironment: environments.AzurePublic(),
 		OidcRequestToken: "whatever",
@@ -0,0 +1,48 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+// KeyVaultURI returns the URI of the Key Vault named vaultName in the cloud
+// environment env, for example https://myvault.vault.azure.net.
+func KeyVaultURI(env environments.Environment, vaultName string) (string, error) {
+	if env.KeyVault == nil {
+		return "", fmt.Errorf("Key Vault is not available in the %q cloud environment", env.Name)
+	}
+	suffix, ok := env.KeyVault.DomainSuffix()
+	if !ok || suffix == nil || *suffix == "" {
+		return "", fmt.Errorf("the %q cloud environment does not define a Key Vault DNS suffix", env.Name)
+	}
+	return fmt.Sprintf("https://%s.%s", vaultName, *suffix), nil
+}
+
+// ValidateKeyVaultURI checks that uri is an absolute https URI that can be
+// used as the base URI of a Key Vault, such as a private endpoint or custom
+// DNS name.
+func ValidateKeyVaultURI(uri string) error {
+	return validateServiceURI("vault URI", uri)
+}
+
+// validateServiceURI checks that uri, described as name in errors, is an
+// absolute https URI without path, query or fragment.
+func validateServiceURI(name, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("the %s %q is invalid: %v", name, uri, err)
+	}
+	if !strings.EqualFold(u.Scheme, "https") || u.Host == "" {
+		return fmt.Errorf("the %s %q must be an absolute https URI", name, uri)
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("the %s %q must not have a path, query or fragment", name, uri)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	sdkClient "github.com/hashicorp/go-azure-sdk/sdk/client"
+	"github.com/hashicorp/go-azure-sdk/sdk/client/resourcemanager"
+	sdkEnv "github.com/hashicorp/go-azure-sdk/sdk/environments"
+	"github.com/hashicorp/go-azure-sdk/sdk/odata"
+)
+
+const KeyVaultAPIVersion = "7.5"
+
+type Secret struct {
+	secrets.Secret `mapstructure:",squash"`
+	Value          string            `mapstructure:"value"`
+	ContentType    string            `mapstructure:"contentType"`
+	Attributes     *SecretAttributes `mapstructure:"attributes"`
+}
+
+// Version returns the version of the secret, taken from its identifier.
+func (s Secret) Version() string {
+	if s.Id == nil {
+		return ""
+	}
+	return lastSegment(*s.Id)
+}
+
+// SecretAttributes are the management attributes of a Key Vault secret. The
+// times are in seconds since the Unix epoch.
+type SecretAttributes struct {
+	Enabled   *bool  `json:"enabled,omitempty"`
+	NotBefore *int64 `json:"nbf,omitempty"`
+	Expires   *int64 `json:"exp,omitempty"`
+}
+
+// IsActive reports whether a secret with these attributes is enabled and
+// valid at time now.
+func (a *SecretAttributes) IsActive(now time.Time) bool {
+	if a == nil {
+		return true
+	}
+	if a.Enabled != nil && !*a.Enabled {
+		return false
+	}
+	if a.NotBefore != nil && now.Before(time.Unix(*a.NotBefore, 0)) {
+		return false
+	}
+	if a.Expires != nil && !now.Before(time.Unix(*a.Expires, 0)) {
+		return false
+	}
+	return true
+}
+
+// SecretItem is a secret as returned when listing the secrets of a vault,
+// without its value.
+type SecretItem struct {
+	ID          string            `json:"id"`
+	Attributes  *SecretAttributes `json:"attributes,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Managed     bool              `json:"managed,omitempty"`
+}
+
+// Name returns the name of the secret, taken from its identifier.
+func (s SecretItem) Name() string {
+	return lastSegment(s.ID)
+}
+
+type GetSecretResponse struct {
+	HttpResponse *http.Response
+	OData        *odata.OData
+	Model        *Secret
+}
+
+func NewSecretsClientWithBaseURI(sdkApi sdkEnv.Api) (*secrets.SecretsClient, error) {
+	client, err := resourcemanager.NewClient(sdkApi, "secrets", KeyVaultAPIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating SecretsClient: %+v", err)
+	}
+
+	return &secrets.SecretsClient{
+		Client: client,
+	}, nil
+}
+
+// ParseSecretURI splits the URI of a Key Vault secret, such as
+// https://myvault.vault.azure.net/secrets/name/version, into the URI of the
+// vault, the name of the secret and its version, empty for the latest one.
+func ParseSecretURI(uri string) (vaultURI, name, version string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", fmt.Errorf("the secret URI %q is invalid: %v", uri, err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || len(segments) > 3 || segments[0] != "secrets" || segments[1] == "" {
+		return "", "", "", fmt.Errorf("the secret URI %q must be of the form https://<vault>/secrets/<name>[/<version>]", uri)
+	}
+	vaultURI = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	if err := ValidateKeyVaultURI(vaultURI); err != nil {
+		return "", "", "", err
+	}
+	if len(segments) == 3 {
+		version = segments[2]
+	}
+	return vaultURI, segments[1], version, nil
+}
+
+// NewAuthorizedSecretsClient returns a SecretsClient for the vault at
+// vaultURI that authenticates with the credentials of config.
+func NewAuthorizedSecretsClient(ctx context.Context, config *Config, vaultURI string) (*secrets.SecretsClient, error) {
+	client, err := NewSecretsClientWithBaseURI(sdkEnv.NewApiEndpoint("KeyVault", vaultURI, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secrets client: %w", err)
+	}
+
+	authOptions := AzureAuthOptions{
+		AuthType:           config.AuthType(),
+		ClientID:           config.ClientID,
+		ClientSecret:       config.ClientSecret,
+		ClientJWT:          config.ClientJWT,
+		ClientCertPath:     config.ClientCertPath,
+		ClientCertPassword: config.ClientCertPassword,
+		TenantID:           config.TenantID,
+		SubscriptionID:     config.SubscriptionID,
+		OidcRequestUrl:     config.OidcRequestURL,
+		OidcRequestToken:   config.OidcRequestToken,
+		Transport:          config.Transport(),
+	}
+
+	authorizer, err := BuildKeyVaultAuthorizer(ctx, authOptions, *config.CloudEnvironment())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Key Vault authorizer: %w", err)
+	}
+
+	client.Client.SetAuthorizer(authorizer)
+	ConfigureTransport(client.Client, config.Transport())
+	return client, nil
+}
+
+// We are using the SecretsClient from the secrets package, which is a wrapper around the resourcemanager.Client.
+// This allows us to use the same client for both the SecretsClient and the resourcemanager.Client,
+// while still providing the necessary functionality to interact with Azure Key Vault secrets.
+//
+// Using the SecretsClient directly for fetching secrets currently only allows us
+// to get the secret's metadata, and not the actual secret value.
+func GetSecret(ctx context.Context, client *secrets.SecretsClient, name, version string) (result GetSecretResponse, err error) {
+	opts := sdkClient.RequestOptions{
+		ContentType: "application/json; charset=utf-8",
+		ExpectedStatusCodes: []int{
+			http.StatusOK,
+		},
+		HttpMethod: http.MethodGet,
+		Path:       fmt.Sprintf("/secrets/%s/%s", name, version),
+	}
+
+	req, err := client.Client.NewRequest(ctx, opts)
+	if err != nil {
+		return
+	}
+
+	var resp *sdkClient.Response
+	resp, err = req.Execute(ctx)
+	if resp != nil {
+		result.OData = resp.OData
+		result.HttpResponse = resp.Response
+	}
+	if err != nil {
+		return
+	}
+
+	var model Secret
+	result.Model = &model
+	if err = resp.Unmarshal(result.Model); err != nil {
+		return
+	}
+
+	return result, nil
+}
+
+type listSecretsPager struct {
+	NextLink *odata.Link `json:"nextLink"`
+}
+
+func (p *listSecretsPager) NextPageLink() *odata.Link {
+	defer func() {
+		p.NextLink = nil
+	}()
+
+	return p.NextLink
+}
+
+// ListSecrets returns all the secrets of the vault, without their values.
+func ListSecrets(ctx context.Context, client *secrets.SecretsClient) ([]SecretItem, error) {
+	opts := sdkClient.RequestOptions{
+		ContentType: "application/json; charset=utf-8",
+		ExpectedStatusCodes: []int{
+			http.StatusOK,
+		},
+		HttpMethod: http.MethodGet,
+		Pager:      &listSecretsPager{},
+		Path:       "/secrets",
+	}
+
+	req, err := client.Client.NewRequest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := req.ExecutePaged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var values struct {
+		Values []SecretItem `json:"value"`
+	}
+	if err := resp.Unmarshal(&values); err != nil {
+		return nil, err
+	}
+	return values.Values, nil
+}
+
+func lastSegment(id string) string {
+	id = strings.TrimSuffix(id, "/")
+	return id[strings.LastIndex(id, "/")+1:]
+}
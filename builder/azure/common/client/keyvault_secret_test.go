@@ -0,0 +1,29 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+)
+
+func Test_ParseSecretURI(t *testing.T) {
+	vault, name, version, err := ParseSecretURI("https://vault1.vault.azure.net/secrets/token/0123abcd")
+	if err != nil || vault != "https://vault1.vault.azure.net" || name != "token" || version != "0123abcd" {
+		t.Errorf("got %q, %q, %q, %v", vault, name, version, err)
+	}
+	vault, name, version, err = ParseSecretURI("https://vault1.vault.azure.net/secrets/token")
+	if err != nil || vault != "https://vault1.vault.azure.net" || name != "token" || version != "" {
+		t.Errorf("got %q, %q, %q, %v", vault, name, version, err)
+	}
+	for _, uri := range []string{
+		"http://vault1.vault.azure.net/secrets/token",
+		"https://vault1.vault.azure.net/keys/token",
+		"https://vault1.vault.azure.net/secrets/",
+		"https://vault1.vault.azure.net/secrets/token/v1/extra",
+	} {
+		if _, _, _, err := ParseSecretURI(uri); err == nil {
+			t.Errorf("expected an error for %q", uri)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+func Test_KeyVaultURI(t *testing.T) {
+	tests := map[string]*environments.Environment{
+		"https://vault1.vault.azure.net":         environments.AzurePublic(),
+		"https://vault1.vault.azure.cn":          environments.AzureChina(),
+		"https://vault1.vault.usgovcloudapi.net": environments.AzureUSGovernment(),
+	}
+	for want, env := range tests {
+		got, err := KeyVaultURI(*env, "vault1")
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", env.Name, err)
+		}
+		if got != want {
+			t.Errorf("KeyVaultURI(%s) = %q, want %q", env.Name, got, want)
+		}
+	}
+
+	if _, err := KeyVaultURI(environments.Environment{Name: "Custom"}, "vault1"); err == nil {
+		t.Error("Expected an error for an environment without Key Vault")
+	}
+}
+
+func Test_ValidateKeyVaultURI(t *testing.T) {
+	valid := []string{
+		"https://vault1.vault.azure.net",
+		"https://vault1.privatelink.vaultcore.azure.net/",
+		"https://keyvault.internal.example.com:8443",
+	}
+	for _, uri := range valid {
+		if err := ValidateKeyVaultURI(uri); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", uri, err)
+		}
+	}
+
+	invalid := []string{
+		"vault1.vault.azure.net",
+		"http://vault1.vault.azure.net",
+		"https://vault1.vault.azure.net/secrets/foo",
+		"https://vault1.vault.azure.net?x=y",
+		"https://",
+	}
+	for _, uri := range invalid {
+		if err := ValidateKeyVaultURI(uri); err == nil {
+			t.Errorf("Expected %q to be invalid", uri)
+		}
+	}
+}
@@ -4,10 +4,13 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 // DefaultMetadataClient is the default instance metadata client for Azure. Replace this variable for testing purposes only
@@ -45,34 +48,43 @@ type metadataClient struct {
 
 var _ MetadataClientAPI = metadataClient{}
 
-const imdsURL = "http://169.254.169.254/metadata/instance?api-version=2021-02-01"
+// IMDSEndpoint is the address of the Azure Instance Metadata Service.
+const IMDSEndpoint = "http://169.254.169.254"
 
 // VMResourceID returns the resource ID of the current VM
 func (client metadataClient) GetComputeInfo() (*ComputeInfo, error) {
-	httpClient := &http.Client{}
-	req, err := http.NewRequest("GET", imdsURL, nil)
+	var vminfo struct {
+		ComputeInfo `json:"compute"`
+	}
+	err := GetMetadata(context.Background(), IMDSEndpoint, "/metadata/instance", url.Values{"api-version": {"2021-02-01"}}, &vminfo)
 	if err != nil {
 		return nil, err
 	}
+	return &vminfo.ComputeInfo, nil
+}
+
+// GetMetadata queries path of the instance metadata service at endpoint and
+// decodes the JSON response into v.
+func GetMetadata(ctx context.Context, endpoint, path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
 	req.Header.Add("Metadata", "true")
-	resp, err := httpClient.Do(req)
+	resp, err := imdsHTTPClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	//nolint:errcheck
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-	var vminfo struct {
-		ComputeInfo `json:"compute"`
+		return err
 	}
-	err = json.Unmarshal(body, &vminfo)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("instance metadata service returned %s for %s: %s", resp.Status, path, strings.TrimSpace(string(body)))
 	}
-	return &vminfo.ComputeInfo, nil
+	return json.Unmarshal(body, v)
 }
 
 func (ci ComputeInfo) GetResourceID() string {
@@ -0,0 +1,124 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+)
+
+// RequiredPermission is an Azure RBAC action that a build needs to be able to
+// perform on a scope.
+type RequiredPermission struct {
+	// Scope is the subscription, resource group or resource ID the action is
+	// performed on.
+	Scope string
+	// Action is the RBAC operation, for example Microsoft.Compute/images/write.
+	Action string
+	// DataAction is set when Action is a data plane operation, for example a
+	// blob write.
+	DataAction bool
+	// Reason explains which part of the configuration needs the permission.
+	Reason string
+}
+
+// PermissionsLister returns the effective permissions of the caller on a scope.
+type PermissionsLister func(ctx context.Context, scope string) ([]permissions.Permission, error)
+
+// NewPermissionsLister returns a PermissionsLister backed by the Microsoft.Authorization
+// permissions API.
+func NewPermissionsLister(c permissions.PermissionsClient) PermissionsLister {
+	return func(ctx context.Context, scope string) ([]permissions.Permission, error) {
+		result, err := c.ListForResourceComplete(ctx, commonids.NewScopeID(scope))
+		if err != nil {
+			return nil, err
+		}
+		return result.Items, nil
+	}
+}
+
+// FindMissingPermissions returns the entries of required that are not granted
+// to the caller. The permissions of each scope are only requested once.
+func FindMissingPermissions(ctx context.Context, list PermissionsLister, required []RequiredPermission) ([]RequiredPermission, error) {
+	granted := make(map[string][]permissions.Permission)
+	var missing []RequiredPermission
+	for _, r := range required {
+		key := strings.ToLower(r.Scope)
+		perms, ok := granted[key]
+		if !ok {
+			var err error
+			perms, err = list(ctx, r.Scope)
+			if err != nil {
+				return nil, fmt.Errorf("could not list permissions on %q: %v", r.Scope, err)
+			}
+			granted[key] = perms
+		}
+		if !IsPermitted(perms, r.Action, r.DataAction) {
+			missing = append(missing, r)
+		}
+	}
+	return missing, nil
+}
+
+// IsPermitted reports whether action is allowed by any of the permission
+// sets, taking wildcards and not-actions into account. Deny assignments are
+// not considered.
+func IsPermitted(granted []permissions.Permission, action string, dataAction bool) bool {
+	for _, p := range granted {
+		allowed, denied := p.Actions, p.NotActions
+		if dataAction {
+			allowed, denied = p.DataActions, p.NotDataActions
+		}
+		if matchesAnyAction(allowed, action) && !matchesAnyAction(denied, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyAction(patterns *[]string, action string) bool {
+	if patterns == nil {
+		return false
+	}
+	for _, pattern := range *patterns {
+		if actionPatternRegexp(pattern).MatchString(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// actionPatternRegexp converts an RBAC action pattern such as
+// Microsoft.Compute/*/read into a case insensitive regular expression.
+func actionPatternRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+// FormatMissingPermissions renders missing permissions as a table that can be
+// shown to the user.
+func FormatMissingPermissions(missing []RequiredPermission) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tSCOPE\tREQUIRED FOR")
+	for _, m := range missing {
+		action := m.Action
+		if m.DataAction {
+			action += " (data action)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", action, m.Scope, m.Reason)
+	}
+	_ = w.Flush()
+	return buf.String()
+}
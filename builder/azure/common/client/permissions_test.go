@@ -0,0 +1,124 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/authorization/2022-04-01/permissions"
+)
+
+func Test_IsPermitted(t *testing.T) {
+	contributor := permissions.Permission{
+		Actions: &[]string{"*"},
+		NotActions: &[]string{
+			"Microsoft.Authorization/*/Delete",
+			"Microsoft.Authorization/*/Write",
+		},
+	}
+	blobWriter := permissions.Permission{
+		DataActions: &[]string{"Microsoft.Storage/storageAccounts/blobServices/containers/blobs/*"},
+	}
+	computeReader := permissions.Permission{
+		Actions: &[]string{"Microsoft.Compute/*/read"},
+	}
+
+	tests := []struct {
+		name       string
+		granted    []permissions.Permission
+		action     string
+		dataAction bool
+		want       bool
+	}{
+		{
+			name:    "wildcard",
+			granted: []permissions.Permission{contributor},
+			action:  "Microsoft.Compute/images/write",
+			want:    true,
+		},
+		{
+			name:    "not action",
+			granted: []permissions.Permission{contributor},
+			action:  "Microsoft.Authorization/roleAssignments/write",
+		},
+		{
+			name:    "case insensitive",
+			granted: []permissions.Permission{contributor},
+			action:  "microsoft.authorization/roleassignments/WRITE",
+		},
+		{
+			name:    "wildcard in the middle",
+			granted: []permissions.Permission{computeReader},
+			action:  "Microsoft.Compute/galleries/images/read",
+			want:    true,
+		},
+		{
+			name:    "wildcard in the middle does not match other operations",
+			granted: []permissions.Permission{computeReader},
+			action:  "Microsoft.Compute/galleries/images/write",
+		},
+		{
+			name:       "actions do not grant data actions",
+			granted:    []permissions.Permission{contributor},
+			action:     "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+			dataAction: true,
+		},
+		{
+			name:       "data action",
+			granted:    []permissions.Permission{contributor, blobWriter},
+			action:     "Microsoft.Storage/storageAccounts/blobServices/containers/blobs/write",
+			dataAction: true,
+			want:       true,
+		},
+		{
+			name:   "no permissions",
+			action: "Microsoft.Compute/images/write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermitted(tt.granted, tt.action, tt.dataAction); got != tt.want {
+				t.Errorf("IsPermitted(%q) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_FindMissingPermissions(t *testing.T) {
+	granted := map[string][]permissions.Permission{
+		"/subscriptions/sub/resourceGroups/build": {{Actions: &[]string{"Microsoft.Compute/*"}}},
+		"/subscriptions/sub/resourceGroups/image": {{Actions: &[]string{"*/read"}}},
+	}
+	calls := map[string]int{}
+	list := func(_ context.Context, scope string) ([]permissions.Permission, error) {
+		calls[scope]++
+		return granted[scope], nil
+	}
+
+	required := []RequiredPermission{
+		{Scope: "/subscriptions/sub/resourceGroups/build", Action: "Microsoft.Compute/virtualMachines/write"},
+		{Scope: "/subscriptions/sub/resourceGroups/BUILD", Action: "Microsoft.Compute/disks/write"},
+		{Scope: "/subscriptions/sub/resourceGroups/image", Action: "Microsoft.Compute/images/write", Reason: "creating the managed image"},
+	}
+	missing, err := FindMissingPermissions(context.TODO(), list, required)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(missing) != 1 || missing[0].Action != "Microsoft.Compute/images/write" {
+		t.Fatalf("Expected only Microsoft.Compute/images/write to be missing, got %+v", missing)
+	}
+	if len(calls) != 2 {
+		t.Errorf("Expected permissions to be listed once per scope, got %v", calls)
+	}
+
+	table := FormatMissingPermissions(missing)
+	for _, s := range []string{"ACTION", "Microsoft.Compute/images/write", "/subscriptions/sub/resourceGroups/image", "creating the managed image"} {
+		if !strings.Contains(table, s) {
+			t.Errorf("Expected %q in the formatted output:\n%s", s, table)
+		}
+	}
+}
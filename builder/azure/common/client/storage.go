@@ -0,0 +1,24 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+// BlobStorageURI returns the URI of the blob service of the storage account
+// named accountName in the cloud environment env, for example
+// https://myaccount.blob.core.windows.net.
+func BlobStorageURI(env environments.Environment, accountName string) (string, error) {
+	if env.Storage == nil {
+		return "", fmt.Errorf("Storage is not available in the %q cloud environment", env.Name)
+	}
+	suffix, ok := env.Storage.DomainSuffix()
+	if !ok || suffix == nil || *suffix == "" {
+		return "", fmt.Errorf("the %q cloud environment does not define a Storage DNS suffix", env.Name)
+	}
+	return fmt.Sprintf("https://%s.blob.%s", accountName, *suffix), nil
+}
@@ -0,0 +1,31 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+)
+
+func Test_BlobStorageURI(t *testing.T) {
+	tests := map[string]*environments.Environment{
+		"https://account1.blob.core.windows.net":       environments.AzurePublic(),
+		"https://account1.blob.core.chinacloudapi.cn":  environments.AzureChina(),
+		"https://account1.blob.core.usgovcloudapi.net": environments.AzureUSGovernment(),
+	}
+	for want, env := range tests {
+		got, err := BlobStorageURI(*env, "account1")
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", env.Name, err)
+		}
+		if got != want {
+			t.Errorf("BlobStorageURI(%s) = %q, want %q", env.Name, got, want)
+		}
+	}
+
+	if _, err := BlobStorageURI(environments.Environment{Name: "Custom"}, "account1"); err == nil {
+		t.Error("Expected an error for an environment without Storage")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+)
+
+// redactedQueryParameters are the query parameters whose values are replaced
+// before a URL is written to the trace file. They carry SAS signatures and
+// OAuth tokens or secrets.
+var redactedQueryParameters = map[string]bool{
+	"sig":                   true,
+	"signature":             true,
+	"token":                 true,
+	"access_token":          true,
+	"refresh_token":         true,
+	"id_token":              true,
+	"code":                  true,
+	"client_secret":         true,
+	"client_assertion":      true,
+	"assertion":             true,
+	"password":              true,
+	"x-ms-copy-source-auth": true,
+}
+
+// traceRecord is one line of the trace file.
+type traceRecord struct {
+	Time                 string  `json:"time"`
+	Method               string  `json:"method"`
+	URL                  string  `json:"url"`
+	Operation            string  `json:"operation,omitempty"`
+	Status               int     `json:"status,omitempty"`
+	DurationMS           float64 `json:"duration_ms"`
+	RequestID            string  `json:"x_ms_request_id,omitempty"`
+	CorrelationRequestID string  `json:"x_ms_correlation_request_id,omitempty"`
+	ClientRequestID      string  `json:"x_ms_client_request_id,omitempty"`
+	Retry                int     `json:"retry"`
+	Error                string  `json:"error,omitempty"`
+}
+
+// traceFiles are the open trace files by path. The builders and data sources
+// of a build that trace to the same file share one handle, so that their lines
+// are not interleaved, and the last one to be closed closes it.
+var (
+	traceFilesMu sync.Mutex
+	traceFiles   = map[string]*traceFile{}
+)
+
+// traceFile is a trace file shared by the transports tracing to it.
+type traceFile struct {
+	path string
+	refs int
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// openTraceFile returns the trace file at path, opening it unless it is
+// already open.
+func openTraceFile(path string) (*traceFile, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	traceFilesMu.Lock()
+	defer traceFilesMu.Unlock()
+	if tf, ok := traceFiles[path]; ok {
+		tf.refs++
+		return tf, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening azure_trace_file: %v", err)
+	}
+	tf := &traceFile{path: path, refs: 1, f: f}
+	traceFiles[path] = tf
+	return tf, nil
+}
+
+func (tf *traceFile) Write(p []byte) (int, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	if tf.f == nil {
+		return 0, os.ErrClosed
+	}
+	return tf.f.Write(p)
+}
+
+// release syncs and closes the file when no transport traces to it anymore.
+func (tf *traceFile) release() error {
+	traceFilesMu.Lock()
+	defer traceFilesMu.Unlock()
+	tf.refs--
+	if tf.refs > 0 {
+		return nil
+	}
+	delete(traceFiles, tf.path)
+
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	f := tf.f
+	tf.f = nil
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// traceTransport is an http.RoundTripper that appends a JSON line describing
+// every request it sends to a trace file. Retries of a request are sent by
+// the Azure SDK with the same *http.Request, which is how they are counted.
+type traceTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	w        io.Writer
+	attempts map[weak.Pointer[http.Request]]int
+
+	file      *traceFile
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newTraceTransport returns a traceTransport that sends requests with base
+// and appends the trace to the file at path, until it is closed.
+func newTraceTransport(base http.RoundTripper, path string) (*traceTransport, error) {
+	tf, err := openTraceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := newTraceTransportWithWriter(base, tf)
+	t.file = tf
+	return t, nil
+}
+
+func newTraceTransportWithWriter(base http.RoundTripper, w io.Writer) *traceTransport {
+	return &traceTransport{
+		base:     base,
+		w:        w,
+		attempts: make(map[weak.Pointer[http.Request]]int),
+	}
+}
+
+// Close releases the trace file. Requests sent afterwards are not traced
+// once every transport tracing to the file is closed.
+func (t *traceTransport) Close() error {
+	t.closeOnce.Do(func() {
+		if t.file != nil {
+			t.closeErr = t.file.release()
+		}
+	})
+	return t.closeErr
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	retry := t.nextAttempt(req)
+
+	resp, err := t.base.RoundTrip(req)
+
+	record := traceRecord{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		Operation:  operationName(req.URL),
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Retry:      retry,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if resp != nil {
+		record.Status = resp.StatusCode
+		record.RequestID = resp.Header.Get("x-ms-request-id")
+		record.CorrelationRequestID = resp.Header.Get("x-ms-correlation-request-id")
+		record.ClientRequestID = resp.Header.Get("x-ms-client-request-id")
+	}
+	if record.ClientRequestID == "" {
+		record.ClientRequestID = req.Header.Get("x-ms-client-request-id")
+	}
+	t.write(record)
+
+	return resp, err
+}
+
+// nextAttempt returns how many times req has been sent before.
+func (t *traceTransport) nextAttempt(req *http.Request) int {
+	key := weak.Make(req)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attempt, seen := t.attempts[key]
+	t.attempts[key] = attempt + 1
+	if !seen {
+		runtime.AddCleanup(req, t.forget, key)
+	}
+	return attempt
+}
+
+func (t *traceTransport) forget(key weak.Pointer[http.Request]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.attempts, key)
+}
+
+func (t *traceTransport) write(record traceRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.w.Write(append(line, '\n'))
+}
+
+// redactURL returns u without user information and with the values of
+// sensitive query parameters replaced.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for key := range query {
+		if redactedQueryParameters[strings.ToLower(key)] {
+			query.Set(key, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// operationName derives the Resource Manager resource type and action from
+// the path of u, for example Microsoft.Compute/virtualMachines/powerOff. It
+// returns an empty string for requests that are not made to a resource
+// provider.
+func operationName(u *url.URL) string {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	provider := -1
+	for i, s := range segments {
+		if strings.EqualFold(s, "providers") {
+			provider = i
+		}
+	}
+	if provider == -1 || provider+1 >= len(segments) {
+		return ""
+	}
+
+	// After the namespace, resource types and names alternate. A trailing
+	// type without a name is either a collection or an action.
+	parts := []string{segments[provider+1]}
+	for i := provider + 2; i < len(segments); i += 2 {
+		parts = append(parts, segments[i])
+	}
+	return strings.Join(parts, "/")
+}
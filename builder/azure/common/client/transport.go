@@ -0,0 +1,217 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/auth"
+	"github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/oauth2"
+)
+
+// imdsHost is the link-local address of the Azure Instance Metadata Service.
+// Requests to it must never be sent through a proxy.
+const imdsHost = "169.254.169.254"
+
+// newTransport creates the http.RoundTripper that is shared by all Azure
+// clients created from a Config. When httpProxy is empty the proxy is taken
+// from the environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY), which matches
+// the default behaviour of the Azure SDK. The Instance Metadata Service is
+// always excluded from proxying.
+func newTransport(httpProxy, noProxy, caBundleFile string) (*http.Transport, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if caBundleFile != "" {
+		pool, err := loadCABundle(caBundleFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Transport{
+		Proxy: proxyFunc(httpProxy, noProxy),
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			d := &net.Dialer{Resolver: &net.Resolver{}}
+			return d.DialContext(ctx, network, addr)
+		},
+		TLSClientConfig:       tlsConfig,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConnsPerHost:   runtime.GOMAXPROCS(0) + 1,
+	}, nil
+}
+
+// proxyFunc returns the proxy selection function used by newTransport.
+func proxyFunc(httpProxy, noProxy string) func(*http.Request) (*url.URL, error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	if httpProxy != "" {
+		proxyConfig.HTTPProxy = httpProxy
+		proxyConfig.HTTPSProxy = httpProxy
+	}
+	if noProxy != "" {
+		proxyConfig.NoProxy = noProxy
+	}
+	fn := proxyConfig.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		if req.URL.Hostname() == imdsHost {
+			return nil, nil
+		}
+		return fn(req.URL)
+	}
+}
+
+// loadCABundle returns the system certificate pool extended with the PEM
+// encoded certificates found in path.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ca_bundle_file: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_bundle_file %q does not contain any PEM encoded certificates", path)
+	}
+	return pool, nil
+}
+
+// validateProxyURL checks that the value of http_proxy can be used as a proxy
+// URL.
+func validateProxyURL(proxy string) error {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("http_proxy is not a valid URL: %v", err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("http_proxy %q does not specify a host", proxy)
+	}
+	return nil
+}
+
+// newTokenHTTPClient wraps transport in a retrying http.Client with the same
+// retry settings that the Azure SDK uses for authentication requests.
+func newTokenHTTPClient(transport http.RoundTripper) *http.Client {
+	r := retryablehttp.NewClient()
+	r.Logger = nil
+	r.RetryWaitMin = 1 * time.Second
+	r.RetryWaitMax = 30 * time.Second
+	r.RetryMax = 8
+	r.HTTPClient = &http.Client{
+		Transport: transport,
+	}
+	return r.StandardClient()
+}
+
+// tokenTransportKey is the context key of the transport that the token
+// requests of an authorizer are sent with.
+type tokenTransportKey struct{}
+
+// tokenClient sends the token requests of the Azure SDK. The SDK sends them
+// with the package level auth.Client, so tokenClient is installed there once
+// and sends each request with the transport found in its context, that of
+// the authorizer requesting the token (see transportAuthorizer). Requests
+// without one use the client of the SDK, so the proxy and CA settings of a
+// configuration do not leak into the clients of another.
+type tokenClient struct {
+	sdkClient auth.HTTPClient
+
+	mu      sync.Mutex
+	clients map[http.RoundTripper]*http.Client
+}
+
+var (
+	installTokenClientOnce sync.Once
+	sharedTokenClient      *tokenClient
+)
+
+func (c *tokenClient) Do(req *http.Request) (*http.Response, error) {
+	transport, ok := req.Context().Value(tokenTransportKey{}).(http.RoundTripper)
+	if !ok {
+		return c.sdkClient.Do(req)
+	}
+
+	c.mu.Lock()
+	client, ok := c.clients[transport]
+	if !ok {
+		client = newTokenHTTPClient(transport)
+		c.clients[transport] = client
+	}
+	c.mu.Unlock()
+	return client.Do(req)
+}
+
+// withTokenTransport returns a context whose token requests are sent with
+// transport.
+func withTokenTransport(ctx context.Context, transport http.RoundTripper) context.Context {
+	installTokenClientOnce.Do(func() {
+		sharedTokenClient = &tokenClient{
+			sdkClient: auth.Client,
+			clients:   make(map[http.RoundTripper]*http.Client),
+		}
+		auth.Client = sharedTokenClient
+	})
+	return context.WithValue(ctx, tokenTransportKey{}, transport)
+}
+
+// transportAuthorizer is an authorizer whose token requests are sent with
+// transport, including the ones refreshing its tokens.
+type transportAuthorizer struct {
+	auth.Authorizer
+	transport http.RoundTripper
+}
+
+func (a transportAuthorizer) Token(ctx context.Context, req *http.Request) (*oauth2.Token, error) {
+	return a.Authorizer.Token(withTokenTransport(ctx, a.transport), req)
+}
+
+func (a transportAuthorizer) AuxiliaryTokens(ctx context.Context, req *http.Request) ([]*oauth2.Token, error) {
+	return a.Authorizer.AuxiliaryTokens(withTokenTransport(ctx, a.transport), req)
+}
+
+// imdsHTTPClient is the http.Client for talking to the Instance Metadata
+// Service, which ignores any proxy configured in the environment. It is
+// shared so that connections to the service are reused.
+var imdsHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil,
+	},
+}
+
+// transportSetter is implemented by the base clients of the Azure SDK.
+type transportSetter interface {
+	SetTransport(transport http.RoundTripper)
+}
+
+// ConfigureTransport sets transport on an Azure SDK client, leaving the SDK
+// default in place when transport is nil.
+func ConfigureTransport(c transportSetter, transport http.RoundTripper) {
+	if transport != nil {
+		c.SetTransport(transport)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/auth"
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/oauth2"
+)
+
+func Test_proxyFunc(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpProxy string
+		noProxy   string
+		url       string
+		want      string
+	}{
+		{
+			name:      "resource manager is proxied",
+			httpProxy: "http://proxy.example.com:3128",
+			url:       "https://management.azure.com/subscriptions",
+			want:      "http://proxy.example.com:3128",
+		},
+		{
+			name:      "token endpoint is proxied",
+			httpProxy: "http://proxy.example.com:3128",
+			url:       "https://login.microsoftonline.com/tenant/oauth2/v2.0/token",
+			want:      "http://proxy.example.com:3128",
+		},
+		{
+			name:      "no_proxy domain is not proxied",
+			httpProxy: "http://proxy.example.com:3128",
+			noProxy:   ".blob.core.windows.net",
+			url:       "https://account.blob.core.windows.net/container/blob",
+		},
+		{
+			name:      "IMDS is never proxied",
+			httpProxy: "http://proxy.example.com:3128",
+			url:       "http://169.254.169.254/metadata/instance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := proxyFunc(tt.httpProxy, tt.noProxy)(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("Expected request to %s not to be proxied, but got %s", tt.url, got)
+				}
+				return
+			}
+			if got == nil || got.String() != tt.want {
+				t.Fatalf("Expected proxy %s, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ClientConfig_NetworkOptions(t *testing.T) {
+	caBundle := writeTestCABundle(t)
+	findTenantID = func(environments.Environment, string, http.RoundTripper) (string, error) { return "tenant", nil }
+
+	cfg := Config{
+		cloudEnvironment: environments.AzurePublic(),
+		ClientID:         "whatever",
+		ClientSecret:     "whatever",
+		SubscriptionID:   "whatever",
+		HTTPProxy:        "proxy.example.com:3128",
+		CABundleFile:     caBundle,
+	}
+	assertValid(t, cfg)
+
+	if err := cfg.FillParameters(); err != nil {
+		t.Fatalf("Expected nil err, but got: %v", err)
+	}
+	transport, ok := cfg.Transport().(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, but got %T", cfg.Transport())
+	}
+	if transport.TLSClientConfig.RootCAs == nil {
+		t.Fatal("Expected the CA bundle to be loaded into the transport")
+	}
+
+	cfg = Config{}
+	if err := cfg.setTransport(); err != nil {
+		t.Fatalf("Expected nil err, but got: %v", err)
+	}
+	if cfg.Transport() != nil {
+		t.Fatal("Expected the SDK default transport to be used without network options")
+	}
+}
+
+func Test_ClientConfig_NetworkOptions_Rejections(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "bundle.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cfg := range []Config{
+		{HTTPProxy: "http://"},
+		{CABundleFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{CABundleFile: notPEM},
+	} {
+		errs := &packersdk.MultiError{}
+		cfg.Validate(errs)
+		if len(errs.Errors) == 0 {
+			t.Errorf("Expected config to be invalid: %+v", cfg)
+		}
+	}
+}
+
+func writeTestCABundle(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Proxy CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(crand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// namedTransport records the name of the transport each request is sent with.
+type namedTransport struct {
+	name string
+	mu   *sync.Mutex
+	sent *[]string
+}
+
+func (t namedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	*t.sent = append(*t.sent, t.name)
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// tokenRequestAuthorizer sends a token request with auth.Client, like the
+// authorizers of the Azure SDK.
+type tokenRequestAuthorizer struct {
+	auth.Authorizer
+	url string
+}
+
+func (a tokenRequestAuthorizer) Token(ctx context.Context, _ *http.Request) (*oauth2.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := auth.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &oauth2.Token{AccessToken: "token"}, nil
+}
+
+func Test_transportAuthorizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var sent []string
+	proxied := transportAuthorizer{
+		Authorizer: tokenRequestAuthorizer{url: server.URL},
+		transport:  namedTransport{name: "proxied", mu: &mu, sent: &sent},
+	}
+	direct := transportAuthorizer{
+		Authorizer: tokenRequestAuthorizer{url: server.URL},
+		transport:  namedTransport{name: "direct", mu: &mu, sent: &sent},
+	}
+	for _, a := range []auth.Authorizer{proxied, direct, proxied, tokenRequestAuthorizer{url: server.URL}} {
+		if _, err := a.Token(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the authorizer without a transport uses the client of the SDK
+	if want := []string{"proxied", "direct", "proxied"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("token requests sent with %v, want %v", sent, want)
+	}
+}
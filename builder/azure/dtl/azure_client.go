@@ -86,6 +86,7 @@ func NewAzureClient(ctx context.Context, subscriptionID string,
 	}
 	dtlMetaClient, err := dtl.NewClientWithBaseURI(cloud.ResourceManager, func(c *resourcemanager.Client) {
 		c.Authorizer = resourceManagerAuthorizer
+		commonclient.ConfigureTransport(c.Client, authOptions.Transport)
 		c.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), "go-azure-sdk Meta Client")
 		c.Client.ResponseMiddlewares = &responseMiddleware
 		c.Client.RequestMiddlewares = &requestMiddleware
@@ -100,6 +101,7 @@ func NewAzureClient(ctx context.Context, subscriptionID string,
 		return nil, err
 	}
 	galleryImageVersionsClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(galleryImageVersionsClient.Client, authOptions.Transport)
 	galleryImageVersionsClient.Client.ResponseMiddlewares = &responseMiddleware
 	galleryImageVersionsClient.Client.RequestMiddlewares = &requestMiddleware
 	galleryImageVersionsClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImageVersionsClient.Client.UserAgent)
@@ -111,6 +113,7 @@ func NewAzureClient(ctx context.Context, subscriptionID string,
 		return nil, err
 	}
 	galleryImagesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(galleryImagesClient.Client, authOptions.Transport)
 	galleryImagesClient.Client.ResponseMiddlewares = &responseMiddleware
 	galleryImagesClient.Client.RequestMiddlewares = &requestMiddleware
 	galleryImagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), galleryImagesClient.Client.UserAgent)
@@ -121,6 +124,7 @@ func NewAzureClient(ctx context.Context, subscriptionID string,
 		return nil, err
 	}
 	imagesClient.Client.Authorizer = resourceManagerAuthorizer
+	commonclient.ConfigureTransport(imagesClient.Client, authOptions.Transport)
 	imagesClient.Client.ResponseMiddlewares = &responseMiddleware
 	imagesClient.Client.RequestMiddlewares = &requestMiddleware
 	imagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), imagesClient.Client.UserAgent)
@@ -128,6 +132,7 @@ func NewAzureClient(ctx context.Context, subscriptionID string,
 
 	networkMetaClient, err := networks.NewClientWithBaseURI(cloud.ResourceManager, func(c *resourcemanager.Client) {
 		c.Client.Authorizer = resourceManagerAuthorizer
+		commonclient.ConfigureTransport(c.Client, authOptions.Transport)
 		c.Client.UserAgent = "some-user-agent"
 		c.Client.RequestMiddlewares = &requestMiddleware
 		c.Client.ResponseMiddlewares = &responseMiddleware
@@ -64,6 +64,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	if err != nil {
 		return nil, err
 	}
+	defer b.config.ClientConfig.Close()
 
 	log.Print(":: Configuration")
 	packerAzureCommon.DumpConfig(b.config, func(s string) { log.Print(s) })
@@ -83,6 +84,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		SubscriptionID:     b.config.ClientConfig.SubscriptionID,
 		OidcRequestUrl:     b.config.ClientConfig.OidcRequestURL,
 		OidcRequestToken:   b.config.ClientConfig.OidcRequestToken,
+		Transport:          b.config.ClientConfig.Transport(),
 	}
 	ui.Say("Creating Azure DevTestLab (DTL) client ...")
 	azureClient, err := NewAzureClient(
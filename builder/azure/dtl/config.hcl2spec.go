@@ -59,6 +59,10 @@ type FlatConfig struct {
 	OidcRequestToken                    *string                            `mapstructure:"oidc_request_token" cty:"oidc_request_token" hcl:"oidc_request_token"`
 	OidcRequestURL                      *string                            `mapstructure:"oidc_request_url" cty:"oidc_request_url" hcl:"oidc_request_url"`
 	UseAzureCLIAuth                     *bool                              `mapstructure:"use_azure_cli_auth" required:"false" cty:"use_azure_cli_auth" hcl:"use_azure_cli_auth"`
+	HTTPProxy                           *string                            `mapstructure:"http_proxy" required:"false" cty:"http_proxy" hcl:"http_proxy"`
+	NoProxy                             *string                            `mapstructure:"no_proxy" required:"false" cty:"no_proxy" hcl:"no_proxy"`
+	CABundleFile                        *string                            `mapstructure:"ca_bundle_file" required:"false" cty:"ca_bundle_file" hcl:"ca_bundle_file"`
+	TraceFile                           *string                            `mapstructure:"azure_trace_file" required:"false" cty:"azure_trace_file" hcl:"azure_trace_file"`
 	CaptureNamePrefix                   *string                            `mapstructure:"capture_name_prefix" cty:"capture_name_prefix" hcl:"capture_name_prefix"`
 	CaptureContainerName                *string                            `mapstructure:"capture_container_name" cty:"capture_container_name" hcl:"capture_container_name"`
 	SharedGallery                       *FlatSharedImageGallery            `mapstructure:"shared_image_gallery" cty:"shared_image_gallery" hcl:"shared_image_gallery"`
@@ -179,6 +183,10 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"oidc_request_token":                       &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
 		"oidc_request_url":                         &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
 		"use_azure_cli_auth":                       &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
+		"http_proxy":                               &hcldec.AttrSpec{Name: "http_proxy", Type: cty.String, Required: false},
+		"no_proxy":                                 &hcldec.AttrSpec{Name: "no_proxy", Type: cty.String, Required: false},
+		"ca_bundle_file":                           &hcldec.AttrSpec{Name: "ca_bundle_file", Type: cty.String, Required: false},
+		"azure_trace_file":                         &hcldec.AttrSpec{Name: "azure_trace_file", Type: cty.String, Required: false},
 		"capture_name_prefix":                      &hcldec.AttrSpec{Name: "capture_name_prefix", Type: cty.String, Required: false},
 		"capture_container_name":                   &hcldec.AttrSpec{Name: "capture_container_name", Type: cty.String, Required: false},
 		"shared_image_gallery":                     &hcldec.BlockSpec{TypeName: "shared_image_gallery", Nested: hcldec.ObjectSpec((*FlatSharedImageGallery)(nil).HCL2Spec())},
@@ -0,0 +1,183 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package appconfiguration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/client/dataplane"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/version"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/useragent"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The name of the App Configuration store. The endpoint of the store is
+	// derived from the name and the cloud environment. One of `store_name`
+	// or `endpoint` must be set.
+	StoreName string `mapstructure:"store_name"`
+	// The endpoint of the App Configuration store, for example
+	// `https://mystore.azconfig.io`. Use it for a private endpoint or a
+	// custom domain.
+	Endpoint string `mapstructure:"endpoint"`
+	// Only return the keys matching this filter. A filter ending with `*`
+	// matches the keys starting with the rest of the filter, and several
+	// filters can be separated with commas. Defaults to `*`, all the keys.
+	KeyFilter string `mapstructure:"key_filter"`
+	// Only return the key-values with this label. Defaults to the key-values
+	// without label. Set it to `*` to return all labels, in which case keys
+	// must not have several matching labels.
+	Label string `mapstructure:"label"`
+	// Remove this prefix from the keys of the `values` output, for example
+	// `images/ubuntu/`.
+	TrimKeyPrefix string `mapstructure:"trim_key_prefix"`
+	// The time to wait for the lookup to complete, including authentication
+	// and the resolution of Key Vault references. Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The values of the key-values by key, with the values of Key Vault
+	// references replaced by the secrets they reference.
+	Values map[string]string `mapstructure:"values"`
+	// The state of the feature flags by name, `true` or `false`.
+	FeatureFlags map[string]string `mapstructure:"feature_flags"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	switch {
+	case d.config.StoreName == "" && d.config.Endpoint == "":
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'store_name' or 'endpoint' must be specified"))
+	case d.config.StoreName != "" && d.config.Endpoint != "":
+		errs = packersdk.MultiErrorAppend(errs, errors.New("only one of 'store_name' or 'endpoint' can be specified"))
+	case d.config.Endpoint != "":
+		if err := azclient.ValidateAppConfigurationURI(d.config.Endpoint); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if d.config.KeyFilter == "" {
+		d.config.KeyFilter = "*"
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	endpoint := strings.TrimSuffix(d.config.Endpoint, "/")
+	if endpoint == "" {
+		endpoint, err = azclient.AppConfigurationURI(*d.config.CloudEnvironment(), d.config.StoreName)
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	client, err := d.newClient(ctx, endpoint)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	items, err := listKeyValues(ctx, client, d.config.KeyFilter, d.config.Label)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the key-values of %s: %w", endpoint, err)
+	}
+
+	resolver := newSecretResolver(d.newSecretsClient)
+	output, err := flatten(ctx, items, d.config.TrimKeyPrefix, resolver.resolve)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// newClient returns a client of the App Configuration store at endpoint that
+// authenticates with the credentials of the configuration.
+func (d *Datasource) newClient(ctx context.Context, endpoint string) (*dataplane.Client, error) {
+	authOptions := azclient.AzureAuthOptions{
+		AuthType:           d.config.AuthType(),
+		ClientID:           d.config.ClientID,
+		ClientSecret:       d.config.ClientSecret,
+		ClientJWT:          d.config.ClientJWT,
+		ClientCertPath:     d.config.ClientCertPath,
+		ClientCertPassword: d.config.ClientCertPassword,
+		TenantID:           d.config.TenantID,
+		SubscriptionID:     d.config.SubscriptionID,
+		OidcRequestUrl:     d.config.OidcRequestURL,
+		OidcRequestToken:   d.config.OidcRequestToken,
+		Transport:          d.config.Transport(),
+	}
+	authorizer, err := azclient.BuildAppConfigurationAuthorizer(ctx, authOptions, *d.config.CloudEnvironment(), endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create App Configuration authorizer: %w", err)
+	}
+
+	client := newKeyValuesClient(endpoint)
+	client.SetAuthorizer(authorizer)
+	azclient.ConfigureTransport(client, d.config.Transport())
+	client.SetUserAgent(fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), client.GetUserAgent()))
+	return client, nil
+}
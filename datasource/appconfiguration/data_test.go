@@ -0,0 +1,35 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package appconfiguration
+
+import (
+	"testing"
+)
+
+func TestDatasourceConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{name: "store name", config: map[string]interface{}{"store_name": "store1"}},
+		{name: "endpoint", config: map[string]interface{}{"endpoint": "https://store1.azconfig.io"}},
+		{name: "no store", config: map[string]interface{}{}, wantErr: true},
+		{name: "store name and endpoint", config: map[string]interface{}{"store_name": "store1", "endpoint": "https://store1.azconfig.io"}, wantErr: true},
+		{name: "http endpoint", config: map[string]interface{}{"endpoint": "http://store1.azconfig.io"}, wantErr: true},
+		{name: "negative timeout", config: map[string]interface{}{"store_name": "store1", "timeout": "-1m"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Datasource{}
+			err := d.Configure(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && (d.config.KeyFilter != "*" || d.config.Timeout != defaultTimeout) {
+				t.Errorf("unexpected defaults: key filter %q, timeout %s", d.config.KeyFilter, d.config.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package appconfiguration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	sdkClient "github.com/hashicorp/go-azure-sdk/sdk/client"
+	"github.com/hashicorp/go-azure-sdk/sdk/client/dataplane"
+	"github.com/hashicorp/go-azure-sdk/sdk/odata"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+)
+
+const (
+	appConfigurationAPIVersion = "1.0"
+
+	featureFlagPrefix      = ".appconfig.featureflag/"
+	featureFlagContentType = "application/vnd.microsoft.appconfig.ff+json"
+	keyVaultRefContentType = "application/vnd.microsoft.appconfig.keyvaultref+json"
+	keyValueSetMediaType   = "application/vnd.microsoft.appconfig.kvset+json"
+	nullLabel              = "\x00"
+)
+
+// keyValue is a key-value of an App Configuration store.
+type keyValue struct {
+	Key         string  `json:"key"`
+	Label       *string `json:"label"`
+	ContentType *string `json:"content_type"`
+	Value       *string `json:"value"`
+}
+
+// keyValueSet is a page of key-values.
+type keyValueSet struct {
+	Items    []keyValue `json:"items"`
+	NextLink string     `json:"@nextLink"`
+}
+
+func newKeyValuesClient(endpoint string) *dataplane.Client {
+	return dataplane.NewDataPlaneClient(endpoint, "appconfiguration", appConfigurationAPIVersion)
+}
+
+// listKeyValuesOptions are the query parameters of a list request.
+type listKeyValuesOptions struct {
+	key   string
+	label string
+	after string
+}
+
+var _ sdkClient.Options = listKeyValuesOptions{}
+
+func (o listKeyValuesOptions) ToHeaders() *sdkClient.Headers {
+	headers := &sdkClient.Headers{}
+	headers.Append("Accept", keyValueSetMediaType+", application/problem+json")
+	return headers
+}
+
+func (o listKeyValuesOptions) ToOData() *odata.Query {
+	return nil
+}
+
+func (o listKeyValuesOptions) ToQuery() *sdkClient.QueryParams {
+	query := &sdkClient.QueryParams{}
+	query.Append("key", o.key)
+	query.Append("label", o.label)
+	if o.after != "" {
+		query.Append("after", o.after)
+	}
+	return query
+}
+
+// listKeyValues returns the key-values matching the key filter and the label
+// filter, following the pages of the results. An empty label matches the
+// key-values without label.
+func listKeyValues(ctx context.Context, client *dataplane.Client, key, label string) ([]keyValue, error) {
+	if label == "" {
+		label = nullLabel
+	}
+	options := listKeyValuesOptions{key: key, label: label}
+
+	var items []keyValue
+	for {
+		req, err := client.NewRequest(ctx, sdkClient.RequestOptions{
+			ContentType:         "application/json; charset=utf-8",
+			ExpectedStatusCodes: []int{http.StatusOK},
+			HttpMethod:          http.MethodGet,
+			OptionsObject:       options,
+			Path:                "/kv",
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := req.Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The key-value set media type is not recognized by the SDK, the
+		// body is decoded here.
+		body, err := io.ReadAll(resp.Body)
+		//nolint:errcheck
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the response: %w", err)
+		}
+		var page keyValueSet
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode the response: %w", err)
+		}
+		items = append(items, page.Items...)
+
+		if page.NextLink == "" {
+			return items, nil
+		}
+		next, err := url.Parse(page.NextLink)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next link %q: %w", page.NextLink, err)
+		}
+		options.after = next.Query().Get("after")
+		if options.after == "" {
+			return nil, fmt.Errorf("the next link %q has no continuation token", page.NextLink)
+		}
+	}
+}
+
+// mediaType returns the media type of a content type, without parameters.
+func mediaType(contentType *string) string {
+	if contentType == nil {
+		return ""
+	}
+	mt, _, _ := strings.Cut(*contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// flatten returns the output for the key-values items. Key Vault references
+// are resolved with resolve, and feature flags are reported separately.
+func flatten(ctx context.Context, items []keyValue, trimKeyPrefix string, resolve func(context.Context, string) (string, error)) (DatasourceOutput, error) {
+	output := DatasourceOutput{
+		Values:       map[string]string{},
+		FeatureFlags: map[string]string{},
+	}
+	labels := map[string]string{}
+
+	for _, item := range items {
+		label := ""
+		if item.Label != nil {
+			label = *item.Label
+		}
+		value := ""
+		if item.Value != nil {
+			value = *item.Value
+		}
+
+		if strings.HasPrefix(item.Key, featureFlagPrefix) || mediaType(item.ContentType) == featureFlagContentType {
+			var flag struct {
+				ID      string `json:"id"`
+				Enabled bool   `json:"enabled"`
+			}
+			if err := json.Unmarshal([]byte(value), &flag); err != nil {
+				return output, fmt.Errorf("the feature flag %q is invalid: %w", item.Key, err)
+			}
+			name := flag.ID
+			if name == "" {
+				name = strings.TrimPrefix(item.Key, featureFlagPrefix)
+			}
+			output.FeatureFlags[name] = fmt.Sprintf("%t", flag.Enabled)
+			continue
+		}
+
+		key := strings.TrimPrefix(item.Key, trimKeyPrefix)
+		if other, ok := labels[key]; ok {
+			return output, fmt.Errorf("the key %q matches with the labels %q and %q, set a 'label' to select one", item.Key, other, label)
+		}
+		labels[key] = label
+
+		if mediaType(item.ContentType) == keyVaultRefContentType {
+			var ref struct {
+				URI string `json:"uri"`
+			}
+			if err := json.Unmarshal([]byte(value), &ref); err != nil {
+				return output, fmt.Errorf("the Key Vault reference %q is invalid: %w", item.Key, err)
+			}
+			secret, err := resolve(ctx, ref.URI)
+			if err != nil {
+				return output, fmt.Errorf("failed to resolve the Key Vault reference %q: %w", item.Key, err)
+			}
+			value = secret
+		}
+		output.Values[key] = value
+	}
+	return output, nil
+}
+
+// secretResolver resolves Key Vault references, with one secrets client per
+// vault.
+type secretResolver struct {
+	newClient func(context.Context, string) (*secrets.SecretsClient, error)
+	clients   map[string]*secrets.SecretsClient
+}
+
+func newSecretResolver(newClient func(context.Context, string) (*secrets.SecretsClient, error)) *secretResolver {
+	return &secretResolver{
+		newClient: newClient,
+		clients:   map[string]*secrets.SecretsClient{},
+	}
+}
+
+// resolve returns the value of the Key Vault secret at uri.
+func (r *secretResolver) resolve(ctx context.Context, uri string) (string, error) {
+	vaultURI, name, version, err := azclient.ParseSecretURI(uri)
+	if err != nil {
+		return "", err
+	}
+	client, ok := r.clients[vaultURI]
+	if !ok {
+		client, err = r.newClient(ctx, vaultURI)
+		if err != nil {
+			return "", err
+		}
+		r.clients[vaultURI] = client
+	}
+	secret, err := azclient.GetSecret(ctx, client, name, version)
+	if err != nil {
+		return "", err
+	}
+	if secret.Model == nil {
+		return "", fmt.Errorf("the secret %q has no value", uri)
+	}
+	return secret.Model.Value, nil
+}
+
+// newSecretsClient returns a secrets client for the vault at vaultURI that
+// authenticates with the credentials of the data source.
+func (d *Datasource) newSecretsClient(ctx context.Context, vaultURI string) (*secrets.SecretsClient, error) {
+	return azclient.NewAuthorizedSecretsClient(ctx, &d.config.Config, vaultURI)
+}
@@ -0,0 +1,174 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package appconfiguration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"golang.org/x/oauth2"
+)
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Token(context.Context, *http.Request) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: "token", TokenType: "Bearer"}, nil
+}
+
+func (fakeAuthorizer) AuxiliaryTokens(context.Context, *http.Request) ([]*oauth2.Token, error) {
+	return nil, nil
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestListKeyValues(t *testing.T) {
+	// Two pages of key-values, the second one behind a continuation token.
+	pages := map[string]keyValueSet{
+		"": {
+			Items:    []keyValue{{Key: "images/ubuntu/version", Value: ptr("24.04")}},
+			NextLink: "/kv?key=images%2F%2A&label=%00&api-version=1.0&after=page2",
+		},
+		"page2": {
+			Items: []keyValue{{Key: "images/ubuntu/packages", Value: ptr("nginx,curl")}},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/kv" || query.Get("key") != "images/*" || query.Get("label") != "\x00" || query.Get("api-version") != appConfigurationAPIVersion {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		page, ok := pages[query.Get("after")]
+		if !ok {
+			http.Error(w, "unknown page", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", keyValueSetMediaType+"; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client := newKeyValuesClient(server.URL)
+	client.SetAuthorizer(fakeAuthorizer{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	items, err := listKeyValues(ctx, client, "images/*", "")
+	if err != nil {
+		t.Fatalf("listKeyValues: %s", err)
+	}
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"images/ubuntu/version", "images/ubuntu/packages"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	ref := "application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8"
+	items := []keyValue{
+		{Key: "images/ubuntu/version", Value: ptr("24.04")},
+		{Key: "images/ubuntu/empty"},
+		{Key: "images/ubuntu/token", ContentType: &ref, Value: ptr(`{"uri":"https://vault1.vault.azure.net/secrets/token"}`)},
+		{Key: ".appconfig.featureflag/hardening", ContentType: ptr(featureFlagContentType + ";charset=utf-8"), Value: ptr(`{"id":"hardening","enabled":true}`)},
+		{Key: ".appconfig.featureflag/gpu", Value: ptr(`{"enabled":false}`)},
+	}
+	resolve := func(_ context.Context, uri string) (string, error) {
+		if uri != "https://vault1.vault.azure.net/secrets/token" {
+			return "", fmt.Errorf("unexpected URI %q", uri)
+		}
+		return "s3cr3t", nil
+	}
+
+	got, err := flatten(context.Background(), items, "images/ubuntu/", resolve)
+	if err != nil {
+		t.Fatalf("flatten: %s", err)
+	}
+	want := DatasourceOutput{
+		Values:       map[string]string{"version": "24.04", "empty": "", "token": "s3cr3t"},
+		FeatureFlags: map[string]string{"hardening": "true", "gpu": "false"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestFlattenErrors(t *testing.T) {
+	ref := keyVaultRefContentType
+	tests := map[string][]keyValue{
+		"several labels": {
+			{Key: "version", Label: ptr("dev"), Value: ptr("1")},
+			{Key: "version", Label: ptr("prod"), Value: ptr("2")},
+		},
+		"invalid reference": {{Key: "token", ContentType: &ref, Value: ptr("not json")}},
+		"invalid flag":      {{Key: ".appconfig.featureflag/gpu", Value: ptr("not json")}},
+		"unresolvable":      {{Key: "token", ContentType: &ref, Value: ptr(`{"uri":"https://vault1.vault.azure.net/secrets/missing"}`)}},
+	}
+	resolve := func(context.Context, string) (string, error) {
+		return "", fmt.Errorf("not found")
+	}
+	for name, items := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := flatten(context.Background(), items, "", resolve); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestSecretResolver(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		name := strings.TrimPrefix(r.URL.Path, "/secrets/")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "https://vault/secrets/" + name, "value": "value-of-" + strings.Trim(name, "/")})
+	}))
+	defer server.Close()
+
+	clients := 0
+	resolver := newSecretResolver(func(_ context.Context, vaultURI string) (*secrets.SecretsClient, error) {
+		clients++
+		client, err := azclient.NewSecretsClientWithBaseURI(environments.NewApiEndpoint("KeyVault", server.URL, nil))
+		if err != nil {
+			return nil, err
+		}
+		client.Client.SetAuthorizer(fakeAuthorizer{})
+		client.Client.SetTransport(server.Client().Transport)
+		return client, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, name := range []string{"token", "password/v2"} {
+		got, err := resolver.resolve(ctx, "https://vault1.vault.azure.net/secrets/"+name)
+		if err != nil {
+			t.Fatalf("resolve: %s", err)
+		}
+		if want := "value-of-" + name; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if clients != 1 || requests != 2 {
+		t.Errorf("got %d clients and %d requests, want 1 client and 2 requests", clients, requests)
+	}
+}
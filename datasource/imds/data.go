@@ -0,0 +1,180 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package imds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 30 * time.Second
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The address of the instance metadata service. Defaults to
+	// `http://169.254.169.254`. Only change it to query a local stand-in of
+	// the service, for example in tests.
+	Endpoint string `mapstructure:"endpoint"`
+	// A nonce of up to 10 digits to include in the attested data document,
+	// to protect its signature against replay.
+	AttestedNonce string `mapstructure:"attested_nonce"`
+	// The time to wait for the instance metadata service. Defaults to `30s`.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The name of the virtual machine.
+	Name string `mapstructure:"name"`
+	// The host name of the virtual machine.
+	ComputerName string `mapstructure:"computer_name"`
+	// The unique ID of the virtual machine.
+	VMID string `mapstructure:"vm_id"`
+	// The size of the virtual machine, for example `Standard_D2s_v5`.
+	VMSize string `mapstructure:"vm_size"`
+	// The resource ID of the virtual machine.
+	ResourceID string `mapstructure:"resource_id"`
+	// The subscription of the virtual machine.
+	SubscriptionID string `mapstructure:"subscription_id"`
+	// The resource group of the virtual machine.
+	ResourceGroupName string `mapstructure:"resource_group_name"`
+	// The region of the virtual machine.
+	Location string `mapstructure:"location"`
+	// The availability zone of the virtual machine, if any.
+	Zone string `mapstructure:"zone"`
+	// The name of the scale set of the virtual machine, if any.
+	VMScaleSetName string `mapstructure:"vm_scale_set_name"`
+	// The Azure cloud of the virtual machine, for example `AzurePublicCloud`.
+	AzEnvironment string `mapstructure:"az_environment"`
+	// The operating system type, `Linux` or `Windows`.
+	OSType string `mapstructure:"os_type"`
+	// The tags of the virtual machine.
+	Tags map[string]string `mapstructure:"tags"`
+	// The publisher of the Marketplace image of the virtual machine.
+	ImagePublisher string `mapstructure:"image_publisher"`
+	// The offer of the Marketplace image of the virtual machine.
+	ImageOffer string `mapstructure:"image_offer"`
+	// The SKU of the Marketplace image of the virtual machine.
+	ImageSku string `mapstructure:"image_sku"`
+	// The version of the Marketplace image of the virtual machine.
+	ImageVersion string `mapstructure:"image_version"`
+	// The name of the purchase plan of the virtual machine, if any.
+	PlanName string `mapstructure:"plan_name"`
+	// The product of the purchase plan of the virtual machine, if any.
+	PlanProduct string `mapstructure:"plan_product"`
+	// The publisher of the purchase plan of the virtual machine, if any.
+	PlanPublisher string `mapstructure:"plan_publisher"`
+	// The security type of the virtual machine, for example `TrustedLaunch`.
+	SecurityType string `mapstructure:"security_type"`
+	// Whether secure boot is enabled.
+	SecureBootEnabled bool `mapstructure:"secure_boot_enabled"`
+	// Whether the virtual TPM is enabled.
+	VirtualTpmEnabled bool `mapstructure:"virtual_tpm_enabled"`
+	// Whether encryption at host is enabled.
+	EncryptionAtHost bool `mapstructure:"encryption_at_host"`
+	// The private IPv4 and IPv6 addresses of the network interfaces.
+	PrivateIPAddresses []string `mapstructure:"private_ip_addresses"`
+	// The public IP addresses of the network interfaces.
+	PublicIPAddresses []string `mapstructure:"public_ip_addresses"`
+	// The MAC addresses of the network interfaces.
+	MACAddresses []string `mapstructure:"mac_addresses"`
+	// The full compute document, as JSON. Decode it with `jsondecode` to
+	// read the values without a dedicated output.
+	ComputeJSON string `mapstructure:"compute_json"`
+	// The full network document, as JSON.
+	NetworkJSON string `mapstructure:"network_json"`
+	// The base64 encoded signature of the attested data document.
+	AttestedSignature string `mapstructure:"attested_signature"`
+	// The encoding of the attested data signature, `pkcs7`.
+	AttestedEncoding string `mapstructure:"attested_encoding"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.Endpoint == "" {
+		d.config.Endpoint = client.IMDSEndpoint
+	}
+	if u, err := url.Parse(d.config.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'endpoint' must be an http or https URL, got %q", d.config.Endpoint))
+	}
+	if len(d.config.AttestedNonce) > 10 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'attested_nonce' must not be longer than 10 digits"))
+	}
+	for _, c := range d.config.AttestedNonce {
+		if c < '0' || c > '9' {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("'attested_nonce' must only contain digits"))
+			break
+		}
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	var instance instanceMetadata
+	err := client.GetMetadata(ctx, d.config.Endpoint, "/metadata/instance", url.Values{"api-version": {instanceAPIVersion}}, &instance)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get the instance metadata: %w", err)
+	}
+
+	query := url.Values{"api-version": {attestedAPIVersion}}
+	if d.config.AttestedNonce != "" {
+		query.Set("nonce", d.config.AttestedNonce)
+	}
+	var attested attestedData
+	err = client.GetMetadata(ctx, d.config.Endpoint, "/metadata/attested/document", query, &attested)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get the attested data: %w", err)
+	}
+
+	output, err := newOutput(instance, attested)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
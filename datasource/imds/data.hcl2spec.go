@@ -0,0 +1,134 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package imds
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Endpoint            *string           `mapstructure:"endpoint" cty:"endpoint" hcl:"endpoint"`
+	AttestedNonce       *string           `mapstructure:"attested_nonce" cty:"attested_nonce" hcl:"attested_nonce"`
+	Timeout             *string           `mapstructure:"timeout" cty:"timeout" hcl:"timeout"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"packer_build_name":          &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":        &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":        &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"endpoint":                   &hcldec.AttrSpec{Name: "endpoint", Type: cty.String, Required: false},
+		"attested_nonce":             &hcldec.AttrSpec{Name: "attested_nonce", Type: cty.String, Required: false},
+		"timeout":                    &hcldec.AttrSpec{Name: "timeout", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	Name               *string           `mapstructure:"name" cty:"name" hcl:"name"`
+	ComputerName       *string           `mapstructure:"computer_name" cty:"computer_name" hcl:"computer_name"`
+	VMID               *string           `mapstructure:"vm_id" cty:"vm_id" hcl:"vm_id"`
+	VMSize             *string           `mapstructure:"vm_size" cty:"vm_size" hcl:"vm_size"`
+	ResourceID         *string           `mapstructure:"resource_id" cty:"resource_id" hcl:"resource_id"`
+	SubscriptionID     *string           `mapstructure:"subscription_id" cty:"subscription_id" hcl:"subscription_id"`
+	ResourceGroupName  *string           `mapstructure:"resource_group_name" cty:"resource_group_name" hcl:"resource_group_name"`
+	Location           *string           `mapstructure:"location" cty:"location" hcl:"location"`
+	Zone               *string           `mapstructure:"zone" cty:"zone" hcl:"zone"`
+	VMScaleSetName     *string           `mapstructure:"vm_scale_set_name" cty:"vm_scale_set_name" hcl:"vm_scale_set_name"`
+	AzEnvironment      *string           `mapstructure:"az_environment" cty:"az_environment" hcl:"az_environment"`
+	OSType             *string           `mapstructure:"os_type" cty:"os_type" hcl:"os_type"`
+	Tags               map[string]string `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	ImagePublisher     *string           `mapstructure:"image_publisher" cty:"image_publisher" hcl:"image_publisher"`
+	ImageOffer         *string           `mapstructure:"image_offer" cty:"image_offer" hcl:"image_offer"`
+	ImageSku           *string           `mapstructure:"image_sku" cty:"image_sku" hcl:"image_sku"`
+	ImageVersion       *string           `mapstructure:"image_version" cty:"image_version" hcl:"image_version"`
+	PlanName           *string           `mapstructure:"plan_name" cty:"plan_name" hcl:"plan_name"`
+	PlanProduct        *string           `mapstructure:"plan_product" cty:"plan_product" hcl:"plan_product"`
+	PlanPublisher      *string           `mapstructure:"plan_publisher" cty:"plan_publisher" hcl:"plan_publisher"`
+	SecurityType       *string           `mapstructure:"security_type" cty:"security_type" hcl:"security_type"`
+	SecureBootEnabled  *bool             `mapstructure:"secure_boot_enabled" cty:"secure_boot_enabled" hcl:"secure_boot_enabled"`
+	VirtualTpmEnabled  *bool             `mapstructure:"virtual_tpm_enabled" cty:"virtual_tpm_enabled" hcl:"virtual_tpm_enabled"`
+	EncryptionAtHost   *bool             `mapstructure:"encryption_at_host" cty:"encryption_at_host" hcl:"encryption_at_host"`
+	PrivateIPAddresses []string          `mapstructure:"private_ip_addresses" cty:"private_ip_addresses" hcl:"private_ip_addresses"`
+	PublicIPAddresses  []string          `mapstructure:"public_ip_addresses" cty:"public_ip_addresses" hcl:"public_ip_addresses"`
+	MACAddresses       []string          `mapstructure:"mac_addresses" cty:"mac_addresses" hcl:"mac_addresses"`
+	ComputeJSON        *string           `mapstructure:"compute_json" cty:"compute_json" hcl:"compute_json"`
+	NetworkJSON        *string           `mapstructure:"network_json" cty:"network_json" hcl:"network_json"`
+	AttestedSignature  *string           `mapstructure:"attested_signature" cty:"attested_signature" hcl:"attested_signature"`
+	AttestedEncoding   *string           `mapstructure:"attested_encoding" cty:"attested_encoding" hcl:"attested_encoding"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":                 &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"computer_name":        &hcldec.AttrSpec{Name: "computer_name", Type: cty.String, Required: false},
+		"vm_id":                &hcldec.AttrSpec{Name: "vm_id", Type: cty.String, Required: false},
+		"vm_size":              &hcldec.AttrSpec{Name: "vm_size", Type: cty.String, Required: false},
+		"resource_id":          &hcldec.AttrSpec{Name: "resource_id", Type: cty.String, Required: false},
+		"subscription_id":      &hcldec.AttrSpec{Name: "subscription_id", Type: cty.String, Required: false},
+		"resource_group_name":  &hcldec.AttrSpec{Name: "resource_group_name", Type: cty.String, Required: false},
+		"location":             &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"zone":                 &hcldec.AttrSpec{Name: "zone", Type: cty.String, Required: false},
+		"vm_scale_set_name":    &hcldec.AttrSpec{Name: "vm_scale_set_name", Type: cty.String, Required: false},
+		"az_environment":       &hcldec.AttrSpec{Name: "az_environment", Type: cty.String, Required: false},
+		"os_type":              &hcldec.AttrSpec{Name: "os_type", Type: cty.String, Required: false},
+		"tags":                 &hcldec.AttrSpec{Name: "tags", Type: cty.Map(cty.String), Required: false},
+		"image_publisher":      &hcldec.AttrSpec{Name: "image_publisher", Type: cty.String, Required: false},
+		"image_offer":          &hcldec.AttrSpec{Name: "image_offer", Type: cty.String, Required: false},
+		"image_sku":            &hcldec.AttrSpec{Name: "image_sku", Type: cty.String, Required: false},
+		"image_version":        &hcldec.AttrSpec{Name: "image_version", Type: cty.String, Required: false},
+		"plan_name":            &hcldec.AttrSpec{Name: "plan_name", Type: cty.String, Required: false},
+		"plan_product":         &hcldec.AttrSpec{Name: "plan_product", Type: cty.String, Required: false},
+		"plan_publisher":       &hcldec.AttrSpec{Name: "plan_publisher", Type: cty.String, Required: false},
+		"security_type":        &hcldec.AttrSpec{Name: "security_type", Type: cty.String, Required: false},
+		"secure_boot_enabled":  &hcldec.AttrSpec{Name: "secure_boot_enabled", Type: cty.Bool, Required: false},
+		"virtual_tpm_enabled":  &hcldec.AttrSpec{Name: "virtual_tpm_enabled", Type: cty.Bool, Required: false},
+		"encryption_at_host":   &hcldec.AttrSpec{Name: "encryption_at_host", Type: cty.Bool, Required: false},
+		"private_ip_addresses": &hcldec.AttrSpec{Name: "private_ip_addresses", Type: cty.List(cty.String), Required: false},
+		"public_ip_addresses":  &hcldec.AttrSpec{Name: "public_ip_addresses", Type: cty.List(cty.String), Required: false},
+		"mac_addresses":        &hcldec.AttrSpec{Name: "mac_addresses", Type: cty.List(cty.String), Required: false},
+		"compute_json":         &hcldec.AttrSpec{Name: "compute_json", Type: cty.String, Required: false},
+		"network_json":         &hcldec.AttrSpec{Name: "network_json", Type: cty.String, Required: false},
+		"attested_signature":   &hcldec.AttrSpec{Name: "attested_signature", Type: cty.String, Required: false},
+		"attested_encoding":    &hcldec.AttrSpec{Name: "attested_encoding", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package imds
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+)
+
+const testInstance = `{
+  "compute": {
+    "azEnvironment": "AzurePublicCloud",
+    "location": "westeurope",
+    "name": "builder_3",
+    "offer": "ubuntu-24_04-lts",
+    "osProfile": {"adminUsername": "packer", "computerName": "builder000003"},
+    "osType": "Linux",
+    "plan": {"name": "", "product": "", "publisher": ""},
+    "publisher": "Canonical",
+    "resourceGroupName": "build-rg",
+    "resourceId": "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Compute/virtualMachineScaleSets/builder/virtualMachines/3",
+    "securityProfile": {"secureBootEnabled": "true", "virtualTpmEnabled": "true", "encryptionAtHost": "false", "securityType": "TrustedLaunch"},
+    "sku": "server",
+    "subscriptionId": "sub",
+    "tags": "env:build;team:images",
+    "tagsList": [{"name": "env", "value": "build"}, {"name": "team", "value": "images"}],
+    "version": "24.04.202609010",
+    "vmId": "0c1d2e3f-0000-0000-0000-000000000000",
+    "vmScaleSetName": "builder",
+    "vmSize": "Standard_D4s_v5",
+    "zone": "2"
+  },
+  "network": {
+    "interface": [{
+      "ipv4": {
+        "ipAddress": [{"privateIpAddress": "10.1.0.4", "publicIpAddress": "20.1.2.3"}],
+        "subnet": [{"address": "10.1.0.0", "prefix": "24"}]
+      },
+      "ipv6": {"ipAddress": []},
+      "macAddress": "000D3A2B4C5D"
+    }]
+  }
+}`
+
+func TestDatasourceExecute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata/instance", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			http.Error(w, "missing Metadata header", http.StatusBadRequest)
+			return
+		}
+		if v := r.URL.Query().Get("api-version"); v != instanceAPIVersion {
+			http.Error(w, "unexpected api-version "+v, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(testInstance))
+	})
+	mux.HandleFunc("/metadata/attested/document", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("nonce") != "1234567890" {
+			http.Error(w, "unexpected nonce", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"encoding": "pkcs7", "signature": "MIIL..."}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	d := Datasource{}
+	err := d.Configure(map[string]interface{}{
+		"endpoint":       server.URL,
+		"attested_nonce": "1234567890",
+	})
+	if err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+
+	var compute struct {
+		VMSize string `json:"vmSize"`
+	}
+	if err := json.Unmarshal([]byte(value.GetAttr("compute_json").AsString()), &compute); err != nil || compute.VMSize != "Standard_D4s_v5" {
+		t.Errorf("unexpected compute_json: %v, %#v", err, compute)
+	}
+
+	want := DatasourceOutput{
+		Name:               "builder_3",
+		ComputerName:       "builder000003",
+		VMID:               "0c1d2e3f-0000-0000-0000-000000000000",
+		VMSize:             "Standard_D4s_v5",
+		ResourceID:         "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Compute/virtualMachineScaleSets/builder/virtualMachines/3",
+		SubscriptionID:     "sub",
+		ResourceGroupName:  "build-rg",
+		Location:           "westeurope",
+		Zone:               "2",
+		VMScaleSetName:     "builder",
+		AzEnvironment:      "AzurePublicCloud",
+		OSType:             "Linux",
+		Tags:               map[string]string{"env": "build", "team": "images"},
+		ImagePublisher:     "Canonical",
+		ImageOffer:         "ubuntu-24_04-lts",
+		ImageSku:           "server",
+		ImageVersion:       "24.04.202609010",
+		SecurityType:       "TrustedLaunch",
+		SecureBootEnabled:  true,
+		VirtualTpmEnabled:  true,
+		PrivateIPAddresses: []string{"10.1.0.4"},
+		PublicIPAddresses:  []string{"20.1.2.3"},
+		MACAddresses:       []string{"000D3A2B4C5D"},
+		AttestedSignature:  "MIIL...",
+		AttestedEncoding:   "pkcs7",
+	}
+	want.ComputeJSON = value.GetAttr("compute_json").AsString()
+	want.NetworkJSON = value.GetAttr("network_json").AsString()
+	if expected := hcl2helper.HCL2ValueFromConfig(want, d.OutputSpec()); !value.RawEquals(expected) {
+		t.Errorf("got %#v, want %#v", value, expected)
+	}
+}
+
+func TestDatasourceExecuteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "throttled", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	d := Datasource{}
+	if err := d.Configure(map[string]interface{}{"endpoint": server.URL}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	if _, err := d.Execute(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    bool
+	}{
+		{name: "defaults", config: map[string]interface{}{}},
+		{name: "invalid endpoint", config: map[string]interface{}{"endpoint": "169.254.169.254"}, err: true},
+		{name: "nonce too long", config: map[string]interface{}{"attested_nonce": "12345678901"}, err: true},
+		{name: "nonce not digits", config: map[string]interface{}{"attested_nonce": "abc"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Datasource{}
+			err := d.Configure(tt.config)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %t", err, tt.err)
+			}
+		})
+	}
+}
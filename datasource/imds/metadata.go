@@ -0,0 +1,148 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package imds
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	instanceAPIVersion = "2023-07-01"
+	attestedAPIVersion = "2020-09-01"
+)
+
+// instanceMetadata is the document returned by /metadata/instance. The
+// documents are kept as raw JSON to expose the fields without a dedicated
+// output as well.
+type instanceMetadata struct {
+	Compute json.RawMessage `json:"compute"`
+	Network json.RawMessage `json:"network"`
+}
+
+// computeMetadata holds the fields of the compute document with a dedicated
+// output. IMDS returns booleans as strings.
+type computeMetadata struct {
+	AzEnvironment     string `json:"azEnvironment"`
+	Location          string `json:"location"`
+	Name              string `json:"name"`
+	Offer             string `json:"offer"`
+	OSType            string `json:"osType"`
+	Publisher         string `json:"publisher"`
+	ResourceGroupName string `json:"resourceGroupName"`
+	ResourceID        string `json:"resourceId"`
+	Sku               string `json:"sku"`
+	SubscriptionID    string `json:"subscriptionId"`
+	Version           string `json:"version"`
+	VMID              string `json:"vmId"`
+	VMScaleSetName    string `json:"vmScaleSetName"`
+	VMSize            string `json:"vmSize"`
+	Zone              string `json:"zone"`
+	TagsList          []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"tagsList"`
+	OSProfile struct {
+		ComputerName string `json:"computerName"`
+	} `json:"osProfile"`
+	Plan struct {
+		Name      string `json:"name"`
+		Product   string `json:"product"`
+		Publisher string `json:"publisher"`
+	} `json:"plan"`
+	SecurityProfile struct {
+		SecureBootEnabled string `json:"secureBootEnabled"`
+		VirtualTpmEnabled string `json:"virtualTpmEnabled"`
+		EncryptionAtHost  string `json:"encryptionAtHost"`
+		SecurityType      string `json:"securityType"`
+	} `json:"securityProfile"`
+}
+
+type ipAddress struct {
+	PrivateIPAddress string `json:"privateIpAddress"`
+	PublicIPAddress  string `json:"publicIpAddress"`
+}
+
+type networkMetadata struct {
+	Interface []struct {
+		IPv4 struct {
+			IPAddress []ipAddress `json:"ipAddress"`
+		} `json:"ipv4"`
+		IPv6 struct {
+			IPAddress []ipAddress `json:"ipAddress"`
+		} `json:"ipv6"`
+		MACAddress string `json:"macAddress"`
+	} `json:"interface"`
+}
+
+// attestedData is the document returned by /metadata/attested/document.
+type attestedData struct {
+	Encoding  string `json:"encoding"`
+	Signature string `json:"signature"`
+}
+
+func newOutput(instance instanceMetadata, attested attestedData) (DatasourceOutput, error) {
+	var compute computeMetadata
+	if err := json.Unmarshal(instance.Compute, &compute); err != nil {
+		return DatasourceOutput{}, fmt.Errorf("failed to decode the compute metadata: %w", err)
+	}
+	var network networkMetadata
+	if len(instance.Network) > 0 {
+		if err := json.Unmarshal(instance.Network, &network); err != nil {
+			return DatasourceOutput{}, fmt.Errorf("failed to decode the network metadata: %w", err)
+		}
+	}
+
+	output := DatasourceOutput{
+		Name:               compute.Name,
+		ComputerName:       compute.OSProfile.ComputerName,
+		VMID:               compute.VMID,
+		VMSize:             compute.VMSize,
+		ResourceID:         compute.ResourceID,
+		SubscriptionID:     compute.SubscriptionID,
+		ResourceGroupName:  compute.ResourceGroupName,
+		Location:           compute.Location,
+		Zone:               compute.Zone,
+		VMScaleSetName:     compute.VMScaleSetName,
+		AzEnvironment:      compute.AzEnvironment,
+		OSType:             compute.OSType,
+		Tags:               map[string]string{},
+		ImagePublisher:     compute.Publisher,
+		ImageOffer:         compute.Offer,
+		ImageSku:           compute.Sku,
+		ImageVersion:       compute.Version,
+		PlanName:           compute.Plan.Name,
+		PlanProduct:        compute.Plan.Product,
+		PlanPublisher:      compute.Plan.Publisher,
+		SecurityType:       compute.SecurityProfile.SecurityType,
+		SecureBootEnabled:  strings.EqualFold(compute.SecurityProfile.SecureBootEnabled, "true"),
+		VirtualTpmEnabled:  strings.EqualFold(compute.SecurityProfile.VirtualTpmEnabled, "true"),
+		EncryptionAtHost:   strings.EqualFold(compute.SecurityProfile.EncryptionAtHost, "true"),
+		PrivateIPAddresses: []string{},
+		PublicIPAddresses:  []string{},
+		MACAddresses:       []string{},
+		ComputeJSON:        string(instance.Compute),
+		NetworkJSON:        string(instance.Network),
+		AttestedSignature:  attested.Signature,
+		AttestedEncoding:   attested.Encoding,
+	}
+	for _, tag := range compute.TagsList {
+		output.Tags[tag.Name] = tag.Value
+	}
+	for _, nic := range network.Interface {
+		if nic.MACAddress != "" {
+			output.MACAddresses = append(output.MACAddresses, nic.MACAddress)
+		}
+		for _, ip := range append(nic.IPv4.IPAddress, nic.IPv6.IPAddress...) {
+			if ip.PrivateIPAddress != "" {
+				output.PrivateIPAddresses = append(output.PrivateIPAddresses, ip.PrivateIPAddress)
+			}
+			if ip.PublicIPAddress != "" {
+				output.PublicIPAddresses = append(output.PublicIPAddresses, ip.PublicIPAddress)
+			}
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,203 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package keyvaultcertificate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-azure/datasource/keyvaultsecret"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 1000 * time.Second
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The name of the Azure Key Vault. The vault host name is derived from the
+	// name and the DNS suffix of the configured `cloud_environment_name`.
+	// Either `vault_name` or `vault_uri` must be specified.
+	VaultName string `mapstructure:"vault_name"`
+	// The full URI of the Azure Key Vault, for example
+	// `https://myvault.privatelink.vaultcore.azure.net`. Use this instead of
+	// `vault_name` when the vault is reached through a private endpoint or a
+	// custom DNS name.
+	VaultURI string `mapstructure:"vault_uri"`
+	// The name of the certificate to fetch from the Azure Key Vault.
+	CertificateName string `mapstructure:"certificate_name" required:"true"`
+	// The version of the certificate to fetch. If not provided, the latest version will be used.
+	Version string `mapstructure:"version"`
+	// Fail if the certificate expires within this duration, for example
+	// `720h` for 30 days. By default the expiry date is not checked.
+	FailIfExpiresWithin time.Duration `mapstructure:"fail_if_expires_within"`
+	// The time to wait for the certificate to be retrieved, including
+	// authentication. Defaults to `1000s`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The PEM encoded certificate.
+	CertificatePEM string `mapstructure:"certificate_pem"`
+	// The PEM encoded intermediate and root certificates included with the
+	// certificate, if any.
+	ChainPEM string `mapstructure:"chain_pem"`
+	// The PEM encoded PKCS#8 private key. Empty when the key of the
+	// certificate is not exportable.
+	PrivateKeyPEM string `mapstructure:"private_key_pem"`
+	// The SHA-1 thumbprint of the certificate in upper case hexadecimal, as
+	// used by Windows certificate stores and WinRM listeners.
+	Thumbprint string `mapstructure:"thumbprint"`
+	// The subject of the certificate.
+	Subject string `mapstructure:"subject"`
+	// The expiry date of the certificate in RFC 3339 format.
+	Expires string `mapstructure:"expires"`
+	// The version of the certificate.
+	Version string `mapstructure:"version"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	errs = keyvaultsecret.ValidateVault(errs, d.config.VaultName, d.config.VaultURI)
+	if d.config.CertificateName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'certificate_name' must be specified"))
+	}
+	if d.config.FailIfExpiresWithin < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'fail_if_expires_within' must not be negative"))
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	vaultURI, err := keyvaultsecret.VaultURI(*d.config.CloudEnvironment(), d.config.VaultName, d.config.VaultURI)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	client, err := azclient.NewAuthorizedSecretsClient(ctx, &d.config.Config, vaultURI)
+	if err != nil {
+		log.Printf("failed to create Key Vault client: %v", err)
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.fetchCertificate(ctx, client, time.Now())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get certificate %q from vault %q: %w", d.config.CertificateName, vaultURI, err)
+	}
+	log.Printf("[DEBUG] Retrieved certificate %q (%s) from vault %q", d.config.CertificateName, output.Thumbprint, vaultURI)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// fetchCertificate retrieves the certificate and its backing secret, and
+// checks that the certificate does not expire within fail_if_expires_within
+// of time now.
+func (d *Datasource) fetchCertificate(ctx context.Context, client *secrets.SecretsClient, now time.Time) (DatasourceOutput, error) {
+	certificate, err := getCertificate(ctx, client, d.config.CertificateName, d.config.Version)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+	cer, err := decodeCER(certificate.CER)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+
+	secretName, secretVersion, err := secretNameAndVersion(certificate.SecretID)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+	result, err := azclient.GetSecret(ctx, client, secretName, secretVersion)
+	if err != nil {
+		return DatasourceOutput{}, fmt.Errorf("failed to get the secret of the certificate: %w", err)
+	}
+
+	bundle, err := decodeCertificateSecret(result.Model.Value, result.Model.ContentType, cer)
+	if err != nil {
+		return DatasourceOutput{}, err
+	}
+	leaf := bundle.leaf
+
+	if d.config.FailIfExpiresWithin > 0 && !now.Add(d.config.FailIfExpiresWithin).Before(leaf.NotAfter) {
+		return DatasourceOutput{}, fmt.Errorf("the certificate expires on %s, within fail_if_expires_within (%s)", leaf.NotAfter.UTC().Format(time.RFC3339), d.config.FailIfExpiresWithin)
+	}
+
+	output := DatasourceOutput{
+		CertificatePEM: encodeCertificates(leaf),
+		ChainPEM:       encodeCertificates(bundle.chain...),
+		Thumbprint:     thumbprint(leaf),
+		Subject:        leaf.Subject.String(),
+		Expires:        leaf.NotAfter.UTC().Format(time.RFC3339),
+		Version:        secretVersion,
+	}
+	if bundle.privateKey != nil {
+		if !publicKeyMatches(leaf, bundle.privateKey) {
+			return DatasourceOutput{}, errors.New("the private key does not match the certificate")
+		}
+		output.PrivateKeyPEM, err = encodePrivateKey(bundle.privateKey)
+		if err != nil {
+			return DatasourceOutput{}, err
+		}
+	}
+	return output, nil
+}
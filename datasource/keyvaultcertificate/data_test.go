@@ -0,0 +1,218 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package keyvaultcertificate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/pkcs12"
+	"golang.org/x/oauth2"
+)
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Token(context.Context, *http.Request) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: "token", TokenType: "Bearer"}, nil
+}
+
+func (fakeAuthorizer) AuxiliaryTokens(context.Context, *http.Request) ([]*oauth2.Token, error) {
+	return nil, nil
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: Config{VaultName: "test-vault", CertificateName: "winrm"},
+		},
+		{
+			name:    "no certificate_name",
+			config:  Config{VaultName: "test-vault"},
+			wantErr: true,
+		},
+		{
+			name:    "negative fail_if_expires_within",
+			config:  Config{VaultName: "test-vault", CertificateName: "winrm", FailIfExpiresWithin: -time.Hour},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{config: tt.config}
+			err := d.Configure()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func testCertificate(t *testing.T, cn string, notAfter time.Time, parent *x509.Certificate, parentKey interface{}) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// newTestVault returns a function that fetches the certificate winrm from a
+// fake vault, where it has version v1 and is backed by a secret with value
+// and contentType.
+func newTestVault(t *testing.T, cer []byte, value, contentType string) func(context.Context, *Datasource, time.Time) (DatasourceOutput, error) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch strings.TrimSuffix(r.URL.Path, "/") {
+		case "/certificates/winrm":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"id":  server.URL + "/certificates/winrm/v1",
+				"sid": server.URL + "/secrets/winrm/v1",
+				"cer": base64.StdEncoding.EncodeToString(cer),
+			})
+		case "/secrets/winrm/v1":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"id":          server.URL + "/secrets/winrm/v1",
+				"value":       value,
+				"contentType": contentType,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := azclient.NewSecretsClientWithBaseURI(environments.NewApiEndpoint("KeyVault", server.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Client.SetAuthorizer(fakeAuthorizer{})
+
+	return func(ctx context.Context, d *Datasource, now time.Time) (DatasourceOutput, error) {
+		return d.fetchCertificate(ctx, client, now)
+	}
+}
+
+func TestDatasourceFetchCertificate_PKCS12(t *testing.T) {
+	notAfter := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert, key := testCertificate(t, "winrm.example.com", notAfter, nil, nil)
+	pfx, err := pkcs12.EncodeModern(cert.Raw, key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetch := newTestVault(t, cert.Raw, base64.StdEncoding.EncodeToString(pfx), contentTypePKCS12)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d := &Datasource{config: Config{CertificateName: "winrm"}}
+	got, err := fetch(ctx, d, notAfter.Add(-60*24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got.Thumbprint != thumbprint(cert) || len(got.Thumbprint) != 40 || strings.ToUpper(got.Thumbprint) != got.Thumbprint {
+		t.Errorf("unexpected thumbprint %q", got.Thumbprint)
+	}
+	if got.Subject != "CN=winrm.example.com" || got.Version != "v1" || got.Expires != "2027-01-01T00:00:00Z" || got.ChainPEM != "" {
+		t.Errorf("unexpected output %+v", got)
+	}
+	block, _ := pem.Decode([]byte(got.PrivateKeyPEM))
+	if block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("expected a PKCS#8 private key, got %q", got.PrivateKeyPEM)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil || !key.Equal(parsed) {
+		t.Errorf("expected the private key of the certificate, got %v", err)
+	}
+}
+
+func TestDatasourceFetchCertificate_PEMWithChain(t *testing.T) {
+	notAfter := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	ca, caKey := testCertificate(t, "Test CA", notAfter, nil, nil)
+	leaf, _ := testCertificate(t, "winrm.example.com", notAfter, ca, caKey)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key in the secret does not belong to the certificate.
+	value := encodeCertificates(leaf, ca) + string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))
+	fetch := newTestVault(t, leaf.Raw, value, contentTypePEM)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d := &Datasource{config: Config{CertificateName: "winrm"}}
+	if _, err := fetch(ctx, d, notAfter.Add(-time.Hour)); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected a mismatched key error, got %v", err)
+	}
+
+	value = encodeCertificates(ca, leaf)
+	fetch = newTestVault(t, leaf.Raw, value, contentTypePEM)
+	got, err := fetch(ctx, d, notAfter.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got.CertificatePEM != encodeCertificates(leaf) || got.ChainPEM != encodeCertificates(ca) || got.PrivateKeyPEM != "" {
+		t.Errorf("expected the leaf, its chain and no private key, got %+v", got)
+	}
+}
+
+func TestDatasourceFetchCertificate_FailIfExpiresWithin(t *testing.T) {
+	notAfter := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert, _ := testCertificate(t, "winrm.example.com", notAfter, nil, nil)
+	fetch := newTestVault(t, cert.Raw, encodeCertificates(cert), contentTypePEM)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d := &Datasource{config: Config{CertificateName: "winrm", FailIfExpiresWithin: 30 * 24 * time.Hour}}
+	if _, err := fetch(ctx, d, notAfter.Add(-31*24*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := fetch(ctx, d, notAfter.Add(-29*24*time.Hour)); err == nil || !strings.Contains(err.Error(), "fail_if_expires_within") {
+		t.Fatalf("expected the certificate to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package keyvaultcertificate
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	sdkClient "github.com/hashicorp/go-azure-sdk/sdk/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/pkcs12"
+)
+
+const (
+	contentTypePKCS12 = "application/x-pkcs12"
+	contentTypePEM    = "application/x-pem-file"
+)
+
+// Certificate is a Key Vault certificate, as returned by the data plane API.
+type Certificate struct {
+	ID string `json:"id"`
+	// The identifier of the secret that holds the certificate and its key.
+	SecretID string `json:"sid"`
+	// The base64 encoded DER content of the certificate.
+	CER string `json:"cer"`
+}
+
+// getCertificate returns the certificate name at version from the vault. The
+// latest version is returned when version is empty.
+func getCertificate(ctx context.Context, client *secrets.SecretsClient, name, version string) (*Certificate, error) {
+	opts := sdkClient.RequestOptions{
+		ContentType: "application/json; charset=utf-8",
+		ExpectedStatusCodes: []int{
+			http.StatusOK,
+		},
+		HttpMethod: http.MethodGet,
+		Path:       fmt.Sprintf("/certificates/%s/%s", name, version),
+	}
+
+	req, err := client.Client.NewRequest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := req.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var model Certificate
+	if err := resp.Unmarshal(&model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// secretNameAndVersion returns the name and version of the secret identified
+// by sid, for example https://myvault.vault.azure.net/secrets/name/version.
+func secretNameAndVersion(sid string) (string, string, error) {
+	u, err := url.Parse(sid)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid secret identifier %q: %w", sid, err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) != 3 || segments[0] != "secrets" {
+		return "", "", fmt.Errorf("invalid secret identifier %q", sid)
+	}
+	return segments[1], segments[2], nil
+}
+
+// certificateBundle is the decoded content of a certificate secret.
+type certificateBundle struct {
+	leaf       *x509.Certificate
+	chain      []*x509.Certificate
+	privateKey crypto.PrivateKey
+}
+
+// decodeCertificateSecret decodes the value of the secret backing a Key
+// Vault certificate. Depending on contentType, value is either a base64
+// encoded PKCS#12 archive without password or PEM blocks. cer is the DER
+// content of the certificate, which identifies the leaf certificate among the
+// ones in the secret.
+func decodeCertificateSecret(value, contentType string, cer []byte) (*certificateBundle, error) {
+	var blocks []*pem.Block
+	switch contentType {
+	case contentTypePKCS12, "":
+		pfx, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the PKCS#12 content: %w", err)
+		}
+		blocks, err = pkcs12.ToPEM(pfx, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the PKCS#12 content: %w", err)
+		}
+	case contentTypePEM:
+		rest := []byte(value)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported certificate content type %q", contentType)
+	}
+
+	bundle := &certificateBundle{}
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			key, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			bundle.privateKey = key
+		}
+	}
+
+	for _, cert := range certs {
+		if bundle.leaf == nil && bytes.Equal(cert.Raw, cer) {
+			bundle.leaf = cert
+			continue
+		}
+		bundle.chain = append(bundle.chain, cert)
+	}
+	if bundle.leaf == nil {
+		leaf, err := x509.ParseCertificate(cer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		bundle.leaf = leaf
+	}
+	return bundle, nil
+}
+
+// parsePrivateKey parses a PKCS#8, PKCS#1 or SEC 1 encoded private key. The
+// pkcs12 package labels PKCS#1 and SEC 1 keys as "PRIVATE KEY".
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key: unsupported key format")
+}
+
+// publicKeyMatches reports whether key is the private key of cert.
+func publicKeyMatches(cert *x509.Certificate, key crypto.PrivateKey) bool {
+	var public crypto.PublicKey
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		public = k.Public()
+	case *ecdsa.PrivateKey:
+		public = k.Public()
+	case ed25519.PrivateKey:
+		public = k.Public()
+	default:
+		return false
+	}
+	p, ok := public.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && p.Equal(cert.PublicKey)
+}
+
+// thumbprint returns the SHA-1 thumbprint of cert as used by Windows, in
+// upper case hexadecimal.
+func thumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func encodeCertificates(certs ...*x509.Certificate) string {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.String()
+}
+
+func encodePrivateKey(key crypto.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// decodeCER decodes the cer field of a certificate, which is base64 encoded
+// with or without padding.
+func decodeCER(cer string) ([]byte, error) {
+	if der, err := base64.StdEncoding.DecodeString(cer); err == nil {
+		return der, nil
+	}
+	der, err := base64.RawURLEncoding.DecodeString(cer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate content: %w", err)
+	}
+	return der, nil
+}
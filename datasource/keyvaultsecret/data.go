@@ -11,12 +11,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"time"
 
-	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
-	sdkClient "github.com/hashicorp/go-azure-sdk/sdk/client"
-	"github.com/hashicorp/go-azure-sdk/sdk/environments"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
 	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
@@ -31,16 +27,28 @@ import (
 type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 
-	// The name of the Azure Key Vault.
-	VaultName string `mapstructure:"vault_name" required:"true"`
+	// The name of the Azure Key Vault. The vault host name is derived from the
+	// name and the DNS suffix of the configured `cloud_environment_name`.
+	// Either `vault_name` or `vault_uri` must be specified.
+	VaultName string `mapstructure:"vault_name"`
+	// The full URI of the Azure Key Vault, for example
+	// `https://myvault.privatelink.vaultcore.azure.net`. Use this instead of
+	// `vault_name` when the vault is reached through a private endpoint or a
+	// custom DNS name.
+	VaultURI string `mapstructure:"vault_uri"`
 	// The name of the secret to fetch from the Azure Key Vault.
 	SecretName string `mapstructure:"secret_name" required:"true"`
 	// The version of the secret to fetch. If not provided, the latest version will be used.
 	Version string `mapstructure:"version"`
+	// The time to wait for the secret to be retrieved, including
+	// authentication. Defaults to `1000s`.
+	Timeout time.Duration `mapstructure:"timeout"`
 
 	azclient.Config `mapstructure:",squash"` // Embed ClientConfig to allow for common client configuration
 }
 
+const defaultTimeout = 1000 * time.Second
+
 type Datasource struct {
 	config Config
 }
@@ -69,13 +77,18 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 
 	errs := new(packersdk.MultiError)
 
-	if d.config.VaultName == "" {
-		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'vault_name' must be specified"))
-	}
+	errs = ValidateVault(errs, d.config.VaultName, d.config.VaultURI)
 	if d.config.SecretName == "" {
 		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'secret_name' must be specified"))
 	}
 
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
 	d.config.Validate(errs)
 
 	err = d.config.SetDefaultValues()
@@ -95,94 +108,35 @@ func (d *Datasource) Execute() (cty.Value, error) {
 	if err != nil {
 		return cty.NullVal(cty.EmptyObject), err
 	}
+	defer d.config.Close()
 
-	vaultURI := fmt.Sprintf("https://%s.vault.azure.net", d.config.VaultName)
-	endpoint := environments.NewApiEndpoint("KeyVault", vaultURI, nil)
-	client, err := NewSecretsClientWithBaseURI(endpoint)
+	vaultURI, err := VaultURI(*d.config.CloudEnvironment(), d.config.VaultName, d.config.VaultURI)
 	if err != nil {
-		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create secrets client: %w", err)
+		return cty.NullVal(cty.EmptyObject), err
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 1000*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
 	defer cancel()
 
-	authOptions := azclient.AzureAuthOptions{
-		AuthType:           d.config.AuthType(),
-		ClientID:           d.config.ClientID,
-		ClientSecret:       d.config.ClientSecret,
-		ClientJWT:          d.config.ClientJWT,
-		ClientCertPath:     d.config.ClientCertPath,
-		ClientCertPassword: d.config.ClientCertPassword,
-		TenantID:           d.config.TenantID,
-		SubscriptionID:     d.config.SubscriptionID,
-		OidcRequestUrl:     d.config.OidcRequestURL,
-		OidcRequestToken:   d.config.OidcRequestToken,
-	}
-
-	authorizer, err := azclient.BuildKeyVaultAuthorizer(ctx, authOptions, *d.config.CloudEnvironment())
+	client, err := azclient.NewAuthorizedSecretsClient(ctx, &d.config.Config, vaultURI)
 	if err != nil {
-		log.Printf("failed to create Key Vault authorizer: %v", err)
-		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create Key Vault authorizer: %w", err)
+		log.Printf("failed to create Key Vault client: %v", err)
+		return cty.NullVal(cty.EmptyObject), err
 	}
 
-	client.Client.SetAuthorizer(authorizer)
-
-	result, err := d.getSecret(ctx, client)
+	result, err := azclient.GetSecret(ctx, client, d.config.SecretName, d.config.Version)
 	if err != nil {
 		log.Printf("failed to get secret: %v", err)
-		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get secret %q from vault %q: %w", d.config.SecretName, d.config.VaultName, err)
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get secret %q from vault %q: %w", d.config.SecretName, vaultURI, err)
 	}
 
 	bytes, err := io.ReadAll(result.HttpResponse.Body)
 	if err != nil {
 		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to read response body: %w", err)
 	}
-	log.Printf("[DEBUG] Retrieved secret %q from vault %q", d.config.SecretName, d.config.VaultName)
+	log.Printf("[DEBUG] Retrieved secret %q from vault %q", d.config.SecretName, vaultURI)
 
 	return hcl2helper.HCL2ValueFromConfig(DatasourceOutput{
 		Response: string(bytes),
 		Value:    result.Model.Value,
 	}, d.OutputSpec()), nil
 }
-
-// We are using the SecretsClient from the secrets package, which is a wrapper around the resourcemanager.Client.
-// This allows us to use the same client for both the SecretsClient and the resourcemanager.Client,
-// while still providing the necessary functionality to interact with Azure Key Vault secrets.
-//
-// Using the SecretsClient directly for fetching secrets currently only allows us
-// to get the secret's metadata, and not the actual secret value.
-func (d *Datasource) getSecret(ctx context.Context, client *secrets.SecretsClient) (result GetOperationResponse, err error) {
-	// Implementation for retrieving the secret goes here
-	opts := sdkClient.RequestOptions{
-		ContentType: "application/json; charset=utf-8",
-		ExpectedStatusCodes: []int{
-			http.StatusOK,
-		},
-		HttpMethod: http.MethodGet,
-		Path:       fmt.Sprintf("/secrets/%s/%s", d.config.SecretName, d.config.Version),
-	}
-
-	req, err := client.Client.NewRequest(ctx, opts)
-	if err != nil {
-		return
-	}
-
-	var resp *sdkClient.Response
-	resp, err = req.Execute(ctx)
-	if resp != nil {
-		result.OData = resp.OData
-		result.HttpResponse = resp.Response
-	}
-	if err != nil {
-		return
-	}
-
-	var model Secret
-	result.Model = &model
-	if err = resp.Unmarshal(result.Model); err != nil {
-		return
-	}
-
-	return result, nil
-
-}
@@ -18,9 +18,11 @@ type FlatConfig struct {
 	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
 	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
 	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	VaultName            *string           `mapstructure:"vault_name" required:"true" cty:"vault_name" hcl:"vault_name"`
+	VaultName            *string           `mapstructure:"vault_name" cty:"vault_name" hcl:"vault_name"`
+	VaultURI             *string           `mapstructure:"vault_uri" cty:"vault_uri" hcl:"vault_uri"`
 	SecretName           *string           `mapstructure:"secret_name" required:"true" cty:"secret_name" hcl:"secret_name"`
 	Version              *string           `mapstructure:"version" cty:"version" hcl:"version"`
+	Timeout              *string           `mapstructure:"timeout" cty:"timeout" hcl:"timeout"`
 	CloudEnvironmentName *string           `mapstructure:"cloud_environment_name" required:"false" cty:"cloud_environment_name" hcl:"cloud_environment_name"`
 	MetadataHost         *string           `mapstructure:"metadata_host" required:"false" cty:"metadata_host" hcl:"metadata_host"`
 	ClientID             *string           `mapstructure:"client_id" cty:"client_id" hcl:"client_id"`
@@ -34,6 +36,10 @@ type FlatConfig struct {
 	OidcRequestToken     *string           `mapstructure:"oidc_request_token" cty:"oidc_request_token" hcl:"oidc_request_token"`
 	OidcRequestURL       *string           `mapstructure:"oidc_request_url" cty:"oidc_request_url" hcl:"oidc_request_url"`
 	UseAzureCLIAuth      *bool             `mapstructure:"use_azure_cli_auth" required:"false" cty:"use_azure_cli_auth" hcl:"use_azure_cli_auth"`
+	HTTPProxy            *string           `mapstructure:"http_proxy" required:"false" cty:"http_proxy" hcl:"http_proxy"`
+	NoProxy              *string           `mapstructure:"no_proxy" required:"false" cty:"no_proxy" hcl:"no_proxy"`
+	CABundleFile         *string           `mapstructure:"ca_bundle_file" required:"false" cty:"ca_bundle_file" hcl:"ca_bundle_file"`
+	TraceFile            *string           `mapstructure:"azure_trace_file" required:"false" cty:"azure_trace_file" hcl:"azure_trace_file"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -57,8 +63,10 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"vault_name":                 &hcldec.AttrSpec{Name: "vault_name", Type: cty.String, Required: false},
+		"vault_uri":                  &hcldec.AttrSpec{Name: "vault_uri", Type: cty.String, Required: false},
 		"secret_name":                &hcldec.AttrSpec{Name: "secret_name", Type: cty.String, Required: false},
 		"version":                    &hcldec.AttrSpec{Name: "version", Type: cty.String, Required: false},
+		"timeout":                    &hcldec.AttrSpec{Name: "timeout", Type: cty.String, Required: false},
 		"cloud_environment_name":     &hcldec.AttrSpec{Name: "cloud_environment_name", Type: cty.String, Required: false},
 		"metadata_host":              &hcldec.AttrSpec{Name: "metadata_host", Type: cty.String, Required: false},
 		"client_id":                  &hcldec.AttrSpec{Name: "client_id", Type: cty.String, Required: false},
@@ -72,6 +80,10 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"oidc_request_token":         &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
 		"oidc_request_url":           &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
 		"use_azure_cli_auth":         &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
+		"http_proxy":                 &hcldec.AttrSpec{Name: "http_proxy", Type: cty.String, Required: false},
+		"no_proxy":                   &hcldec.AttrSpec{Name: "no_proxy", Type: cty.String, Required: false},
+		"ca_bundle_file":             &hcldec.AttrSpec{Name: "ca_bundle_file", Type: cty.String, Required: false},
+		"azure_trace_file":           &hcldec.AttrSpec{Name: "azure_trace_file", Type: cty.String, Required: false},
 	}
 	return s
 }
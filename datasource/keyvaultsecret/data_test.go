@@ -5,6 +5,9 @@ package keyvaultsecret
 
 import (
 	"testing"
+	"time"
+
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
 )
 
 func TestDatasourceConfigure_EmptyVaultName(t *testing.T) {
@@ -43,3 +46,71 @@ func TestDatasourceConfigure_Defaults(t *testing.T) {
 		t.Fatalf("unexpected error: %s", err)
 	}
 }
+
+func TestDatasourceConfigure_VaultURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "vault_uri",
+			config: Config{SecretName: "test-secret", VaultURI: "https://test-vault.privatelink.vaultcore.azure.net/"},
+		},
+		{
+			name:    "vault_name and vault_uri",
+			config:  Config{SecretName: "test-secret", VaultName: "test-vault", VaultURI: "https://test-vault.vault.azure.net"},
+			wantErr: true,
+		},
+		{
+			name:    "plain http vault_uri",
+			config:  Config{SecretName: "test-secret", VaultURI: "http://test-vault.vault.azure.net"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{config: tt.config}
+			err := d.Configure()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatasourceConfigure_Timeout(t *testing.T) {
+	d := &Datasource{config: Config{SecretName: "test-secret", VaultName: "test-vault"}}
+	if err := d.Configure(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.config.Timeout != defaultTimeout {
+		t.Errorf("expected default timeout %s, got %s", defaultTimeout, d.config.Timeout)
+	}
+
+	d = &Datasource{config: Config{SecretName: "test-secret", VaultName: "test-vault", Timeout: -time.Second}}
+	if err := d.Configure(); err == nil {
+		t.Fatal("expected error for a negative timeout")
+	}
+}
+
+func TestVaultURI(t *testing.T) {
+	tests := map[string]Config{
+		"https://test-vault.vault.azure.cn":                  {SecretName: "s", VaultName: "test-vault", Config: azclient.Config{CloudEnvironmentName: "China"}},
+		"https://test-vault.vault.azure.net":                 {SecretName: "s", VaultName: "test-vault"},
+		"https://test-vault.privatelink.vaultcore.azure.net": {SecretName: "s", VaultURI: "https://test-vault.privatelink.vaultcore.azure.net/"},
+	}
+	for want, config := range tests {
+		d := &Datasource{config: config}
+		if err := d.Configure(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		got, err := VaultURI(*d.config.CloudEnvironment(), d.config.VaultName, d.config.VaultURI)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != want {
+			t.Errorf("vaultURI() = %q, want %q", got, want)
+		}
+	}
+}
@@ -4,36 +4,36 @@
 package keyvaultsecret
 
 import (
-	"fmt"
-	"net/http"
+	"errors"
+	"strings"
 
-	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
-	"github.com/hashicorp/go-azure-sdk/sdk/client/resourcemanager"
-	"github.com/hashicorp/go-azure-sdk/sdk/odata"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 
 	sdkEnv "github.com/hashicorp/go-azure-sdk/sdk/environments"
 )
 
-const KeyVaultAPIVersion = "7.5"
-
-type Secret struct {
-	secrets.Secret `mapstructure:",squash"`
-	Value          string `mapstructure:"value"`
-}
-
-type GetOperationResponse struct {
-	HttpResponse *http.Response
-	OData        *odata.OData
-	Model        *Secret
+// ValidateVault checks that exactly one of vault_name and vault_uri is set,
+// and that vault_uri is a valid vault URI.
+func ValidateVault(errs *packersdk.MultiError, vaultName, vaultURI string) *packersdk.MultiError {
+	switch {
+	case vaultName == "" && vaultURI == "":
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'vault_name' or 'vault_uri' must be specified"))
+	case vaultName != "" && vaultURI != "":
+		errs = packersdk.MultiErrorAppend(errs, errors.New("only one of 'vault_name' or 'vault_uri' can be specified"))
+	case vaultURI != "":
+		if err := azclient.ValidateKeyVaultURI(vaultURI); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	return errs
 }
 
-func NewSecretsClientWithBaseURI(sdkApi sdkEnv.Api) (*secrets.SecretsClient, error) {
-	client, err := resourcemanager.NewClient(sdkApi, "secrets", KeyVaultAPIVersion)
-	if err != nil {
-		return nil, fmt.Errorf("instantiating SecretsClient: %+v", err)
+// VaultURI returns the base URI of the vault, either vaultURI or the URI
+// derived from vaultName and the cloud environment env.
+func VaultURI(env sdkEnv.Environment, vaultName, vaultURI string) (string, error) {
+	if vaultURI != "" {
+		return strings.TrimSuffix(vaultURI, "/"), nil
 	}
-
-	return &secrets.SecretsClient{
-		Client: client,
-	}, nil
+	return azclient.KeyVaultURI(env, vaultName)
 }
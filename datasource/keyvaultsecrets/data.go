@@ -0,0 +1,236 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package keyvaultsecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/keyvault/2023-07-01/secrets"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-azure/datasource/keyvaultsecret"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	defaultTimeout     = 1000 * time.Second
+	defaultConcurrency = 8
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The name of the Azure Key Vault. The vault host name is derived from the
+	// name and the DNS suffix of the configured `cloud_environment_name`.
+	// Either `vault_name` or `vault_uri` must be specified.
+	VaultName string `mapstructure:"vault_name"`
+	// The full URI of the Azure Key Vault, for example
+	// `https://myvault.privatelink.vaultcore.azure.net`. Use this instead of
+	// `vault_name` when the vault is reached through a private endpoint or a
+	// custom DNS name.
+	VaultURI string `mapstructure:"vault_uri"`
+	// Only fetch the secrets whose name starts with this prefix.
+	NamePrefix string `mapstructure:"name_prefix"`
+	// Only fetch the secrets whose name matches this regular expression.
+	NameRegex string `mapstructure:"name_regex"`
+	nameRegex *regexp.Regexp
+	// Only fetch the secrets that have all of these tags, with the same values.
+	Tags map[string]string `mapstructure:"tags"`
+	// The maximum number of secrets fetched at the same time. Defaults to `8`.
+	Concurrency int `mapstructure:"concurrency"`
+	// The time to wait for all the secrets to be retrieved, including
+	// authentication. Defaults to `1000s`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The values of the secrets, by secret name.
+	Secrets map[string]string `mapstructure:"secrets"`
+	// The current versions of the secrets, by secret name.
+	Versions map[string]string `mapstructure:"versions"`
+	// The content types of the secrets, by secret name. Secrets without a
+	// content type are omitted.
+	ContentTypes map[string]string `mapstructure:"content_types"`
+	// The expiry dates of the secrets in RFC 3339 format, by secret name.
+	// Secrets without an expiry date are omitted.
+	Expires map[string]string `mapstructure:"expires"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	errs = keyvaultsecret.ValidateVault(errs, d.config.VaultName, d.config.VaultURI)
+	if d.config.NameRegex != "" {
+		d.config.nameRegex, err = regexp.Compile(d.config.NameRegex)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name_regex' is invalid: %w", err))
+		}
+	}
+	if d.config.Concurrency < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'concurrency' must not be negative"))
+	}
+	if d.config.Concurrency == 0 {
+		d.config.Concurrency = defaultConcurrency
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	vaultURI, err := keyvaultsecret.VaultURI(*d.config.CloudEnvironment(), d.config.VaultName, d.config.VaultURI)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	client, err := azclient.NewAuthorizedSecretsClient(ctx, &d.config.Config, vaultURI)
+	if err != nil {
+		log.Printf("failed to create Key Vault client: %v", err)
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := d.fetchSecrets(ctx, client, time.Now())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get secrets from vault %q: %w", vaultURI, err)
+	}
+	log.Printf("[DEBUG] Retrieved %d secrets from vault %q", len(output.Secrets), vaultURI)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// fetchSecrets lists the secrets of the vault and fetches the values of the
+// ones that match the filters and are active at time now, at most
+// concurrency at a time.
+func (d *Datasource) fetchSecrets(ctx context.Context, client *secrets.SecretsClient, now time.Time) (DatasourceOutput, error) {
+	items, err := azclient.ListSecrets(ctx, client)
+	if err != nil {
+		return DatasourceOutput{}, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	var names []string
+	for _, item := range items {
+		if d.matches(item, now) {
+			names = append(names, item.Name())
+		}
+	}
+	sort.Strings(names)
+
+	output := DatasourceOutput{
+		Secrets:      make(map[string]string, len(names)),
+		Versions:     make(map[string]string, len(names)),
+		ContentTypes: make(map[string]string),
+		Expires:      make(map[string]string),
+	}
+	var mu sync.Mutex
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.config.Concurrency)
+	for _, name := range names {
+		g.Go(func() error {
+			result, err := azclient.GetSecret(ctx, client, name, "")
+			if err != nil {
+				return fmt.Errorf("failed to get secret %q: %w", name, err)
+			}
+			secret := result.Model
+
+			mu.Lock()
+			defer mu.Unlock()
+			output.Secrets[name] = secret.Value
+			output.Versions[name] = secret.Version()
+			if secret.ContentType != "" {
+				output.ContentTypes[name] = secret.ContentType
+			}
+			if secret.Attributes != nil && secret.Attributes.Expires != nil {
+				output.Expires[name] = time.Unix(*secret.Attributes.Expires, 0).UTC().Format(time.RFC3339)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return DatasourceOutput{}, err
+	}
+	return output, nil
+}
+
+// matches reports whether item passes the name and tag filters and is
+// enabled and valid at time now.
+func (d *Datasource) matches(item azclient.SecretItem, now time.Time) bool {
+	name := item.Name()
+	if !strings.HasPrefix(name, d.config.NamePrefix) {
+		return false
+	}
+	if d.config.nameRegex != nil && !d.config.nameRegex.MatchString(name) {
+		return false
+	}
+	for k, v := range d.config.Tags {
+		if tag, ok := item.Tags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	if !item.Attributes.IsActive(now) {
+		log.Printf("[DEBUG] Skipping secret %q, it is disabled, expired or not yet valid", name)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package keyvaultsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"golang.org/x/oauth2"
+)
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Token(context.Context, *http.Request) (*oauth2.Token, error) {
+	return &oauth2.Token{AccessToken: "token", TokenType: "Bearer"}, nil
+}
+
+func (fakeAuthorizer) AuxiliaryTokens(context.Context, *http.Request) ([]*oauth2.Token, error) {
+	return nil, nil
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			config: Config{VaultName: "test-vault"},
+		},
+		{
+			name:    "no vault",
+			config:  Config{NamePrefix: "build-"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			config:  Config{VaultName: "test-vault", NameRegex: "build-("},
+			wantErr: true,
+		},
+		{
+			name:    "negative concurrency",
+			config:  Config{VaultName: "test-vault", Concurrency: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{config: tt.config}
+			err := d.Configure()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (d.config.Concurrency != defaultConcurrency || d.config.Timeout != defaultTimeout) {
+				t.Errorf("expected defaults to be set, got concurrency %d and timeout %s", d.config.Concurrency, d.config.Timeout)
+			}
+		})
+	}
+}
+
+func TestDatasourceFetchSecrets(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	expires := now.Add(24 * time.Hour).Unix()
+	expired := now.Add(-time.Hour).Unix()
+
+	var inFlight, maxInFlight int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		id := func(name string) string { return fmt.Sprintf("%s/secrets/%s", server.URL, name) }
+		switch {
+		case r.URL.Path == "/secrets" && r.URL.Query().Get("page") == "":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []interface{}{
+					map[string]interface{}{"id": id("build-one"), "tags": map[string]string{"purpose": "packer"}, "attributes": map[string]interface{}{"enabled": true, "exp": expires}},
+					map[string]interface{}{"id": id("build-disabled"), "tags": map[string]string{"purpose": "packer"}, "attributes": map[string]interface{}{"enabled": false}},
+					map[string]interface{}{"id": id("other"), "tags": map[string]string{"purpose": "packer"}},
+				},
+				"nextLink": server.URL + "/secrets?page=2",
+			})
+		case r.URL.Path == "/secrets":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []interface{}{
+					map[string]interface{}{"id": id("build-two"), "tags": map[string]string{"purpose": "packer"}},
+					map[string]interface{}{"id": id("build-expired"), "tags": map[string]string{"purpose": "packer"}, "attributes": map[string]interface{}{"exp": expired}},
+					map[string]interface{}{"id": id("build-untagged")},
+				},
+			})
+		case strings.HasPrefix(r.URL.Path, "/secrets/"):
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/secrets/"), "/")
+			secret := map[string]interface{}{"id": id(name) + "/v1", "value": "value-of-" + name}
+			if name == "build-one" {
+				secret["contentType"] = "text/plain"
+				secret["attributes"] = map[string]interface{}{"enabled": true, "exp": expires}
+			}
+			_ = json.NewEncoder(w).Encode(secret)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := azclient.NewSecretsClientWithBaseURI(environments.NewApiEndpoint("KeyVault", server.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Client.SetAuthorizer(fakeAuthorizer{})
+
+	d := &Datasource{config: Config{
+		VaultURI:    server.URL,
+		NamePrefix:  "build-",
+		Tags:        map[string]string{"purpose": "packer"},
+		Concurrency: 1,
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	got, err := d.fetchSecrets(ctx, client, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := DatasourceOutput{
+		Secrets:      map[string]string{"build-one": "value-of-build-one", "build-two": "value-of-build-two"},
+		Versions:     map[string]string{"build-one": "v1", "build-two": "v1"},
+		ContentTypes: map[string]string{"build-one": "text/plain"},
+		Expires:      map[string]string{"build-one": time.Unix(expires, 0).UTC().Format(time.RFC3339)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetchSecrets() = %+v, want %+v", got, want)
+	}
+	if maxInFlight != 1 {
+		t.Errorf("expected at most 1 secret to be fetched at a time, got %d", maxInFlight)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package managedimage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/images"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-azure/version"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/useragent"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// Only search the managed images of this resource group. By default the
+	// whole subscription is searched.
+	ResourceGroupName string `mapstructure:"resource_group_name"`
+	// Only match the managed images whose name matches this regular
+	// expression, for example `^ubuntu-base-`.
+	NameRegex string `mapstructure:"name_regex"`
+	nameRegex *regexp.Regexp
+	// Only match the managed images that have all of these tags, with the same
+	// values.
+	Tags map[string]string `mapstructure:"tags"`
+	// Only match the managed images in this region.
+	Location string `mapstructure:"location"`
+	// Return the managed image with the highest value of this tag instead of
+	// the most recently created one. Values are compared as versions when
+	// they parse as versions, for example `1.10.0` is higher than `1.9.0`, and
+	// as strings otherwise. Images without the tag are not matched. Images
+	// with the same value are ordered by name, and the last one is returned.
+	SortByTag string `mapstructure:"sort_by_tag"`
+	// The time to wait for the lookup to complete, including authentication.
+	// Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The resource ID of the managed image.
+	ID string `mapstructure:"id"`
+	// The name of the managed image.
+	Name string `mapstructure:"name"`
+	// The resource group of the managed image. Use it with `name` as
+	// `custom_managed_image_resource_group_name` and `custom_managed_image_name`.
+	ResourceGroupName string `mapstructure:"resource_group_name"`
+	// The region of the managed image.
+	Location string `mapstructure:"location"`
+	// The OS type of the managed image, `Linux` or `Windows`.
+	OSType string `mapstructure:"os_type"`
+	// The OS state of the managed image, `Generalized` or `Specialized`.
+	OSState string `mapstructure:"os_state"`
+	// The Hyper-V generation of the managed image, `V1` or `V2`.
+	HyperVGeneration string `mapstructure:"hyper_v_generation"`
+	// The resource ID of the virtual machine the managed image was captured
+	// from, if any.
+	SourceVMID string `mapstructure:"source_vm_id"`
+	// The creation time of the managed image in RFC 3339 format, if known.
+	CreatedAt string `mapstructure:"created_at"`
+	// The tags of the managed image.
+	Tags map[string]string `mapstructure:"tags"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.NameRegex != "" {
+		d.config.nameRegex, err = regexp.Compile(d.config.NameRegex)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name_regex' is invalid: %w", err))
+		}
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	azcli, err := azclient.New(d.config.Config, func(s string) { log.Print(s) })
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create Azure client: %w", err)
+	}
+
+	// The images client of the client set uses an API version that does not
+	// return the creation time of images.
+	imagesClient, err := images.NewImagesClientWithBaseURI(d.config.CloudEnvironment().ResourceManager)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create images client: %w", err)
+	}
+	imagesClient.Client.Authorizer = azcli.TokenAuthorizer()
+	azclient.ConfigureTransport(imagesClient.Client, d.config.Transport())
+	imagesClient.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), imagesClient.Client.UserAgent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	var items []images.Image
+	scope := "subscription " + azcli.SubscriptionID()
+	if d.config.ResourceGroupName != "" {
+		scope = "resource group " + d.config.ResourceGroupName
+		result, err := imagesClient.ListByResourceGroupComplete(ctx, commonids.NewResourceGroupID(azcli.SubscriptionID(), d.config.ResourceGroupName))
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the managed images of %s: %w", scope, err)
+		}
+		items = result.Items
+	} else {
+		result, err := imagesClient.ListComplete(ctx, commonids.NewSubscriptionID(azcli.SubscriptionID()))
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the managed images of %s: %w", scope, err)
+		}
+		items = result.Items
+	}
+
+	image, err := d.selectImage(items)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("%s in %s", err, scope)
+	}
+	output := newOutput(image)
+	log.Printf("[DEBUG] Selected managed image %q", output.ID)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
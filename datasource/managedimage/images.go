@@ -0,0 +1,132 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package managedimage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/images"
+	goversion "github.com/hashicorp/go-version"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+)
+
+// selectImage returns the newest of candidates that passes the filters of
+// the configuration, by creation time or by the value of sort_by_tag.
+// Images without a known creation time sort as the oldest, and ties are
+// broken by name so that the same image is returned every time.
+func (d *Datasource) selectImage(candidates []images.Image) (*images.Image, error) {
+	var matches []images.Image
+	for _, image := range candidates {
+		if d.matches(image) {
+			matches = append(matches, image)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, errors.New("no managed image matches the filters")
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if d.config.SortByTag != "" {
+			a, b := tags(matches[i])[d.config.SortByTag], tags(matches[j])[d.config.SortByTag]
+			if lessTagValue(a, b) || lessTagValue(b, a) {
+				return lessTagValue(a, b)
+			}
+		} else if a, b := createdAt(matches[i]), createdAt(matches[j]); !a.Equal(b) {
+			return a.Before(b)
+		}
+		return *matches[i].Name < *matches[j].Name
+	})
+	return &matches[len(matches)-1], nil
+}
+
+func (d *Datasource) matches(image images.Image) bool {
+	if image.Id == nil || image.Name == nil {
+		return false
+	}
+	if image.Properties != nil && image.Properties.ProvisioningState != nil && !strings.EqualFold(*image.Properties.ProvisioningState, "Succeeded") {
+		return false
+	}
+	if d.config.nameRegex != nil && !d.config.nameRegex.MatchString(*image.Name) {
+		return false
+	}
+	if d.config.Location != "" && azclient.NormalizeLocation(image.Location) != azclient.NormalizeLocation(d.config.Location) {
+		return false
+	}
+	imageTags := tags(image)
+	for k, v := range d.config.Tags {
+		if tag, ok := imageTags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	if d.config.SortByTag != "" {
+		if _, ok := imageTags[d.config.SortByTag]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// lessTagValue compares two tag values as versions if they both parse as
+// versions, and as strings otherwise.
+func lessTagValue(a, b string) bool {
+	va, errA := goversion.NewVersion(a)
+	vb, errB := goversion.NewVersion(b)
+	if errA == nil && errB == nil {
+		return va.LessThan(vb)
+	}
+	return a < b
+}
+
+func tags(image images.Image) map[string]string {
+	if image.Tags == nil {
+		return map[string]string{}
+	}
+	return *image.Tags
+}
+
+// createdAt returns the creation time of image, or the zero time if it is
+// not known.
+func createdAt(image images.Image) time.Time {
+	if image.SystemData == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, image.SystemData.CreatedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func newOutput(image *images.Image) DatasourceOutput {
+	output := DatasourceOutput{
+		ID:       *image.Id,
+		Name:     *image.Name,
+		Location: azclient.NormalizeLocation(image.Location),
+		Tags:     tags(*image),
+	}
+	if id, err := images.ParseImageIDInsensitively(*image.Id); err == nil {
+		output.ResourceGroupName = id.ResourceGroupName
+	}
+	if t := createdAt(*image); !t.IsZero() {
+		output.CreatedAt = t.UTC().Format(time.RFC3339)
+	}
+	props := image.Properties
+	if props == nil {
+		return output
+	}
+	if props.HyperVGeneration != nil {
+		output.HyperVGeneration = string(*props.HyperVGeneration)
+	}
+	if props.SourceVirtualMachine != nil && props.SourceVirtualMachine.Id != nil {
+		output.SourceVMID = *props.SourceVirtualMachine.Id
+	}
+	if props.StorageProfile != nil && props.StorageProfile.OsDisk != nil {
+		output.OSType = string(props.StorageProfile.OsDisk.OsType)
+		output.OSState = string(props.StorageProfile.OsDisk.OsState)
+	}
+	return output
+}
@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package managedimage
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/systemdata"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-11-01/images"
+)
+
+func testImage(name, location, created string, tags map[string]string) images.Image {
+	id := fmt.Sprintf("/subscriptions/sub/resourceGroups/images-rg/providers/Microsoft.Compute/images/%s", name)
+	state := "Succeeded"
+	generation := images.HyperVGenerationTypesVTwo
+	vm := "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Compute/virtualMachines/pkrvm"
+	return images.Image{
+		Id:         &id,
+		Name:       &name,
+		Location:   location,
+		Tags:       &tags,
+		SystemData: &systemdata.SystemData{CreatedAt: created},
+		Properties: &images.ImageProperties{
+			ProvisioningState:    &state,
+			HyperVGeneration:     &generation,
+			SourceVirtualMachine: &images.SubResource{Id: &vm},
+			StorageProfile: &images.ImageStorageProfile{
+				OsDisk: &images.ImageOSDisk{OsType: images.OperatingSystemTypesLinux, OsState: images.OperatingSystemStateTypesGeneralized},
+			},
+		},
+	}
+}
+
+func TestDatasourceSelectImage(t *testing.T) {
+	candidates := []images.Image{
+		testImage("ubuntu-base-1", "West Europe", "2026-03-01T10:00:00Z", map[string]string{"role": "base", "version": "1.9.0"}),
+		testImage("ubuntu-base-2", "westeurope", "2026-04-01T10:00:00Z", map[string]string{"role": "base", "version": "1.10.0"}),
+		testImage("ubuntu-base-3", "eastus", "2026-05-01T10:00:00Z", map[string]string{"role": "base", "version": "1.8.0"}),
+		testImage("ubuntu-app-1", "westeurope", "2026-06-01T10:00:00Z", map[string]string{"role": "app"}),
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name: "newest",
+			want: "ubuntu-app-1",
+		},
+		{
+			name:   "name regex",
+			config: Config{nameRegex: regexp.MustCompile("^ubuntu-base-")},
+			want:   "ubuntu-base-3",
+		},
+		{
+			name:   "tags and location",
+			config: Config{Tags: map[string]string{"role": "base"}, Location: "westeurope"},
+			want:   "ubuntu-base-2",
+		},
+		{
+			name:   "sort by tag as versions",
+			config: Config{SortByTag: "version"},
+			want:   "ubuntu-base-2",
+		},
+		{
+			name:   "no match",
+			config: Config{Tags: map[string]string{"role": "db"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{config: tt.config}
+			got, err := d.selectImage(candidates)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("expected no image to match, got %s", *got.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *got.Name != tt.want {
+				t.Errorf("expected image %s, got %s", tt.want, *got.Name)
+			}
+		})
+	}
+}
+
+func TestDatasourceSelectImage_withoutCreationTime(t *testing.T) {
+	noSystemData := func(name string) images.Image {
+		image := testImage(name, "westeurope", "", map[string]string{"version": "1.0.0"})
+		image.SystemData = nil
+		return image
+	}
+
+	for _, config := range []Config{{}, {SortByTag: "version"}} {
+		for _, candidates := range [][]images.Image{
+			{noSystemData("image-b"), noSystemData("image-c"), noSystemData("image-a")},
+			{noSystemData("image-c"), noSystemData("image-a"), noSystemData("image-b")},
+		} {
+			d := &Datasource{config: config}
+			got, err := d.selectImage(candidates)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *got.Name != "image-c" {
+				t.Errorf("expected the highest name image-c, got %s", *got.Name)
+			}
+		}
+	}
+}
+
+func TestNewOutput(t *testing.T) {
+	image := testImage("ubuntu-base-1", "West Europe", "2026-03-01T10:00:00Z", map[string]string{"role": "base"})
+	want := DatasourceOutput{
+		ID:                *image.Id,
+		Name:              "ubuntu-base-1",
+		ResourceGroupName: "images-rg",
+		Location:          "westeurope",
+		OSType:            "Linux",
+		OSState:           "Generalized",
+		HyperVGeneration:  "V2",
+		SourceVMID:        "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Compute/virtualMachines/pkrvm",
+		CreatedAt:         "2026-03-01T10:00:00Z",
+		Tags:              map[string]string{"role": "base"},
+	}
+	if got := newOutput(&image); !reflect.DeepEqual(got, want) {
+		t.Errorf("newOutput() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package platformimage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2022-03-01/virtualmachineimages"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	defaultTimeout = 5 * time.Minute
+	dateFormat     = "2006-01-02"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The publisher of the image, for example `Canonical`.
+	Publisher string `mapstructure:"publisher" required:"true"`
+	// The offer of the image, for example `0001-com-ubuntu-server-jammy`.
+	Offer string `mapstructure:"offer" required:"true"`
+	// The SKU of the image, for example `22_04-lts-gen2`.
+	Sku string `mapstructure:"sku" required:"true"`
+	// The region to look up the image versions in.
+	Location string `mapstructure:"location" required:"true"`
+	// A version constraint the image version must satisfy, for example
+	// `>= 22.04.202406000, < 22.04.202407000`. By default all versions match.
+	VersionConstraint string `mapstructure:"version_constraint"`
+	versionConstraint goversion.Constraints
+	// Only match versions that start with these dot separated components,
+	// for example `2024.06` matches `2024.06.1` but not `2024.061.1`.
+	VersionPrefix string `mapstructure:"version_prefix"`
+	// Only match versions published before this date, in `YYYY-MM-DD`
+	// format. The publication date is taken from the version string, which
+	// for most publishers embeds a `YYYYMMDD` or `YYMMDD` build date.
+	// Versions without a recognizable date are skipped.
+	PublishedBefore string `mapstructure:"published_before"`
+	publishedBefore time.Time
+	// The time to wait for the lookup to complete, including authentication.
+	// Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The exact version of the image.
+	Version string `mapstructure:"version"`
+	// The URN of the image, `publisher:offer:sku:version`.
+	URN string `mapstructure:"urn"`
+	// The resource ID of the image version.
+	ID string `mapstructure:"id"`
+	// The OS type of the image, `Linux` or `Windows`.
+	OSType string `mapstructure:"os_type"`
+	// The Hyper-V generation of the image, `V1` or `V2`.
+	HyperVGeneration string `mapstructure:"hyper_v_generation"`
+	// The CPU architecture of the image, `x64` or `Arm64`.
+	Architecture string `mapstructure:"architecture"`
+	// The name of the purchase plan of the image, if any. Use it as
+	// `plan_name` in `plan_info`.
+	PlanName string `mapstructure:"plan_name"`
+	// The product of the purchase plan of the image, if any.
+	PlanProduct string `mapstructure:"plan_product"`
+	// The publisher of the purchase plan of the image, if any.
+	PlanPublisher string `mapstructure:"plan_publisher"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.Publisher == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'publisher' must be specified"))
+	}
+	if d.config.Offer == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("an 'offer' must be specified"))
+	}
+	if d.config.Sku == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'sku' must be specified"))
+	}
+	if d.config.Location == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'location' must be specified"))
+	}
+	if d.config.VersionConstraint != "" {
+		d.config.versionConstraint, err = goversion.NewConstraint(d.config.VersionConstraint)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'version_constraint' is invalid: %w", err))
+		}
+	}
+	if d.config.PublishedBefore != "" {
+		d.config.publishedBefore, err = time.Parse(dateFormat, d.config.PublishedBefore)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'published_before' must be a date in YYYY-MM-DD format: %w", err))
+		}
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	azcli, err := azclient.New(d.config.Config, func(s string) { log.Print(s) })
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create Azure client: %w", err)
+	}
+
+	location := azclient.NormalizeLocation(d.config.Location)
+	image := azclient.PlatformImage{Publisher: d.config.Publisher, Offer: d.config.Offer, Sku: d.config.Sku}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	skuID := virtualmachineimages.NewSkuID(azcli.SubscriptionID(), location, image.Publisher, image.Offer, image.Sku)
+	list, err := azcli.VirtualMachineImagesClient().List(ctx, skuID, virtualmachineimages.DefaultListOperationOptions())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the versions of %s:%s:%s in %s: %w", image.Publisher, image.Offer, image.Sku, location, err)
+	}
+	if list.Model == nil {
+		return cty.NullVal(cty.EmptyObject), azclient.NullModelSDKErr
+	}
+
+	var names []string
+	for _, v := range *list.Model {
+		names = append(names, v.Name)
+	}
+	image.Version, err = d.selectVersion(names)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("%s for %s:%s:%s in %s", err, image.Publisher, image.Offer, image.Sku, location)
+	}
+	log.Printf("[DEBUG] Selected platform image %s", image.URN())
+
+	versionID := virtualmachineimages.NewSkuVersionID(azcli.SubscriptionID(), location, image.Publisher, image.Offer, image.Sku, image.Version)
+	result, err := azcli.VirtualMachineImagesClient().Get(ctx, versionID)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get platform image %s: %w", image.URN(), err)
+	}
+	if result.Model == nil {
+		return cty.NullVal(cty.EmptyObject), azclient.NullModelSDKErr
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(newOutput(image, result.Model), d.OutputSpec()), nil
+}
+
+func newOutput(image azclient.PlatformImage, vmi *virtualmachineimages.VirtualMachineImage) DatasourceOutput {
+	output := DatasourceOutput{
+		Version: image.Version,
+		URN:     image.URN(),
+	}
+	if vmi.Id != nil {
+		output.ID = *vmi.Id
+	}
+	props := vmi.Properties
+	if props == nil {
+		return output
+	}
+	if props.OsDiskImage != nil {
+		output.OSType = string(props.OsDiskImage.OperatingSystem)
+	}
+	if props.HyperVGeneration != nil {
+		output.HyperVGeneration = string(*props.HyperVGeneration)
+	}
+	if props.Architecture != nil {
+		output.Architecture = string(*props.Architecture)
+	}
+	if props.Plan != nil {
+		output.PlanName = props.Plan.Name
+		output.PlanProduct = props.Plan.Product
+		output.PlanPublisher = props.Plan.Publisher
+	}
+	return output
+}
@@ -0,0 +1,72 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package platformimage
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+)
+
+// selectVersion returns the highest of the image versions names that passes
+// the filters of the configuration.
+func (d *Datasource) selectVersion(names []string) (string, error) {
+	var selected *goversion.Version
+	for _, name := range names {
+		v, err := goversion.NewVersion(name)
+		if err != nil {
+			log.Printf("[DEBUG] Skipping image version %q: %v", name, err)
+			continue
+		}
+		if selected != nil && !v.GreaterThan(selected) {
+			continue
+		}
+		if !d.matches(name, v) {
+			continue
+		}
+		selected = v
+	}
+	if selected == nil {
+		return "", errors.New("no image version matches the filters")
+	}
+	return selected.Original(), nil
+}
+
+func (d *Datasource) matches(name string, v *goversion.Version) bool {
+	if d.config.VersionPrefix != "" && name != d.config.VersionPrefix && !strings.HasPrefix(name, d.config.VersionPrefix+".") {
+		return false
+	}
+	if d.config.versionConstraint != nil && !d.config.versionConstraint.Check(v) {
+		return false
+	}
+	if !d.config.publishedBefore.IsZero() {
+		published, ok := versionDate(name)
+		if !ok || !published.Before(d.config.publishedBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// versionDate returns the build date embedded in an image version, for
+// example 2024-06-14 for the Ubuntu version 22.04.202406140 or 2024-06-05 for
+// the Windows Server version 20348.2527.240605.
+func versionDate(version string) (time.Time, bool) {
+	for _, component := range strings.Split(version, ".") {
+		if len(component) >= 8 {
+			if t, err := time.Parse("20060102", component[:8]); err == nil && t.Year() >= 2000 && t.Year() < 2100 {
+				return t, true
+			}
+		}
+		if len(component) == 6 {
+			if t, err := time.Parse("060102", component); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,116 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package platformimage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVersionDate(t *testing.T) {
+	tests := map[string]string{
+		"22.04.202406140":   "2024-06-14",
+		"20348.2527.240605": "2024-06-05",
+		"0.20240611.1773":   "2024-06-11",
+		"9.4.2024061417":    "2024-06-14",
+		"1.0.0":             "",
+		"7.9.123456":        "",
+	}
+	for version, want := range tests {
+		got, ok := versionDate(version)
+		if want == "" {
+			if ok {
+				t.Errorf("versionDate(%q) = %s, expected no date", version, got.Format(dateFormat))
+			}
+			continue
+		}
+		if !ok || got.Format(dateFormat) != want {
+			t.Errorf("versionDate(%q) = %s, %t, want %s", version, got.Format(dateFormat), ok, want)
+		}
+	}
+}
+
+func TestDatasourceSelectVersion(t *testing.T) {
+	versions := []string{
+		"20348.2402.240405",
+		"20348.2461.240510",
+		"20348.2527.240605",
+		"20348.2529.240619",
+		"20348.2582.240703",
+		"not-a-version",
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name: "latest",
+			want: "20348.2582.240703",
+		},
+		{
+			name:   "published before",
+			config: Config{PublishedBefore: "2024-06-19"},
+			want:   "20348.2527.240605",
+		},
+		{
+			name:   "version constraint",
+			config: Config{VersionConstraint: "< 20348.2500"},
+			want:   "20348.2461.240510",
+		},
+		{
+			name:   "version prefix",
+			config: Config{VersionPrefix: "20348.252"},
+		},
+		{
+			name:   "version prefix on component boundary",
+			config: Config{VersionPrefix: "20348.2527"},
+			want:   "20348.2527.240605",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Publisher, tt.config.Offer, tt.config.Sku, tt.config.Location = "MicrosoftWindowsServer", "WindowsServer", "2022-datacenter-g2", "westeurope"
+			d := &Datasource{config: tt.config}
+			if err := d.Configure(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := d.selectVersion(versions)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("expected no version to match, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected version %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	d := &Datasource{config: Config{Publisher: "Canonical", Offer: "ubuntu-24_04-lts", Sku: "server", Location: "westeurope", PublishedBefore: "2024/06/01"}}
+	if err := d.Configure(); err == nil {
+		t.Fatal("expected an error for an invalid published_before")
+	}
+
+	d = &Datasource{config: Config{Publisher: "Canonical", Offer: "ubuntu-24_04-lts", Location: "westeurope"}}
+	if err := d.Configure(); err == nil {
+		t.Fatal("expected an error for a missing sku")
+	}
+
+	d = &Datasource{config: Config{Publisher: "Canonical", Offer: "ubuntu-24_04-lts", Sku: "server", Location: "westeurope", PublishedBefore: "2024-06-01"}}
+	if err := d.Configure(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !d.config.publishedBefore.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected published_before %s", d.config.publishedBefore)
+	}
+}
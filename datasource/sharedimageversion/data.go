@@ -0,0 +1,209 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package sharedimageversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimages"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The subscription of the Shared Image Gallery. Defaults to `subscription_id`.
+	GallerySubscriptionID string `mapstructure:"gallery_subscription_id"`
+	// The resource group of the Shared Image Gallery.
+	ResourceGroupName string `mapstructure:"resource_group_name" required:"true"`
+	// The name of the Shared Image Gallery.
+	GalleryName string `mapstructure:"gallery_name" required:"true"`
+	// The name of the image definition in the Shared Image Gallery.
+	ImageName string `mapstructure:"image_name" required:"true"`
+	// A version constraint the image version must satisfy, for example
+	// `>= 1.4.0, < 2.0.0` or `~> 1.4`. By default all versions match.
+	VersionConstraint string `mapstructure:"version_constraint"`
+	versionConstraint goversion.Constraints
+	// Only match versions that are replicated to this region.
+	Location string `mapstructure:"location"`
+	// Only match versions that have all of these tags, with the same values.
+	Tags map[string]string `mapstructure:"tags"`
+	// Also match versions that are excluded from latest, either globally or
+	// in `location`. Defaults to `false`.
+	IncludeExcludedFromLatest bool `mapstructure:"include_excluded_from_latest"`
+	// Also match versions whose end of life date has passed. Defaults to `false`.
+	IncludeEndOfLife bool `mapstructure:"include_end_of_life"`
+	// The time to wait for the lookup to complete, including authentication.
+	// Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The resource ID of the image version.
+	ID string `mapstructure:"id"`
+	// The image version, for example `1.4.2`. Use it as `image_version` in
+	// `shared_image_gallery`.
+	Version string `mapstructure:"version"`
+	// The subscription of the Shared Image Gallery.
+	SubscriptionID string `mapstructure:"subscription_id"`
+	// The resource group of the Shared Image Gallery.
+	ResourceGroupName string `mapstructure:"resource_group_name"`
+	// The name of the Shared Image Gallery.
+	GalleryName string `mapstructure:"gallery_name"`
+	// The name of the image definition.
+	ImageName string `mapstructure:"image_name"`
+	// The regions the image version is replicated to.
+	Regions []string `mapstructure:"regions"`
+	// The end of life date of the image version in RFC 3339 format, if any.
+	EndOfLifeDate string `mapstructure:"end_of_life_date"`
+	// The OS type of the image definition, `Linux` or `Windows`.
+	OSType string `mapstructure:"os_type"`
+	// The OS state of the image definition, `Generalized` or `Specialized`.
+	OSState string `mapstructure:"os_state"`
+	// The Hyper-V generation of the image definition, `V1` or `V2`.
+	HyperVGeneration string `mapstructure:"hyper_v_generation"`
+	// The CPU architecture of the image definition, `x64` or `Arm64`.
+	Architecture string `mapstructure:"architecture"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.ResourceGroupName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'resource_group_name' must be specified"))
+	}
+	if d.config.GalleryName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'gallery_name' must be specified"))
+	}
+	if d.config.ImageName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("an 'image_name' must be specified"))
+	}
+	if d.config.VersionConstraint != "" {
+		d.config.versionConstraint, err = goversion.NewConstraint(d.config.VersionConstraint)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'version_constraint' is invalid: %w", err))
+		}
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	azcli, err := azclient.New(d.config.Config, func(s string) { log.Print(s) })
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create Azure client: %w", err)
+	}
+
+	subscriptionID := d.config.GallerySubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = azcli.SubscriptionID()
+	}
+	imageID := galleryimages.NewGalleryImageID(subscriptionID, d.config.ResourceGroupName, d.config.GalleryName, d.config.ImageName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	image, err := azcli.GalleryImagesClient().Get(ctx, imageID)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to get image definition %q: %w", imageID.ID(), err)
+	}
+	if image.Model == nil || image.Model.Properties == nil {
+		return cty.NullVal(cty.EmptyObject), azclient.NullModelSDKErr
+	}
+
+	versions, err := azcli.GalleryImageVersionsClient().ListByGalleryImageComplete(ctx, galleryimageversions.NewGalleryImageID(subscriptionID, d.config.ResourceGroupName, d.config.GalleryName, d.config.ImageName))
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the versions of image definition %q: %w", imageID.ID(), err)
+	}
+
+	selected, semver, err := d.selectVersion(versions.Items, time.Now())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("%s in image definition %q", err, imageID.ID())
+	}
+	log.Printf("[DEBUG] Selected version %s of image definition %q", semver.Original(), imageID.ID())
+
+	output := DatasourceOutput{
+		Version:           semver.Original(),
+		SubscriptionID:    subscriptionID,
+		ResourceGroupName: d.config.ResourceGroupName,
+		GalleryName:       d.config.GalleryName,
+		ImageName:         d.config.ImageName,
+		Regions:           targetRegions(selected),
+		OSType:            string(image.Model.Properties.OsType),
+		OSState:           string(image.Model.Properties.OsState),
+	}
+	if selected.Id != nil {
+		output.ID = *selected.Id
+	}
+	if profile := selected.Properties.PublishingProfile; profile != nil && profile.EndOfLifeDate != nil {
+		output.EndOfLifeDate = *profile.EndOfLifeDate
+	}
+	if image.Model.Properties.HyperVGeneration != nil {
+		output.HyperVGeneration = string(*image.Model.Properties.HyperVGeneration)
+	}
+	if image.Model.Properties.Architecture != nil {
+		output.Architecture = string(*image.Model.Properties.Architecture)
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
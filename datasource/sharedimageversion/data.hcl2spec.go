@@ -0,0 +1,144 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package sharedimageversion
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	PackerBuildName           *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType         *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion         *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug               *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce               *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError             *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars            map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars       []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	GallerySubscriptionID     *string           `mapstructure:"gallery_subscription_id" cty:"gallery_subscription_id" hcl:"gallery_subscription_id"`
+	ResourceGroupName         *string           `mapstructure:"resource_group_name" required:"true" cty:"resource_group_name" hcl:"resource_group_name"`
+	GalleryName               *string           `mapstructure:"gallery_name" required:"true" cty:"gallery_name" hcl:"gallery_name"`
+	ImageName                 *string           `mapstructure:"image_name" required:"true" cty:"image_name" hcl:"image_name"`
+	VersionConstraint         *string           `mapstructure:"version_constraint" cty:"version_constraint" hcl:"version_constraint"`
+	Location                  *string           `mapstructure:"location" cty:"location" hcl:"location"`
+	Tags                      map[string]string `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	IncludeExcludedFromLatest *bool             `mapstructure:"include_excluded_from_latest" cty:"include_excluded_from_latest" hcl:"include_excluded_from_latest"`
+	IncludeEndOfLife          *bool             `mapstructure:"include_end_of_life" cty:"include_end_of_life" hcl:"include_end_of_life"`
+	Timeout                   *string           `mapstructure:"timeout" cty:"timeout" hcl:"timeout"`
+	CloudEnvironmentName      *string           `mapstructure:"cloud_environment_name" required:"false" cty:"cloud_environment_name" hcl:"cloud_environment_name"`
+	MetadataHost              *string           `mapstructure:"metadata_host" required:"false" cty:"metadata_host" hcl:"metadata_host"`
+	ClientID                  *string           `mapstructure:"client_id" cty:"client_id" hcl:"client_id"`
+	ClientSecret              *string           `mapstructure:"client_secret" cty:"client_secret" hcl:"client_secret"`
+	ClientCertPath            *string           `mapstructure:"client_cert_path" cty:"client_cert_path" hcl:"client_cert_path"`
+	ClientCertPassword        *string           `mapstructure:"client_cert_password" cty:"client_cert_password" hcl:"client_cert_password"`
+	ClientJWT                 *string           `mapstructure:"client_jwt" cty:"client_jwt" hcl:"client_jwt"`
+	ObjectID                  *string           `mapstructure:"object_id" cty:"object_id" hcl:"object_id"`
+	TenantID                  *string           `mapstructure:"tenant_id" required:"false" cty:"tenant_id" hcl:"tenant_id"`
+	SubscriptionID            *string           `mapstructure:"subscription_id" cty:"subscription_id" hcl:"subscription_id"`
+	OidcRequestToken          *string           `mapstructure:"oidc_request_token" cty:"oidc_request_token" hcl:"oidc_request_token"`
+	OidcRequestURL            *string           `mapstructure:"oidc_request_url" cty:"oidc_request_url" hcl:"oidc_request_url"`
+	UseAzureCLIAuth           *bool             `mapstructure:"use_azure_cli_auth" required:"false" cty:"use_azure_cli_auth" hcl:"use_azure_cli_auth"`
+	HTTPProxy                 *string           `mapstructure:"http_proxy" required:"false" cty:"http_proxy" hcl:"http_proxy"`
+	NoProxy                   *string           `mapstructure:"no_proxy" required:"false" cty:"no_proxy" hcl:"no_proxy"`
+	CABundleFile              *string           `mapstructure:"ca_bundle_file" required:"false" cty:"ca_bundle_file" hcl:"ca_bundle_file"`
+	TraceFile                 *string           `mapstructure:"azure_trace_file" required:"false" cty:"azure_trace_file" hcl:"azure_trace_file"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"packer_build_name":            &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":          &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":          &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                 &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                 &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":              &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":        &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":   &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"gallery_subscription_id":      &hcldec.AttrSpec{Name: "gallery_subscription_id", Type: cty.String, Required: false},
+		"resource_group_name":          &hcldec.AttrSpec{Name: "resource_group_name", Type: cty.String, Required: false},
+		"gallery_name":                 &hcldec.AttrSpec{Name: "gallery_name", Type: cty.String, Required: false},
+		"image_name":                   &hcldec.AttrSpec{Name: "image_name", Type: cty.String, Required: false},
+		"version_constraint":           &hcldec.AttrSpec{Name: "version_constraint", Type: cty.String, Required: false},
+		"location":                     &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"tags":                         &hcldec.AttrSpec{Name: "tags", Type: cty.Map(cty.String), Required: false},
+		"include_excluded_from_latest": &hcldec.AttrSpec{Name: "include_excluded_from_latest", Type: cty.Bool, Required: false},
+		"include_end_of_life":          &hcldec.AttrSpec{Name: "include_end_of_life", Type: cty.Bool, Required: false},
+		"timeout":                      &hcldec.AttrSpec{Name: "timeout", Type: cty.String, Required: false},
+		"cloud_environment_name":       &hcldec.AttrSpec{Name: "cloud_environment_name", Type: cty.String, Required: false},
+		"metadata_host":                &hcldec.AttrSpec{Name: "metadata_host", Type: cty.String, Required: false},
+		"client_id":                    &hcldec.AttrSpec{Name: "client_id", Type: cty.String, Required: false},
+		"client_secret":                &hcldec.AttrSpec{Name: "client_secret", Type: cty.String, Required: false},
+		"client_cert_path":             &hcldec.AttrSpec{Name: "client_cert_path", Type: cty.String, Required: false},
+		"client_cert_password":         &hcldec.AttrSpec{Name: "client_cert_password", Type: cty.String, Required: false},
+		"client_jwt":                   &hcldec.AttrSpec{Name: "client_jwt", Type: cty.String, Required: false},
+		"object_id":                    &hcldec.AttrSpec{Name: "object_id", Type: cty.String, Required: false},
+		"tenant_id":                    &hcldec.AttrSpec{Name: "tenant_id", Type: cty.String, Required: false},
+		"subscription_id":              &hcldec.AttrSpec{Name: "subscription_id", Type: cty.String, Required: false},
+		"oidc_request_token":           &hcldec.AttrSpec{Name: "oidc_request_token", Type: cty.String, Required: false},
+		"oidc_request_url":             &hcldec.AttrSpec{Name: "oidc_request_url", Type: cty.String, Required: false},
+		"use_azure_cli_auth":           &hcldec.AttrSpec{Name: "use_azure_cli_auth", Type: cty.Bool, Required: false},
+		"http_proxy":                   &hcldec.AttrSpec{Name: "http_proxy", Type: cty.String, Required: false},
+		"no_proxy":                     &hcldec.AttrSpec{Name: "no_proxy", Type: cty.String, Required: false},
+		"ca_bundle_file":               &hcldec.AttrSpec{Name: "ca_bundle_file", Type: cty.String, Required: false},
+		"azure_trace_file":             &hcldec.AttrSpec{Name: "azure_trace_file", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	ID                *string  `mapstructure:"id" cty:"id" hcl:"id"`
+	Version           *string  `mapstructure:"version" cty:"version" hcl:"version"`
+	SubscriptionID    *string  `mapstructure:"subscription_id" cty:"subscription_id" hcl:"subscription_id"`
+	ResourceGroupName *string  `mapstructure:"resource_group_name" cty:"resource_group_name" hcl:"resource_group_name"`
+	GalleryName       *string  `mapstructure:"gallery_name" cty:"gallery_name" hcl:"gallery_name"`
+	ImageName         *string  `mapstructure:"image_name" cty:"image_name" hcl:"image_name"`
+	Regions           []string `mapstructure:"regions" cty:"regions" hcl:"regions"`
+	EndOfLifeDate     *string  `mapstructure:"end_of_life_date" cty:"end_of_life_date" hcl:"end_of_life_date"`
+	OSType            *string  `mapstructure:"os_type" cty:"os_type" hcl:"os_type"`
+	OSState           *string  `mapstructure:"os_state" cty:"os_state" hcl:"os_state"`
+	HyperVGeneration  *string  `mapstructure:"hyper_v_generation" cty:"hyper_v_generation" hcl:"hyper_v_generation"`
+	Architecture      *string  `mapstructure:"architecture" cty:"architecture" hcl:"architecture"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"id":                  &hcldec.AttrSpec{Name: "id", Type: cty.String, Required: false},
+		"version":             &hcldec.AttrSpec{Name: "version", Type: cty.String, Required: false},
+		"subscription_id":     &hcldec.AttrSpec{Name: "subscription_id", Type: cty.String, Required: false},
+		"resource_group_name": &hcldec.AttrSpec{Name: "resource_group_name", Type: cty.String, Required: false},
+		"gallery_name":        &hcldec.AttrSpec{Name: "gallery_name", Type: cty.String, Required: false},
+		"image_name":          &hcldec.AttrSpec{Name: "image_name", Type: cty.String, Required: false},
+		"regions":             &hcldec.AttrSpec{Name: "regions", Type: cty.List(cty.String), Required: false},
+		"end_of_life_date":    &hcldec.AttrSpec{Name: "end_of_life_date", Type: cty.String, Required: false},
+		"os_type":             &hcldec.AttrSpec{Name: "os_type", Type: cty.String, Required: false},
+		"os_state":            &hcldec.AttrSpec{Name: "os_state", Type: cty.String, Required: false},
+		"hyper_v_generation":  &hcldec.AttrSpec{Name: "hyper_v_generation", Type: cty.String, Required: false},
+		"architecture":        &hcldec.AttrSpec{Name: "architecture", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package sharedimageversion
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	goversion "github.com/hashicorp/go-version"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+)
+
+// selectVersion returns the highest of versions that passes the filters of
+// the configuration at time now, along with its parsed version number.
+func (d *Datasource) selectVersion(versions []galleryimageversions.GalleryImageVersion, now time.Time) (*galleryimageversions.GalleryImageVersion, *goversion.Version, error) {
+	var selected *galleryimageversions.GalleryImageVersion
+	var selectedVersion *goversion.Version
+	for i, v := range versions {
+		if v.Name == nil || v.Properties == nil {
+			continue
+		}
+		semver, err := goversion.NewVersion(*v.Name)
+		if err != nil {
+			log.Printf("[DEBUG] Skipping image version %q: %v", *v.Name, err)
+			continue
+		}
+		if selectedVersion != nil && !semver.GreaterThan(selectedVersion) {
+			continue
+		}
+		if reason := d.rejectReason(v, semver, now); reason != "" {
+			log.Printf("[DEBUG] Skipping image version %q: %s", *v.Name, reason)
+			continue
+		}
+		selected, selectedVersion = &versions[i], semver
+	}
+	if selected == nil {
+		return nil, nil, errors.New("no image version matches the filters")
+	}
+	return selected, selectedVersion, nil
+}
+
+// rejectReason returns why v does not pass the filters, or an empty string
+// if it does.
+func (d *Datasource) rejectReason(v galleryimageversions.GalleryImageVersion, semver *goversion.Version, now time.Time) string {
+	if d.config.versionConstraint != nil && !d.config.versionConstraint.Check(semver) {
+		return "it does not satisfy the version constraint"
+	}
+	if state := v.Properties.ProvisioningState; state == nil || *state != galleryimageversions.GalleryProvisioningStateSucceeded {
+		return "it is not successfully provisioned"
+	}
+	for k, value := range d.config.Tags {
+		if v.Tags == nil {
+			return "it does not have the requested tags"
+		}
+		if tag, ok := (*v.Tags)[k]; !ok || tag != value {
+			return "it does not have the requested tags"
+		}
+	}
+
+	profile := v.Properties.PublishingProfile
+	if profile == nil {
+		profile = &galleryimageversions.GalleryArtifactPublishingProfileBase{}
+	}
+	if !d.config.IncludeExcludedFromLatest && profile.ExcludeFromLatest != nil && *profile.ExcludeFromLatest {
+		return "it is excluded from latest"
+	}
+	if !d.config.IncludeEndOfLife {
+		if eol, err := profile.GetEndOfLifeDateAsTime(); err == nil && eol != nil && !now.Before(*eol) {
+			return "it has reached its end of life"
+		}
+	}
+	if d.config.Location != "" {
+		region := findTargetRegion(profile, d.config.Location)
+		if region == nil {
+			return "it is not replicated to " + d.config.Location
+		}
+		if !d.config.IncludeExcludedFromLatest && region.ExcludeFromLatest != nil && *region.ExcludeFromLatest {
+			return "it is excluded from latest in " + d.config.Location
+		}
+	}
+	return ""
+}
+
+func findTargetRegion(profile *galleryimageversions.GalleryArtifactPublishingProfileBase, location string) *galleryimageversions.TargetRegion {
+	if profile.TargetRegions == nil {
+		return nil
+	}
+	for i, r := range *profile.TargetRegions {
+		if azclient.NormalizeLocation(r.Name) == azclient.NormalizeLocation(location) {
+			return &(*profile.TargetRegions)[i]
+		}
+	}
+	return nil
+}
+
+// targetRegions returns the normalized names of the regions v is replicated
+// to.
+func targetRegions(v *galleryimageversions.GalleryImageVersion) []string {
+	regions := []string{}
+	if v.Properties.PublishingProfile == nil || v.Properties.PublishingProfile.TargetRegions == nil {
+		return regions
+	}
+	for _, r := range *v.Properties.PublishingProfile.TargetRegions {
+		regions = append(regions, azclient.NormalizeLocation(r.Name))
+	}
+	return regions
+}
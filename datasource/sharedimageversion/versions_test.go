@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package sharedimageversion
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/resource-manager/compute/2023-07-03/galleryimageversions"
+	goversion "github.com/hashicorp/go-version"
+)
+
+type testVersion struct {
+	name              string
+	state             galleryimageversions.GalleryProvisioningState
+	regions           []string
+	excludeFromLatest bool
+	excludedIn        string
+	endOfLife         string
+	tags              map[string]string
+}
+
+func (tv testVersion) build() galleryimageversions.GalleryImageVersion {
+	name, state := tv.name, tv.state
+	if state == "" {
+		state = galleryimageversions.GalleryProvisioningStateSucceeded
+	}
+	profile := &galleryimageversions.GalleryArtifactPublishingProfileBase{ExcludeFromLatest: &tv.excludeFromLatest}
+	if tv.endOfLife != "" {
+		profile.EndOfLifeDate = &tv.endOfLife
+	}
+	regions := []galleryimageversions.TargetRegion{}
+	for _, r := range tv.regions {
+		excluded := r == tv.excludedIn
+		regions = append(regions, galleryimageversions.TargetRegion{Name: r, ExcludeFromLatest: &excluded})
+	}
+	profile.TargetRegions = &regions
+	v := galleryimageversions.GalleryImageVersion{
+		Name: &name,
+		Properties: &galleryimageversions.GalleryImageVersionProperties{
+			ProvisioningState: &state,
+			PublishingProfile: profile,
+		},
+	}
+	if tv.tags != nil {
+		v.Tags = &tv.tags
+	}
+	return v
+}
+
+func TestDatasourceSelectVersion(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	versions := []testVersion{
+		{name: "1.3.9", regions: []string{"westeurope", "eastus"}, tags: map[string]string{"channel": "stable"}},
+		{name: "1.4.0", regions: []string{"West Europe"}, tags: map[string]string{"channel": "stable"}},
+		{name: "1.4.1", regions: []string{"westeurope", "eastus"}, excludedIn: "eastus", tags: map[string]string{"channel": "beta"}},
+		{name: "1.5.0", regions: []string{"westeurope"}, state: galleryimageversions.GalleryProvisioningStateFailed},
+		{name: "1.6.0", regions: []string{"westeurope"}, endOfLife: "2026-05-01T00:00:00Z"},
+		{name: "2.0.0", regions: []string{"westeurope", "eastus"}, excludeFromLatest: true},
+		{name: "latest", regions: []string{"westeurope"}},
+	}
+	var items []galleryimageversions.GalleryImageVersion
+	for _, v := range versions {
+		items = append(items, v.build())
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		regions []string
+	}{
+		{
+			name:    "latest usable version",
+			config:  Config{},
+			want:    "1.4.1",
+			regions: []string{"westeurope", "eastus"},
+		},
+		{
+			name:   "include excluded from latest and end of life",
+			config: Config{IncludeExcludedFromLatest: true, IncludeEndOfLife: true},
+			want:   "2.0.0",
+		},
+		{
+			name:   "version constraint",
+			config: Config{VersionConstraint: ">= 1.3.0, < 1.4.1"},
+			want:   "1.4.0",
+		},
+		{
+			name:   "replicated to location",
+			config: Config{Location: "East US"},
+			want:   "1.3.9",
+		},
+		{
+			name:   "tags",
+			config: Config{Tags: map[string]string{"channel": "stable"}},
+			want:   "1.4.0",
+		},
+		{
+			name:   "no match",
+			config: Config{VersionConstraint: "~> 3.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{config: tt.config}
+			if tt.config.VersionConstraint != "" {
+				d.config.versionConstraint = goversion.MustConstraints(goversion.NewConstraint(tt.config.VersionConstraint))
+			}
+			selected, semver, err := d.selectVersion(items, now)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("expected no version to match, got %s", semver)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if semver.Original() != tt.want || *selected.Name != tt.want {
+				t.Fatalf("expected version %s, got %s", tt.want, semver)
+			}
+			if tt.regions != nil && !reflect.DeepEqual(targetRegions(selected), tt.regions) {
+				t.Errorf("expected regions %v, got %v", tt.regions, targetRegions(selected))
+			}
+		})
+	}
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	d := &Datasource{config: Config{ResourceGroupName: "rg", GalleryName: "gallery", ImageName: "image", VersionConstraint: ">= 1.4.0, < 2.0.0"}}
+	if err := d.Configure(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.config.versionConstraint == nil {
+		t.Error("expected the version constraint to be parsed")
+	}
+
+	d = &Datasource{config: Config{ResourceGroupName: "rg", GalleryName: "gallery", VersionConstraint: "newest"}}
+	if err := d.Configure(); err == nil {
+		t.Fatal("expected errors for a missing image_name and an invalid version_constraint")
+	}
+}
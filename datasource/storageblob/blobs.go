@@ -0,0 +1,202 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package storageblob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-azure-sdk/sdk/auth"
+	sdkClient "github.com/hashicorp/go-azure-sdk/sdk/client"
+	"github.com/hashicorp/go-azure-sdk/sdk/client/dataplane/storage"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/version"
+	"github.com/hashicorp/packer-plugin-sdk/useragent"
+	"github.com/tombuildsstuff/giovanni/storage/2020-08-04/blob/containers"
+)
+
+// storageAPIVersion is the version of the Blob service REST API, the same as
+// the one of the containers client.
+const storageAPIVersion = "2020-08-04"
+
+// blob is the content and the properties of a blob.
+type blob struct {
+	Contents     []byte
+	ContentType  string
+	ETag         string
+	LastModified string
+}
+
+// blobItem is a blob as returned when listing a container.
+type blobItem struct {
+	Name         string
+	ETag         string
+	LastModified string
+}
+
+// newBlobClient returns a client of the blob service at endpoint. It
+// authenticates with the SAS token or the account key of the configuration
+// when set, and with the Azure credentials otherwise.
+func (d *Datasource) newBlobClient(ctx context.Context, endpoint string) (*storage.Client, error) {
+	client, err := storage.NewStorageClient(endpoint, "blob", storageAPIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob client: %w", err)
+	}
+
+	// the proxy, CA bundle and trace settings apply whatever the
+	// authentication
+	if err := d.config.PrepareTransport(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case d.config.SASToken != "":
+		sas, err := url.ParseQuery(strings.TrimPrefix(d.config.SASToken, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("the SAS token is invalid: %w", err)
+		}
+		client.Client.AuthorizeRequest = sasAuthorizeRequest(sas)
+	case d.config.StorageAccountKey != "":
+		authorizer, err := auth.NewSharedKeyAuthorizer(d.config.StorageAccountName, d.config.StorageAccountKey, auth.SharedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create shared key authorizer: %w", err)
+		}
+		client.Client.SetAuthorizer(authorizer)
+	default:
+		err := d.config.FillParameters()
+		if err != nil {
+			return nil, err
+		}
+		authOptions := azclient.AzureAuthOptions{
+			AuthType:           d.config.AuthType(),
+			ClientID:           d.config.ClientID,
+			ClientSecret:       d.config.ClientSecret,
+			ClientJWT:          d.config.ClientJWT,
+			ClientCertPath:     d.config.ClientCertPath,
+			ClientCertPassword: d.config.ClientCertPassword,
+			TenantID:           d.config.TenantID,
+			SubscriptionID:     d.config.SubscriptionID,
+			OidcRequestUrl:     d.config.OidcRequestURL,
+			OidcRequestToken:   d.config.OidcRequestToken,
+			Transport:          d.config.Transport(),
+		}
+		authorizer, err := azclient.BuildStorageAuthorizer(ctx, authOptions, *d.config.CloudEnvironment())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Storage authorizer: %w", err)
+		}
+		client.Client.SetAuthorizer(authorizer)
+	}
+
+	azclient.ConfigureTransport(client.Client, d.config.Transport())
+	client.Client.SetUserAgent(fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), client.Client.GetUserAgent()))
+	return client, nil
+}
+
+// sasAuthorizeRequest returns a function that authorizes the requests by
+// adding the parameters of a shared access signature to their query.
+func sasAuthorizeRequest(sas url.Values) func(context.Context, *http.Request, auth.Authorizer) error {
+	return func(_ context.Context, req *http.Request, _ auth.Authorizer) error {
+		query := req.URL.Query()
+		for k, v := range sas {
+			query[k] = v
+		}
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+}
+
+// getBlob reads the blob blobName of the container containerName. The
+// contents are read as is, whatever the content type of the blob.
+func getBlob(ctx context.Context, client *storage.Client, containerName, blobName string) (*blob, error) {
+	opts := sdkClient.RequestOptions{
+		ContentType: "application/octet-stream",
+		ExpectedStatusCodes: []int{
+			http.StatusOK,
+		},
+		HttpMethod: http.MethodGet,
+		Path:       blobPath(containerName, blobName),
+	}
+	req, err := client.NewRequest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := req.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+	return &blob{
+		Contents:     contents,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: rfc3339(resp.Header.Get("Last-Modified")),
+	}, nil
+}
+
+// blobPath returns the escaped path of the blob blobName of the container
+// containerName. The slashes of the name are kept, they separate the virtual
+// directories of the blob.
+func blobPath(containerName, blobName string) string {
+	segments := strings.Split(blobName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/" + url.PathEscape(containerName) + "/" + strings.Join(segments, "/")
+}
+
+// listBlobs lists the blobs of the container containerName whose name starts
+// with prefix, following the continuation markers.
+func listBlobs(ctx context.Context, client *storage.Client, containerName, prefix string) ([]blobItem, error) {
+	containersClient := containers.Client{Client: client}
+	var items []blobItem
+	input := containers.ListBlobsInput{}
+	if prefix != "" {
+		input.Prefix = &prefix
+	}
+	for {
+		resp, err := containersClient.ListBlobs(ctx, containerName, input)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Model == nil {
+			return items, nil
+		}
+		for _, b := range resp.Model.Blobs.Blobs {
+			item := blobItem{Name: b.Name}
+			if b.Properties != nil {
+				if b.Properties.ETag != nil {
+					item.ETag = *b.Properties.ETag
+				}
+				if b.Properties.LastModified != nil {
+					item.LastModified = rfc3339(*b.Properties.LastModified)
+				}
+			}
+			items = append(items, item)
+		}
+		if resp.Model.NextMarker == nil || *resp.Model.NextMarker == "" {
+			return items, nil
+		}
+		input.Marker = resp.Model.NextMarker
+	}
+}
+
+// rfc3339 converts an HTTP date, as used by the Blob service, to RFC 3339.
+// Dates that cannot be parsed are returned as is.
+func rfc3339(date string) string {
+	t, err := http.ParseTime(date)
+	if err != nil {
+		return date
+	}
+	return t.UTC().Format(time.RFC3339)
+}
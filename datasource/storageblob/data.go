@@ -0,0 +1,230 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package storageblob
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The name of the storage account. Required unless `blob_endpoint` is
+	// set.
+	StorageAccountName string `mapstructure:"storage_account_name"`
+	// The URI of the blob service, for a private endpoint or a custom domain.
+	// Defaults to the blob endpoint of the storage account in the cloud
+	// environment, for example `https://myaccount.blob.core.windows.net`.
+	BlobEndpoint string `mapstructure:"blob_endpoint"`
+	// The name of the container.
+	ContainerName string `mapstructure:"container_name" required:"true"`
+	// The name of the blob to read. When it is not set, the names of the
+	// blobs of the container that start with `prefix` are listed instead.
+	BlobName string `mapstructure:"blob_name"`
+	// List the blobs whose name starts with this prefix, for example
+	// `manifests/`. Cannot be used with `blob_name`.
+	Prefix string `mapstructure:"prefix"`
+	// Decode the blob as JSON into the `decoded` output. Only valid with
+	// `blob_name`.
+	DecodeJSON bool `mapstructure:"decode_json"`
+	// A shared access signature to use instead of the Azure credentials, with
+	// or without its leading `?`.
+	SASToken string `mapstructure:"sas_token"`
+	// An access key of the storage account to use instead of the Azure
+	// credentials.
+	StorageAccountKey string `mapstructure:"storage_account_key"`
+	// The time to wait for the request to complete, including authentication.
+	// Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The content of the blob, when it is valid UTF-8 text.
+	Content string `mapstructure:"content"`
+	// The content of the blob, base64 encoded.
+	ContentBase64 string `mapstructure:"content_base64"`
+	// The content type of the blob.
+	ContentType string `mapstructure:"content_type"`
+	// The ETag of the blob.
+	ETag string `mapstructure:"etag"`
+	// The time the blob was last modified, in RFC 3339 format.
+	LastModified string `mapstructure:"last_modified"`
+	// The names of the blobs, when listing the container.
+	BlobNames []string `mapstructure:"blob_names"`
+	// The ETags of the listed blobs, by name.
+	BlobETags map[string]string `mapstructure:"blob_etags"`
+	// The times the listed blobs were last modified, by name, in RFC 3339
+	// format.
+	BlobLastModified map[string]string `mapstructure:"blob_last_modified"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+// OutputSpec adds the `decoded` output to the generated spec. Its type
+// depends on the content of the blob, which the generated code cannot
+// express.
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	spec := (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+	spec["decoded"] = &hcldec.AttrSpec{Name: "decoded", Type: cty.DynamicPseudoType, Required: false}
+	return spec
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.StorageAccountName == "" && d.config.BlobEndpoint == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'storage_account_name' or 'blob_endpoint' must be specified"))
+	}
+	if d.config.BlobEndpoint != "" {
+		u, err := url.Parse(d.config.BlobEndpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'blob_endpoint' must be an absolute http or https URI, got %q", d.config.BlobEndpoint))
+		}
+	}
+	if d.config.ContainerName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("a 'container_name' must be specified"))
+	}
+	if d.config.BlobName != "" && d.config.Prefix != "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("only one of 'blob_name' or 'prefix' can be specified"))
+	}
+	if d.config.DecodeJSON && d.config.BlobName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'decode_json' requires a 'blob_name'"))
+	}
+	if d.config.SASToken != "" && d.config.StorageAccountKey != "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("only one of 'sas_token' or 'storage_account_key' can be specified"))
+	}
+	if d.config.StorageAccountKey != "" && d.config.StorageAccountName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'storage_account_key' requires a 'storage_account_name'"))
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	if d.config.SASToken == "" && d.config.StorageAccountKey == "" {
+		d.config.Validate(errs)
+	}
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	endpoint := strings.TrimSuffix(d.config.BlobEndpoint, "/")
+	if endpoint == "" {
+		var err error
+		endpoint, err = azclient.BlobStorageURI(*d.config.CloudEnvironment(), d.config.StorageAccountName)
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), err
+		}
+	}
+
+	defer d.config.Close()
+	client, err := d.newBlobClient(ctx, endpoint)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output := DatasourceOutput{
+		BlobNames:        []string{},
+		BlobETags:        map[string]string{},
+		BlobLastModified: map[string]string{},
+	}
+	decoded := cty.NullVal(cty.DynamicPseudoType)
+
+	if d.config.BlobName != "" {
+		blob, err := getBlob(ctx, client, d.config.ContainerName, d.config.BlobName)
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to read blob %q of container %q: %w", d.config.BlobName, d.config.ContainerName, err)
+		}
+		if utf8.Valid(blob.Contents) {
+			output.Content = string(blob.Contents)
+		} else {
+			log.Printf("[DEBUG] Blob %q is not valid UTF-8 text, only content_base64 is set", d.config.BlobName)
+		}
+		output.ContentBase64 = base64.StdEncoding.EncodeToString(blob.Contents)
+		output.ContentType = blob.ContentType
+		output.ETag = blob.ETag
+		output.LastModified = blob.LastModified
+
+		if d.config.DecodeJSON {
+			decoded, err = decodeJSON(blob.Contents)
+			if err != nil {
+				return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to decode blob %q as JSON: %w", d.config.BlobName, err)
+			}
+		}
+	} else {
+		items, err := listBlobs(ctx, client, d.config.ContainerName, d.config.Prefix)
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the blobs of container %q: %w", d.config.ContainerName, err)
+		}
+		for _, item := range items {
+			output.BlobNames = append(output.BlobNames, item.Name)
+			output.BlobETags[item.Name] = item.ETag
+			output.BlobLastModified[item.Name] = item.LastModified
+		}
+	}
+
+	value := hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec())
+	attrs := value.AsValueMap()
+	attrs["decoded"] = decoded
+	return cty.ObjectVal(attrs), nil
+}
+
+// decodeJSON converts the JSON document data to a value of the type implied
+// by the document.
+func decodeJSON(data []byte) (cty.Value, error) {
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(data, ty)
+}
@@ -0,0 +1,229 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package storageblob
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const testManifest = `{"version": "1.4.2", "regions": ["westeurope", "eastus"]}`
+
+// newTestServer returns a stand-in of the Blob service with a container
+// named inputs, that requires the SAS token sig=secret.
+func newTestServer() *httptest.Server {
+	blobs := []string{"manifests/app.json", "manifests/base.json", "allowlist.txt"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("sig") != "secret" {
+			http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.URL.Path == "/inputs/manifests/app.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"0x8DC0000000000A1"`)
+			w.Header().Set("Last-Modified", "Mon, 05 Oct 2026 10:00:00 GMT")
+			_, _ = w.Write([]byte(testManifest))
+		case r.URL.Path == "/inputs/reports/50% done?#1.txt":
+			_, _ = w.Write([]byte("done"))
+		case r.URL.Path == "/inputs/binary.bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+		case r.URL.Path == "/inputs" && query.Get("comp") == "list":
+			// Return one blob per page to exercise the continuation markers.
+			var matching []string
+			for _, name := range blobs {
+				if strings.HasPrefix(name, query.Get("prefix")) {
+					matching = append(matching, name)
+				}
+			}
+			index := 0
+			if m := query.Get("marker"); m != "" {
+				_, _ = fmt.Sscanf(m, "%d", &index)
+			}
+			next := ""
+			if index+1 < len(matching) {
+				next = fmt.Sprintf("%d", index+1)
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs><Blob><Name>%s</Name><Properties><Last-Modified>Mon, 05 Oct 2026 10:00:00 GMT</Last-Modified><Etag>0x%d</Etag></Properties></Blob></Blobs><NextMarker>%s</NextMarker></EnumerationResults>`, matching[index], index, next)
+		default:
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+		}
+	}))
+}
+
+func configure(t *testing.T, server *httptest.Server, raws map[string]interface{}) *Datasource {
+	t.Helper()
+	config := map[string]interface{}{
+		"blob_endpoint":  server.URL,
+		"container_name": "inputs",
+		"sas_token":      "?sv=2020-08-04&sig=secret",
+	}
+	for k, v := range raws {
+		config[k] = v
+	}
+	d := &Datasource{}
+	if err := d.Configure(config); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	return d
+}
+
+func TestDatasourceExecuteGet(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	d := configure(t, server, map[string]interface{}{
+		"blob_name":   "manifests/app.json",
+		"decode_json": true,
+	})
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+
+	if got := value.GetAttr("content").AsString(); got != testManifest {
+		t.Errorf("content = %q, want %q", got, testManifest)
+	}
+	if got := value.GetAttr("etag").AsString(); got != `"0x8DC0000000000A1"` {
+		t.Errorf("etag = %q", got)
+	}
+	if got := value.GetAttr("last_modified").AsString(); got != "2026-10-05T10:00:00Z" {
+		t.Errorf("last_modified = %q", got)
+	}
+	decoded := value.GetAttr("decoded")
+	if got := decoded.GetAttr("version"); !got.RawEquals(cty.StringVal("1.4.2")) {
+		t.Errorf("decoded.version = %#v", got)
+	}
+	if got := decoded.GetAttr("regions").LengthInt(); got != 2 {
+		t.Errorf("len(decoded.regions) = %d, want 2", got)
+	}
+}
+
+func TestDatasourceExecuteGetBinary(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	d := configure(t, server, map[string]interface{}{"blob_name": "binary.bin"})
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+	if got := value.GetAttr("content").AsString(); got != "" {
+		t.Errorf("content = %q, want it empty for binary content", got)
+	}
+	if got := value.GetAttr("content_base64").AsString(); got != "//4A" {
+		t.Errorf("content_base64 = %q, want %q", got, "//4A")
+	}
+	if !value.GetAttr("decoded").IsNull() {
+		t.Error("decoded should be null without decode_json")
+	}
+}
+
+func TestDatasourceExecuteGetEscapedName(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	d := configure(t, server, map[string]interface{}{"blob_name": "reports/50% done?#1.txt"})
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+	if got := value.GetAttr("content").AsString(); got != "done" {
+		t.Errorf("content = %q, want %q", got, "done")
+	}
+}
+
+func TestDatasourceExecuteTraceWithSAS(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+	d := configure(t, server, map[string]interface{}{
+		"blob_name":        "manifests/app.json",
+		"azure_trace_file": traceFile,
+	})
+	if _, err := d.Execute(); err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+	trace, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(trace), "/inputs/manifests/app.json") {
+		t.Errorf("trace = %q, want the request of the blob", trace)
+	}
+	if strings.Contains(string(trace), "secret") {
+		t.Errorf("trace = %q, want the SAS signature redacted", trace)
+	}
+}
+
+func TestDatasourceExecuteList(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	d := configure(t, server, map[string]interface{}{"prefix": "manifests/"})
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %s", err)
+	}
+	want := cty.ListVal([]cty.Value{cty.StringVal("manifests/app.json"), cty.StringVal("manifests/base.json")})
+	if got := value.GetAttr("blob_names"); !got.RawEquals(want) {
+		t.Errorf("blob_names = %#v, want %#v", got, want)
+	}
+	if got := value.GetAttr("blob_etags").Index(cty.StringVal("manifests/base.json")).AsString(); got != "0x1" {
+		t.Errorf("etag of manifests/base.json = %q, want %q", got, "0x1")
+	}
+}
+
+func TestDatasourceExecuteErrors(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	tests := map[string]map[string]interface{}{
+		"missing blob": {"blob_name": "missing.json"},
+		"wrong SAS":    {"blob_name": "manifests/app.json", "sas_token": "sig=wrong"},
+		"invalid JSON": {"blob_name": "binary.bin", "decode_json": true},
+	}
+	for name, raws := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := configure(t, server, raws)
+			if _, err := d.Execute(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{name: "no account", config: map[string]interface{}{"container_name": "inputs", "sas_token": "sig=x"}},
+		{name: "no container", config: map[string]interface{}{"storage_account_name": "acct", "sas_token": "sig=x"}},
+		{name: "blob and prefix", config: map[string]interface{}{"storage_account_name": "acct", "container_name": "inputs", "blob_name": "a", "prefix": "b", "sas_token": "sig=x"}},
+		{name: "decode without blob", config: map[string]interface{}{"storage_account_name": "acct", "container_name": "inputs", "decode_json": true, "sas_token": "sig=x"}},
+		{name: "SAS and key", config: map[string]interface{}{"storage_account_name": "acct", "container_name": "inputs", "sas_token": "sig=x", "storage_account_key": "a2V5"}},
+		{name: "key without account", config: map[string]interface{}{"blob_endpoint": "https://blobs.example.com", "container_name": "inputs", "storage_account_key": "a2V5"}},
+		{name: "invalid endpoint", config: map[string]interface{}{"blob_endpoint": "blobs.example.com", "container_name": "inputs", "sas_token": "sig=x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Datasource{}
+			if err := d.Configure(tt.config); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package virtualnetwork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/network/2023-09-01/virtualnetworks"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+	"github.com/hashicorp/packer-plugin-azure/version"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/useragent"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// Only match the virtual network with this name.
+	VirtualNetworkName string `mapstructure:"virtual_network_name"`
+	// Only search the virtual networks of this resource group. By default the
+	// whole subscription is searched.
+	VirtualNetworkResourceGroupName string `mapstructure:"virtual_network_resource_group_name"`
+	// Only match the subnet with this name.
+	SubnetName string `mapstructure:"subnet_name"`
+	// Only match the virtual networks that have all of these tags, with the
+	// same values.
+	Tags map[string]string `mapstructure:"tags"`
+	// Only match the virtual networks in this region.
+	Location string `mapstructure:"location"`
+	// Only match the subnets whose address prefixes are all contained in this
+	// CIDR block, for example `10.20.0.0/16`.
+	WithinCIDR string `mapstructure:"within_cidr"`
+	withinCIDR netip.Prefix
+	// Only match the subnets with at least this many available IP addresses.
+	MinAvailableIPs int `mapstructure:"min_available_ips"`
+	// The time to wait for the lookup to complete, including authentication.
+	// Defaults to `5m`.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	azclient.Config `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The name of the virtual network. Use it as `virtual_network_name`.
+	VirtualNetworkName string `mapstructure:"virtual_network_name"`
+	// The resource group of the virtual network. Use it as
+	// `virtual_network_resource_group_name`.
+	VirtualNetworkResourceGroupName string `mapstructure:"virtual_network_resource_group_name"`
+	// The name of the subnet. Use it as `virtual_network_subnet_name`.
+	VirtualNetworkSubnetName string `mapstructure:"virtual_network_subnet_name"`
+	// The resource ID of the virtual network.
+	VirtualNetworkID string `mapstructure:"virtual_network_id"`
+	// The resource ID of the subnet.
+	SubnetID string `mapstructure:"subnet_id"`
+	// The region of the virtual network.
+	Location string `mapstructure:"location"`
+	// The address prefixes of the subnet.
+	AddressPrefixes []string `mapstructure:"address_prefixes"`
+	// The number of IP addresses of the subnet that are not in use.
+	AvailableIPCount int `mapstructure:"available_ip_count"`
+	// The resource ID of the network security group of the subnet, if any.
+	NetworkSecurityGroupID string `mapstructure:"network_security_group_id"`
+	// Whether the subnet has a network security group.
+	HasNetworkSecurityGroup bool `mapstructure:"has_network_security_group"`
+	// Whether the subnet has a NAT gateway for outbound access.
+	HasNATGateway bool `mapstructure:"has_nat_gateway"`
+	// The services with a service endpoint in the subnet, for example
+	// `Microsoft.Storage`.
+	ServiceEndpoints []string `mapstructure:"service_endpoints"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if d.config.WithinCIDR != "" {
+		d.config.withinCIDR, err = netip.ParsePrefix(d.config.WithinCIDR)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'within_cidr' is invalid: %w", err))
+		}
+	}
+	if d.config.MinAvailableIPs < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'min_available_ips' must not be negative"))
+	}
+	if d.config.Timeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'timeout' must not be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = defaultTimeout
+	}
+
+	d.config.Validate(errs)
+
+	err = d.config.SetDefaultValues()
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("failed to set default values: %w", err))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	err := d.config.FillParameters()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer d.config.Close()
+
+	azcli, err := azclient.New(d.config.Config, func(s string) { log.Print(s) })
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create Azure client: %w", err)
+	}
+
+	client, err := virtualnetworks.NewVirtualNetworksClientWithBaseURI(d.config.CloudEnvironment().ResourceManager)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to create virtual networks client: %w", err)
+	}
+	client.Client.Authorizer = azcli.TokenAuthorizer()
+	azclient.ConfigureTransport(client.Client, d.config.Transport())
+	client.Client.UserAgent = fmt.Sprintf("%s %s", useragent.String(version.AzurePluginVersion.FormattedVersion()), client.Client.UserAgent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	var vnets []virtualnetworks.VirtualNetwork
+	scope := "subscription " + azcli.SubscriptionID()
+	if d.config.VirtualNetworkResourceGroupName != "" {
+		scope = "resource group " + d.config.VirtualNetworkResourceGroupName
+		result, err := client.ListComplete(ctx, commonids.NewResourceGroupID(azcli.SubscriptionID(), d.config.VirtualNetworkResourceGroupName))
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the virtual networks of %s: %w", scope, err)
+		}
+		vnets = result.Items
+	} else {
+		result, err := client.ListAllComplete(ctx, commonids.NewSubscriptionID(azcli.SubscriptionID()))
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), fmt.Errorf("failed to list the virtual networks of %s: %w", scope, err)
+		}
+		vnets = result.Items
+	}
+
+	usage := func(ctx context.Context, id commonids.VirtualNetworkId) ([]virtualnetworks.VirtualNetworkUsage, error) {
+		result, err := client.VirtualNetworksListUsageComplete(ctx, id)
+		return result.Items, err
+	}
+	selected, err := d.selectSubnet(ctx, vnets, usage)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("%s in %s", err, scope)
+	}
+	output := selected.output()
+	log.Printf("[DEBUG] Selected subnet %q", output.SubnetID)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
@@ -0,0 +1,217 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualnetwork
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/network/2023-09-01/virtualnetworks"
+	azclient "github.com/hashicorp/packer-plugin-azure/builder/azure/common/client"
+	"github.com/hashicorp/packer-plugin-azure/builder/azure/common/log"
+)
+
+// Azure reserves the first four and the last IP address of every subnet.
+const reservedIPsPerSubnet = 5
+
+// reservedSubnetNames are the names of the subnets dedicated to Azure
+// services, in which virtual machines cannot be deployed.
+var reservedSubnetNames = map[string]bool{
+	"gatewaysubnet":                 true,
+	"azurebastionsubnet":            true,
+	"azurefirewallsubnet":           true,
+	"azurefirewallmanagementsubnet": true,
+	"routeserversubnet":             true,
+}
+
+type usageFunc func(context.Context, commonids.VirtualNetworkId) ([]virtualnetworks.VirtualNetworkUsage, error)
+
+// candidate is a subnet along with its virtual network.
+type candidate struct {
+	vnet         virtualnetworks.VirtualNetwork
+	vnetID       *commonids.VirtualNetworkId
+	subnet       virtualnetworks.Subnet
+	availableIPs int
+}
+
+// selectSubnet returns the subnet of vnets that passes the filters of the
+// configuration and has the most available IP addresses. The IP usage of the
+// virtual networks with matching subnets is retrieved with usage.
+func (d *Datasource) selectSubnet(ctx context.Context, vnets []virtualnetworks.VirtualNetwork, usage usageFunc) (*candidate, error) {
+	var candidates []candidate
+	for _, vnet := range vnets {
+		if vnet.Id == nil || vnet.Properties == nil || vnet.Properties.Subnets == nil || !d.matchesVirtualNetwork(vnet) {
+			continue
+		}
+		vnetID, err := commonids.ParseVirtualNetworkIDInsensitively(*vnet.Id)
+		if err != nil {
+			log.Printf("[DEBUG] Skipping virtual network %q: %v", *vnet.Id, err)
+			continue
+		}
+
+		var matches []candidate
+		for _, subnet := range *vnet.Properties.Subnets {
+			if d.matchesSubnet(subnet) {
+				matches = append(matches, candidate{vnet: vnet, vnetID: vnetID, subnet: subnet, availableIPs: estimateAvailableIPs(subnet)})
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		usages, err := usage(ctx, *vnetID)
+		if err != nil {
+			log.Printf("[DEBUG] Unable to get the IP usage of %q, estimating it: %v", *vnet.Id, err)
+		}
+		for i := range matches {
+			if available, ok := availableIPsFromUsage(usages, *matches[i].subnet.Id); ok {
+				matches[i].availableIPs = available
+			}
+			if matches[i].availableIPs >= d.config.MinAvailableIPs {
+				candidates = append(candidates, matches[i])
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.New("no subnet matches the filters")
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].availableIPs != candidates[j].availableIPs {
+			return candidates[i].availableIPs > candidates[j].availableIPs
+		}
+		return strings.ToLower(*candidates[i].subnet.Id) < strings.ToLower(*candidates[j].subnet.Id)
+	})
+	return &candidates[0], nil
+}
+
+func (d *Datasource) matchesVirtualNetwork(vnet virtualnetworks.VirtualNetwork) bool {
+	if d.config.VirtualNetworkName != "" && (vnet.Name == nil || !strings.EqualFold(*vnet.Name, d.config.VirtualNetworkName)) {
+		return false
+	}
+	if d.config.Location != "" && (vnet.Location == nil || azclient.NormalizeLocation(*vnet.Location) != azclient.NormalizeLocation(d.config.Location)) {
+		return false
+	}
+	for k, v := range d.config.Tags {
+		if vnet.Tags == nil {
+			return false
+		}
+		if tag, ok := (*vnet.Tags)[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Datasource) matchesSubnet(subnet virtualnetworks.Subnet) bool {
+	if subnet.Id == nil || subnet.Name == nil || subnet.Properties == nil {
+		return false
+	}
+	if d.config.SubnetName != "" && !strings.EqualFold(*subnet.Name, d.config.SubnetName) {
+		return false
+	}
+	if reservedSubnetNames[strings.ToLower(*subnet.Name)] {
+		return false
+	}
+	if subnet.Properties.Delegations != nil && len(*subnet.Properties.Delegations) > 0 {
+		log.Printf("[DEBUG] Skipping subnet %q, it is delegated to a service", *subnet.Id)
+		return false
+	}
+	prefixes := addressPrefixes(subnet)
+	if len(prefixes) == 0 {
+		return false
+	}
+	if d.config.withinCIDR.IsValid() {
+		for _, p := range prefixes {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil || !contains(d.config.withinCIDR, prefix) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// contains reports whether prefix is entirely within outer.
+func contains(outer, prefix netip.Prefix) bool {
+	return prefix.Bits() >= outer.Bits() && outer.Contains(prefix.Addr())
+}
+
+func addressPrefixes(subnet virtualnetworks.Subnet) []string {
+	if subnet.Properties.AddressPrefixes != nil && len(*subnet.Properties.AddressPrefixes) > 0 {
+		return *subnet.Properties.AddressPrefixes
+	}
+	if subnet.Properties.AddressPrefix != nil {
+		return []string{*subnet.Properties.AddressPrefix}
+	}
+	return nil
+}
+
+// estimateAvailableIPs returns the number of IPv4 addresses of the subnet that
+// are neither reserved by Azure nor used by an IP configuration.
+func estimateAvailableIPs(subnet virtualnetworks.Subnet) int {
+	total := 0
+	for _, p := range addressPrefixes(subnet) {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil || !prefix.Addr().Is4() {
+			continue
+		}
+		if size := 1<<(32-prefix.Bits()) - reservedIPsPerSubnet; size > 0 {
+			total += size
+		}
+	}
+	if subnet.Properties.IPConfigurations != nil {
+		total -= len(*subnet.Properties.IPConfigurations)
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
+// availableIPsFromUsage returns the number of available IP addresses of the
+// subnet subnetID from the usage of its virtual network.
+func availableIPsFromUsage(usages []virtualnetworks.VirtualNetworkUsage, subnetID string) (int, bool) {
+	for _, u := range usages {
+		if u.Id == nil || !strings.EqualFold(*u.Id, subnetID) || u.CurrentValue == nil || u.Limit == nil {
+			continue
+		}
+		return int(*u.Limit - *u.CurrentValue), true
+	}
+	return 0, false
+}
+
+func (c *candidate) output() DatasourceOutput {
+	output := DatasourceOutput{
+		VirtualNetworkName:              c.vnetID.VirtualNetworkName,
+		VirtualNetworkResourceGroupName: c.vnetID.ResourceGroupName,
+		VirtualNetworkSubnetName:        *c.subnet.Name,
+		VirtualNetworkID:                *c.vnet.Id,
+		SubnetID:                        *c.subnet.Id,
+		AddressPrefixes:                 addressPrefixes(c.subnet),
+		AvailableIPCount:                c.availableIPs,
+		ServiceEndpoints:                []string{},
+	}
+	if c.vnet.Location != nil {
+		output.Location = azclient.NormalizeLocation(*c.vnet.Location)
+	}
+	props := c.subnet.Properties
+	if props.NetworkSecurityGroup != nil && props.NetworkSecurityGroup.Id != nil {
+		output.HasNetworkSecurityGroup = true
+		output.NetworkSecurityGroupID = *props.NetworkSecurityGroup.Id
+	}
+	output.HasNATGateway = props.NatGateway != nil && props.NatGateway.Id != nil
+	if props.ServiceEndpoints != nil {
+		for _, e := range *props.ServiceEndpoints {
+			if e.Service != nil {
+				output.ServiceEndpoints = append(output.ServiceEndpoints, *e.Service)
+			}
+		}
+	}
+	return output
+}
@@ -0,0 +1,184 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualnetwork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/network/2023-09-01/virtualnetworks"
+)
+
+func testSubnet(vnetID, name, prefix string, ipConfigurations int) virtualnetworks.Subnet {
+	id := fmt.Sprintf("%s/subnets/%s", vnetID, name)
+	configs := make([]virtualnetworks.IPConfiguration, ipConfigurations)
+	return virtualnetworks.Subnet{
+		Id:   &id,
+		Name: &name,
+		Properties: &virtualnetworks.SubnetPropertiesFormat{
+			AddressPrefix:    &prefix,
+			IPConfigurations: &configs,
+		},
+	}
+}
+
+func testVirtualNetwork(rg, name, location string, tags map[string]string, subnets ...string) virtualnetworks.VirtualNetwork {
+	id := fmt.Sprintf("/subscriptions/sub/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s", rg, name)
+	var s []virtualnetworks.Subnet
+	for i := 0; i < len(subnets); i += 2 {
+		s = append(s, testSubnet(id, subnets[i], subnets[i+1], 0))
+	}
+	return virtualnetworks.VirtualNetwork{
+		Id:         &id,
+		Name:       &name,
+		Location:   &location,
+		Tags:       &tags,
+		Properties: &virtualnetworks.VirtualNetworkPropertiesFormat{Subnets: &s},
+	}
+}
+
+func TestDatasourceSelectSubnet(t *testing.T) {
+	vnets := []virtualnetworks.VirtualNetwork{
+		testVirtualNetwork("hub-rg", "hub", "westeurope", map[string]string{"env": "shared"},
+			"GatewaySubnet", "10.0.0.0/24",
+			"shared", "10.0.1.0/26"),
+		testVirtualNetwork("build-rg", "build", "West Europe", map[string]string{"env": "build"},
+			"small", "10.1.0.0/28",
+			"large", "10.1.1.0/24",
+			"medium", "10.1.2.0/25"),
+		testVirtualNetwork("build-rg", "build-us", "eastus", map[string]string{"env": "build"},
+			"default", "10.2.0.0/16"),
+	}
+	// The large subnet of the build network is almost full.
+	usages := map[string][]virtualnetworks.VirtualNetworkUsage{
+		*vnets[1].Id: {usage(*vnets[1].Id+"/subnets/large", 240, 251)},
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+		err    bool
+	}{
+		{
+			name: "most available IPs",
+			want: "build-us/default",
+		},
+		{
+			name:   "location and tags",
+			config: Config{Location: "westeurope", Tags: map[string]string{"env": "build"}},
+			want:   "build/medium",
+		},
+		{
+			name:   "names",
+			config: Config{VirtualNetworkName: "BUILD", SubnetName: "small"},
+			want:   "build/small",
+		},
+		{
+			name:   "within CIDR",
+			config: Config{withinCIDR: netip.MustParsePrefix("10.1.0.0/24")},
+			want:   "build/small",
+		},
+		{
+			name:   "within CIDR excludes larger subnets",
+			config: Config{withinCIDR: netip.MustParsePrefix("10.2.0.0/17")},
+			err:    true,
+		},
+		{
+			name:   "reserved subnets are skipped",
+			config: Config{VirtualNetworkName: "hub"},
+			want:   "hub/shared",
+		},
+		{
+			name:   "minimum available IPs",
+			config: Config{Location: "westeurope", MinAvailableIPs: 200},
+			err:    true,
+		},
+		{
+			name:   "no match",
+			config: Config{Tags: map[string]string{"env": "prod"}},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Datasource{config: tt.config}
+			got, err := d.selectSubnet(context.Background(), vnets, func(_ context.Context, id commonids.VirtualNetworkId) ([]virtualnetworks.VirtualNetworkUsage, error) {
+				return usages[id.ID()], nil
+			})
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", *got.subnet.Id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if name := got.vnetID.VirtualNetworkName + "/" + *got.subnet.Name; name != tt.want {
+				t.Errorf("got %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
+
+func usage(subnetID string, current, limit float64) virtualnetworks.VirtualNetworkUsage {
+	return virtualnetworks.VirtualNetworkUsage{Id: &subnetID, CurrentValue: &current, Limit: &limit}
+}
+
+func TestDatasourceSelectSubnetUsageFallback(t *testing.T) {
+	vnet := testVirtualNetwork("build-rg", "build", "westeurope", nil)
+	subnet := testSubnet(*vnet.Id, "default", "10.1.0.0/28", 3)
+	delegated := testSubnet(*vnet.Id, "delegated", "10.1.1.0/24", 0)
+	delegated.Properties.Delegations = &[]virtualnetworks.Delegation{{}}
+	vnet.Properties.Subnets = &[]virtualnetworks.Subnet{subnet, delegated}
+
+	d := Datasource{}
+	got, err := d.selectSubnet(context.Background(), []virtualnetworks.VirtualNetwork{vnet}, func(context.Context, commonids.VirtualNetworkId) ([]virtualnetworks.VirtualNetworkUsage, error) {
+		return nil, errors.New("forbidden")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if *got.subnet.Name != "default" || got.availableIPs != 16-5-3 {
+		t.Errorf("got %q with %d available IPs, want %q with %d", *got.subnet.Name, got.availableIPs, "default", 8)
+	}
+}
+
+func TestCandidateOutput(t *testing.T) {
+	vnet := testVirtualNetwork("build-rg", "build", "West Europe", nil)
+	subnet := testSubnet(*vnet.Id, "default", "10.1.0.0/24", 0)
+	nsg := "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Network/networkSecurityGroups/nsg"
+	nat := "/subscriptions/sub/resourceGroups/build-rg/providers/Microsoft.Network/natGateways/nat"
+	storage := "Microsoft.Storage"
+	subnet.Properties.NetworkSecurityGroup = &virtualnetworks.NetworkSecurityGroup{Id: &nsg}
+	subnet.Properties.NatGateway = &virtualnetworks.SubResource{Id: &nat}
+	subnet.Properties.ServiceEndpoints = &[]virtualnetworks.ServiceEndpointPropertiesFormat{{Service: &storage}}
+	vnetID, _ := commonids.ParseVirtualNetworkID(*vnet.Id)
+
+	c := candidate{vnet: vnet, vnetID: vnetID, subnet: subnet, availableIPs: 251}
+	want := DatasourceOutput{
+		VirtualNetworkName:              "build",
+		VirtualNetworkResourceGroupName: "build-rg",
+		VirtualNetworkSubnetName:        "default",
+		VirtualNetworkID:                *vnet.Id,
+		SubnetID:                        *subnet.Id,
+		Location:                        "westeurope",
+		AddressPrefixes:                 []string{"10.1.0.0/24"},
+		AvailableIPCount:                251,
+		NetworkSecurityGroupID:          nsg,
+		HasNetworkSecurityGroup:         true,
+		HasNATGateway:                   true,
+		ServiceEndpoints:                []string{"Microsoft.Storage"},
+	}
+	if got := c.output(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -10,7 +10,16 @@ import (
 	azurearm "github.com/hashicorp/packer-plugin-azure/builder/azure/arm"
 	azurechroot "github.com/hashicorp/packer-plugin-azure/builder/azure/chroot"
 	azuredtl "github.com/hashicorp/packer-plugin-azure/builder/azure/dtl"
+	"github.com/hashicorp/packer-plugin-azure/datasource/appconfiguration"
+	"github.com/hashicorp/packer-plugin-azure/datasource/imds"
+	"github.com/hashicorp/packer-plugin-azure/datasource/keyvaultcertificate"
 	"github.com/hashicorp/packer-plugin-azure/datasource/keyvaultsecret"
+	"github.com/hashicorp/packer-plugin-azure/datasource/keyvaultsecrets"
+	"github.com/hashicorp/packer-plugin-azure/datasource/managedimage"
+	"github.com/hashicorp/packer-plugin-azure/datasource/platformimage"
+	"github.com/hashicorp/packer-plugin-azure/datasource/sharedimageversion"
+	"github.com/hashicorp/packer-plugin-azure/datasource/storageblob"
+	"github.com/hashicorp/packer-plugin-azure/datasource/virtualnetwork"
 	azuredtlartifact "github.com/hashicorp/packer-plugin-azure/provisioner/azure-dtlartifact"
 	"github.com/hashicorp/packer-plugin-azure/version"
 
@@ -24,6 +33,15 @@ func main() {
 	pps.RegisterBuilder("dtl", new(azuredtl.Builder))
 	pps.RegisterProvisioner("dtlartifact", new(azuredtlartifact.Provisioner))
 	pps.RegisterDatasource("keyvaultsecret", new(keyvaultsecret.Datasource))
+	pps.RegisterDatasource("keyvaultsecrets", new(keyvaultsecrets.Datasource))
+	pps.RegisterDatasource("keyvaultcertificate", new(keyvaultcertificate.Datasource))
+	pps.RegisterDatasource("sharedimageversion", new(sharedimageversion.Datasource))
+	pps.RegisterDatasource("platformimage", new(platformimage.Datasource))
+	pps.RegisterDatasource("managedimage", new(managedimage.Datasource))
+	pps.RegisterDatasource("virtualnetwork", new(virtualnetwork.Datasource))
+	pps.RegisterDatasource("imds", new(imds.Datasource))
+	pps.RegisterDatasource("storageblob", new(storageblob.Datasource))
+	pps.RegisterDatasource("appconfiguration", new(appconfiguration.Datasource))
 	pps.SetVersion(version.AzurePluginVersion)
 	err := pps.Run()
 	if err != nil {
@@ -124,6 +124,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packersdk.Ui, comm packe
 	if err != nil {
 		return err
 	}
+	defer p.config.ClientConfig.Close()
 
 	// Pass in relevant auth information for hashicorp/go-azure-sdk
 	authOptions := client.AzureAuthOptions{
@@ -134,6 +135,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packersdk.Ui, comm packe
 		ClientCertPath: p.config.ClientConfig.ClientCertPath,
 		TenantID:       p.config.ClientConfig.TenantID,
 		SubscriptionID: p.config.ClientConfig.SubscriptionID,
+		Transport:      p.config.ClientConfig.Transport(),
 	}
 	ui.Say("Creating Azure DevTestLab (DTL) client ...")
 	azureClient, err := dtlBuilder.NewAzureClient(